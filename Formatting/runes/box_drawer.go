@@ -168,6 +168,19 @@ func (bs BoxStyle) SideBorder() rune {
 	return side_border
 }
 
+// LeftTee gets the junction between the side border and a horizontal line
+// going to the right. Like the corners, it only depends on IsHeavy.
+//
+// Returns:
+//   - rune: The left tee.
+func (bs BoxStyle) LeftTee() rune {
+	if bs.IsHeavy {
+		return '┣'
+	}
+
+	return '├'
+}
+
 // make_side_padding is a helper function to make side padding.
 //
 // Parameters:
@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"cmp"
+	"slices"
+
+	gch "github.com/PlayerR9/go-commons/helpers"
+)
+
+// beam_item is a branch kept in the beam.
+type beam_item[T any, S any] struct {
+	// history is the history of the branch.
+	history *History[T]
+
+	// subject is the subject of the branch, aligned with the history.
+	subject S
+}
+
+// beam_candidate is a possible extension of a branch of the beam.
+type beam_candidate[T any] struct {
+	// parent is the index of the extended branch.
+	parent int
+
+	// history is the extended history.
+	history *History[T]
+
+	// weight is the score of the extended history.
+	weight float64
+}
+
+// Beam explores the states of a subject like Subject but, at every depth,
+// only keeps the width branches whose partial history has the highest score.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - width: The maximum number of branches kept at each depth.
+//   - scorer: The function that scores partial histories. Histories for which
+//     it returns false are discarded.
+//
+// Returns:
+//   - []*gch.WeightedElement[S]: The subjects that were successfully completed
+//     or ran out of events, weighted by the score of their history, in
+//     descending weight order.
+//     Subjects with the same weight are ordered by depth and then by the order
+//     in which their events were proposed.
+//
+// If the subject implements the Snapshotter interface, sibling branches are
+// resumed from a snapshot of their parent; otherwise, they are replayed
+// through Align. Nil is returned if width is less than 1 or scorer is nil.
+//
+// If the function 'init_fn' is nil, it defaults to 'var subject S'.
+func Beam[T any, S interface {
+	Align(history *History[T]) bool
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](init_fn func() S, width int, scorer gch.WeightFunc[*History[T]]) []*gch.WeightedElement[S] {
+	if width < 1 || scorer == nil {
+		return nil
+	}
+
+	if init_fn == nil {
+		init_fn = func() S {
+			return *new(S)
+		}
+	}
+
+	beam := []*beam_item[T, S]{
+		{
+			history: &History[T]{},
+			subject: init_fn(),
+		},
+	}
+
+	var finals []*gch.WeightedElement[S]
+
+	for len(beam) > 0 {
+		var candidates []*beam_candidate[T]
+
+		for i, item := range beam {
+			events := item.subject.DetermineNextEvents()
+			if item.subject.HasError() {
+				continue
+			}
+
+			// A subject that runs out of events is completed, as in Subject.
+			if len(events) == 0 {
+				weight, ok := scorer(item.history)
+				if ok {
+					finals = append(finals, gch.NewWeightedElement(item.subject, weight))
+				}
+
+				continue
+			}
+
+			for _, event := range events {
+				h := item.history.Copy()
+				h.AddEvent(event)
+
+				weight, ok := scorer(h)
+				if !ok {
+					continue
+				}
+
+				candidates = append(candidates, &beam_candidate[T]{
+					parent:  i,
+					history: h,
+					weight:  weight,
+				})
+			}
+		}
+
+		slices.SortStableFunc(candidates, func(a, b *beam_candidate[T]) int {
+			return cmp.Compare(b.weight, a.weight)
+		})
+
+		if len(candidates) > width {
+			candidates = candidates[:width]
+		}
+
+		next, done := expand_beam(beam, candidates, init_fn)
+
+		finals = append(finals, done...)
+		beam = next
+	}
+
+	slices.SortStableFunc(finals, func(a, b *gch.WeightedElement[S]) int {
+		return cmp.Compare(b.Weight(), a.Weight())
+	})
+
+	return finals
+}
+
+// expand_beam applies the kept candidates to the subjects of their parents.
+//
+// Parameters:
+//   - beam: The branches of the previous depth.
+//   - candidates: The kept candidates.
+//   - init_fn: A function that returns a new instance of the subject.
+//
+// Returns:
+//   - []*beam_item[T, S]: The branches of the next depth.
+//   - []*gch.WeightedElement[S]: The subjects that were completed.
+func expand_beam[T any, S interface {
+	Align(history *History[T]) bool
+	ApplyEvent(event T) bool
+	HasError() bool
+}](beam []*beam_item[T, S], candidates []*beam_candidate[T], init_fn func() S) ([]*beam_item[T, S], []*gch.WeightedElement[S]) {
+	remaining := make([]int, len(beam))
+	snapshots := make([]any, len(beam))
+
+	for _, c := range candidates {
+		remaining[c.parent]++
+	}
+
+	for i, count := range remaining {
+		if count < 2 {
+			continue
+		}
+
+		s, ok := any(beam[i].subject).(Snapshotter)
+		if ok {
+			snapshots[i] = s.Snapshot()
+		}
+	}
+
+	var next []*beam_item[T, S]
+	var done []*gch.WeightedElement[S]
+
+	for _, c := range candidates {
+		parent := beam[c.parent]
+		remaining[c.parent]--
+
+		var sbj S
+
+		if remaining[c.parent] == 0 {
+			// The last child takes over the subject of its parent.
+			sbj = parent.subject
+		} else {
+			var ok bool
+
+			sbj, ok = fork(init_fn, parent.history, snapshots[c.parent])
+			if !ok {
+				continue
+			}
+		}
+
+		c.history.current = len(c.history.timeline)
+
+		is_done := sbj.ApplyEvent(c.history.timeline[len(c.history.timeline)-1])
+		if sbj.HasError() {
+			continue
+		}
+
+		if is_done {
+			done = append(done, gch.NewWeightedElement(sbj, c.weight))
+		} else {
+			next = append(next, &beam_item[T, S]{
+				history: c.history,
+				subject: sbj,
+			})
+		}
+	}
+
+	return next, done
+}
@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"testing"
+)
+
+func TestBeam(t *testing.T) {
+	var n int
+
+	scorer := func(h *History[int]) (float64, bool) {
+		var sum float64
+
+		for _, e := range h.timeline {
+			sum += float64(e)
+		}
+
+		return sum, true
+	}
+
+	results := Beam(func() *MockSnapshotSubject {
+		return &MockSnapshotSubject{MockSubject{applied: &n}}
+	}, 2, scorer)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d instead", len(results))
+	}
+
+	expected := []string{"111", "110"}
+
+	for i, res := range results {
+		sbj, _ := res.Data()
+
+		if sbj.String() != expected[i] {
+			t.Errorf("expected %q, got %q instead", expected[i], sbj.String())
+		}
+
+		if res.Weight() != float64(3-i) {
+			t.Errorf("expected weight %d, got %f instead", 3-i, res.Weight())
+		}
+	}
+}
+
+// MockExhaust is a subject that never reports being done but runs out of
+// events once its sequence has two events.
+type MockExhaust struct {
+	// seq is the sequence built so far.
+	seq []int
+}
+
+func (me *MockExhaust) Align(history *History[int]) bool {
+	for event := range history.Event() {
+		_ = me.ApplyEvent(event)
+	}
+
+	return true
+}
+
+func (me *MockExhaust) ApplyEvent(event int) bool {
+	me.seq = append(me.seq, event)
+	return false
+}
+
+func (me *MockExhaust) DetermineNextEvents() []int {
+	if len(me.seq) == 2 {
+		return nil
+	}
+
+	return []int{1, 2}
+}
+
+func (me *MockExhaust) HasError() bool {
+	return false
+}
+
+func TestBeamExhaust(t *testing.T) {
+	scorer := func(h *History[int]) (float64, bool) {
+		var sum float64
+
+		for _, e := range h.timeline {
+			sum += float64(e)
+		}
+
+		return sum, true
+	}
+
+	results := Beam(func() *MockExhaust {
+		return &MockExhaust{}
+	}, 2, scorer)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d instead", len(results))
+	}
+
+	for i, expected := range []float64{4, 3} {
+		if results[i].Weight() != expected {
+			t.Errorf("expected weight %f, got %f instead", expected, results[i].Weight())
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+const (
+	// HistoryFormatVersion is the version of the format used to serialize
+	// histories. It is written in both the JSON and the binary formats.
+	HistoryFormatVersion uint8 = 1
+)
+
+var (
+	// binary_magic is the magic number at the start of a binary history.
+	binary_magic []byte
+)
+
+func init() {
+	binary_magic = []byte("GCHB")
+}
+
+// Codec encodes and decodes the events of a history.
+type Codec[T any] interface {
+	// EncodeEvent encodes an event.
+	//
+	// Parameters:
+	//   - event: The event to encode.
+	//
+	// Returns:
+	//   - []byte: The encoded event. When used with the JSON format, it must be
+	//     valid JSON.
+	//   - error: An error if the event could not be encoded.
+	EncodeEvent(event T) ([]byte, error)
+
+	// DecodeEvent decodes an event.
+	//
+	// Parameters:
+	//   - data: The data to decode.
+	//
+	// Returns:
+	//   - T: The decoded event.
+	//   - error: An error if the event could not be decoded.
+	DecodeEvent(data []byte) (T, error)
+}
+
+// JSONCodec is a codec that encodes events with the encoding/json package.
+type JSONCodec[T any] struct{}
+
+// EncodeEvent implements the Codec interface.
+func (JSONCodec[T]) EncodeEvent(event T) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// DecodeEvent implements the Codec interface.
+func (JSONCodec[T]) DecodeEvent(data []byte) (T, error) {
+	var event T
+
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
+// ErrUnsupportedVersion is an error that is returned when a serialized history
+// was written with a version of the format that is not supported.
+type ErrUnsupportedVersion struct {
+	// Version is the version that was found.
+	Version uint8
+}
+
+// Error implements the error interface.
+//
+// Message: "unsupported history format version <version>"
+func (e ErrUnsupportedVersion) Error() string {
+	return "unsupported history format version " + strconv.Itoa(int(e.Version))
+}
+
+// NewErrUnsupportedVersion creates a new ErrUnsupportedVersion error.
+//
+// Parameters:
+//   - version: The version that was found.
+//
+// Returns:
+//   - *ErrUnsupportedVersion: The new error. Never returns nil.
+func NewErrUnsupportedVersion(version uint8) *ErrUnsupportedVersion {
+	return &ErrUnsupportedVersion{
+		Version: version,
+	}
+}
+
+// json_history is the JSON representation of a history.
+type json_history struct {
+	// Version is the version of the format.
+	Version uint8 `json:"version"`
+
+	// Current is the current index in the timeline.
+	Current int `json:"current"`
+
+	// Timeline is the list of encoded events.
+	Timeline []json.RawMessage `json:"timeline"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+//
+// Events are encoded with the JSONCodec.
+func (h History[T]) MarshalJSON() ([]byte, error) {
+	return EncodeJSON(&h, JSONCodec[T]{})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+//
+// Events are decoded with the JSONCodec.
+func (h *History[T]) UnmarshalJSON(data []byte) error {
+	if h == nil {
+		return gers.NewErrNilParameter("History")
+	}
+
+	tmp, err := DecodeJSON(data, JSONCodec[T]{})
+	if err != nil {
+		return err
+	}
+
+	*h = *tmp
+
+	return nil
+}
+
+// EncodeJSON encodes a history as JSON.
+//
+// Parameters:
+//   - history: The history to encode. A nil history is encoded as an empty one.
+//   - codec: The codec of the events.
+//
+// Returns:
+//   - []byte: The JSON data.
+//   - error: An error if the history could not be encoded.
+//
+// Errors:
+//   - *errors.Err: If the codec is nil or does not produce valid JSON.
+//   - any error returned by the codec.
+func EncodeJSON[T any](history *History[T], codec Codec[T]) ([]byte, error) {
+	if codec == nil {
+		return nil, gers.NewErrNilParameter("codec")
+	}
+
+	if history == nil {
+		history = &History[T]{}
+	}
+
+	jh := json_history{
+		Version:  HistoryFormatVersion,
+		Current:  history.current,
+		Timeline: make([]json.RawMessage, 0, len(history.timeline)),
+	}
+
+	for _, event := range history.timeline {
+		data, err := codec.EncodeEvent(event)
+		if err != nil {
+			return nil, err
+		} else if !json.Valid(data) {
+			return nil, gerr.New(gers.OperationFail, "codec did not produce valid JSON")
+		}
+
+		jh.Timeline = append(jh.Timeline, data)
+	}
+
+	return json.Marshal(jh)
+}
+
+// DecodeJSON decodes a history from JSON.
+//
+// Parameters:
+//   - data: The JSON data.
+//   - codec: The codec of the events.
+//
+// Returns:
+//   - *History[T]: The decoded history. Nil if an error occurred.
+//   - error: An error if the history could not be decoded.
+//
+// Errors:
+//   - *errors.Err: If the codec is nil or the cursor is out of bounds.
+//   - *ErrUnsupportedVersion: If the version of the format is not supported.
+//   - any error returned by the codec or by the encoding/json package.
+func DecodeJSON[T any](data []byte, codec Codec[T]) (*History[T], error) {
+	if codec == nil {
+		return nil, gers.NewErrNilParameter("codec")
+	}
+
+	var jh json_history
+
+	err := json.Unmarshal(data, &jh)
+	if err != nil {
+		return nil, err
+	} else if jh.Version != HistoryFormatVersion {
+		return nil, NewErrUnsupportedVersion(jh.Version)
+	}
+
+	timeline := make([]T, 0, len(jh.Timeline))
+
+	for _, raw := range jh.Timeline {
+		event, err := codec.DecodeEvent(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		timeline = append(timeline, event)
+	}
+
+	if jh.Current < 0 || jh.Current > len(timeline) {
+		return nil, gerr.New(gers.BadParameter, "cursor is out of bounds")
+	}
+
+	return &History[T]{
+		timeline: timeline,
+		current:  jh.Current,
+	}, nil
+}
+
+// EncodeBinary encodes a history in a compact binary format.
+//
+// The format is the magic number "GCHB", the version byte, the cursor and the
+// number of events as uvarints, followed by every event prefixed by its
+// length as an uvarint.
+//
+// Parameters:
+//   - history: The history to encode. A nil history is encoded as an empty one.
+//   - codec: The codec of the events.
+//
+// Returns:
+//   - []byte: The binary data.
+//   - error: An error if the history could not be encoded.
+//
+// Errors:
+//   - *errors.Err: If the codec is nil.
+//   - any error returned by the codec.
+func EncodeBinary[T any](history *History[T], codec Codec[T]) ([]byte, error) {
+	if codec == nil {
+		return nil, gers.NewErrNilParameter("codec")
+	}
+
+	if history == nil {
+		history = &History[T]{}
+	}
+
+	data := make([]byte, 0, len(binary_magic)+1+2*binary.MaxVarintLen64)
+
+	data = append(data, binary_magic...)
+	data = append(data, HistoryFormatVersion)
+	data = binary.AppendUvarint(data, uint64(history.current))
+	data = binary.AppendUvarint(data, uint64(len(history.timeline)))
+
+	for _, event := range history.timeline {
+		tmp, err := codec.EncodeEvent(event)
+		if err != nil {
+			return nil, err
+		}
+
+		data = binary.AppendUvarint(data, uint64(len(tmp)))
+		data = append(data, tmp...)
+	}
+
+	return data, nil
+}
+
+// read_uvarint reads an uvarint from the data.
+//
+// Parameters:
+//   - data: The data to read from.
+//   - what: The name of the value, used in error messages.
+//
+// Returns:
+//   - int: The value read.
+//   - []byte: The remaining data.
+//   - error: An error if the value could not be read.
+func read_uvarint(data []byte, what string) (int, []byte, error) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 || v > math.MaxInt32 {
+		return 0, nil, errors.New("malformed " + what)
+	}
+
+	return int(v), data[n:], nil
+}
+
+// DecodeBinary decodes a history from the binary format written by
+// EncodeBinary.
+//
+// Parameters:
+//   - data: The binary data.
+//   - codec: The codec of the events.
+//
+// Returns:
+//   - *History[T]: The decoded history. Nil if an error occurred.
+//   - error: An error if the history could not be decoded.
+//
+// Errors:
+//   - *errors.Err: If the codec is nil or the cursor is out of bounds.
+//   - *ErrUnsupportedVersion: If the version of the format is not supported.
+//   - any other error if the data is malformed or the codec fails.
+func DecodeBinary[T any](data []byte, codec Codec[T]) (*History[T], error) {
+	if codec == nil {
+		return nil, gers.NewErrNilParameter("codec")
+	}
+
+	if !bytes.HasPrefix(data, binary_magic) {
+		return nil, errors.New("not a binary history")
+	}
+
+	data = data[len(binary_magic):]
+
+	if len(data) == 0 {
+		return nil, errors.New("missing version")
+	} else if data[0] != HistoryFormatVersion {
+		return nil, NewErrUnsupportedVersion(data[0])
+	}
+
+	data = data[1:]
+
+	current, data, err := read_uvarint(data, "cursor")
+	if err != nil {
+		return nil, err
+	}
+
+	count, data, err := read_uvarint(data, "event count")
+	if err != nil {
+		return nil, err
+	} else if current > count {
+		return nil, gerr.New(gers.BadParameter, "cursor is out of bounds")
+	}
+
+	timeline := make([]T, 0, min(count, len(data)))
+
+	for i := 0; i < count; i++ {
+		var size int
+
+		size, data, err = read_uvarint(data, "event length")
+		if err != nil {
+			return nil, err
+		} else if size > len(data) {
+			return nil, errors.New("truncated event")
+		}
+
+		event, err := codec.DecodeEvent(data[:size])
+		if err != nil {
+			return nil, err
+		}
+
+		timeline = append(timeline, event)
+		data = data[size:]
+	}
+
+	if len(data) > 0 {
+		return nil, errors.New("trailing data after the last event")
+	}
+
+	return &History[T]{
+		timeline: timeline,
+		current:  current,
+	}, nil
+}
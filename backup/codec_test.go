@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestHistoryJSON(t *testing.T) {
+	h := &History[string]{}
+	h.AddEvent("a")
+	h.AddEvent("b")
+	h.current = 1
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	var got History[string]
+
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	if !slices.Equal(got.timeline, h.timeline) || got.current != h.current {
+		t.Errorf("expected %v, got %v instead", *h, got)
+	}
+
+	_, err = DecodeJSON([]byte(`{"version":99,"current":0,"timeline":[]}`), JSONCodec[string]{})
+
+	var ver *ErrUnsupportedVersion
+	if !errors.As(err, &ver) || ver.Version != 99 {
+		t.Errorf("expected unsupported version 99, got %v instead", err)
+	}
+}
+
+func TestHistoryBinary(t *testing.T) {
+	h := &History[int]{}
+	for i := 0; i < 300; i++ {
+		h.AddEvent(i)
+	}
+
+	data, err := EncodeBinary(h, JSONCodec[int]{})
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	got, err := DecodeBinary(data, JSONCodec[int]{})
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	if !slices.Equal(got.timeline, h.timeline) || got.current != h.current {
+		t.Errorf("expected %v, got %v instead", h.timeline, got.timeline)
+	}
+
+	_, err = DecodeBinary(data[:len(data)-1], JSONCodec[int]{})
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+}
@@ -12,6 +12,10 @@ type pairing[T any, S any] struct {
 
 	// Subject is the subject of the pairing.
 	Subject S
+
+	// Node is the node of the explored tree that the history leads to. Nil
+	// if the exploration is not traced.
+	Node *TraceNode[T]
 }
 
 // new_pairing returns a new pairing of a history and a subject.
@@ -19,10 +23,11 @@ type pairing[T any, S any] struct {
 // Parameters:
 //   - history: The history of the subject.
 //   - subject: The subject of the pairing.
+//   - node: The node of the explored tree that the history leads to.
 //
 // Returns:
 //   - *pairing[T, S]: The pairing. Never returns nil.
-func new_pairing[T any, S any](history *History[T], subject S) *pairing[T, S] {
+func new_pairing[T any, S any](history *History[T], subject S, node *TraceNode[T]) *pairing[T, S] {
 	if history == nil {
 		history = &History[T]{}
 	}
@@ -30,6 +35,7 @@ func new_pairing[T any, S any](history *History[T], subject S) *pairing[T, S] {
 	return &pairing[T, S]{
 		History: history,
 		Subject: subject,
+		Node:    node,
 	}
 }
 
@@ -88,10 +94,6 @@ func nexts[T any, S interface {
 		new_histories = append(new_histories, h)
 	}
 
-	for i := 1; i < len(new_histories); i++ {
-		new_histories[i].Restart()
-	}
-
 	return new_histories, true
 }
 
@@ -100,16 +102,19 @@ func nexts[T any, S interface {
 // Parameters:
 //   - history: The history of the subject.
 //   - subject: The subject of the pairing.
+//   - node: The node of the explored tree that the history leads to. Its
+//     descendants are recorded as they are found and the last one is marked
+//     as valid or invalid.
 //
 // Returns:
-//   - []*History[T]: The next possible histories.
+//   - []*checkpoint[T]: The alternative histories found along the way.
 //   - bool: True if the subject is done, false otherwise.
 func execute_one[T any, S interface {
 	ApplyEvent(event T) bool
 	DetermineNextEvents() []T
 	HasError() bool
-}](history *History[T], subject S) ([]*History[T], bool) {
-	var possible []*History[T]
+}](history *History[T], subject S, node *TraceNode[T]) ([]*checkpoint[T], bool) {
+	var possible []*checkpoint[T]
 
 	is_done := false
 
@@ -119,11 +124,15 @@ func execute_one[T any, S interface {
 			break
 		}
 
+		history = tmp[0]
+		next := node.add_child(history.timeline[len(history.timeline)-1], NodeExplored)
+
 		if len(tmp) > 1 {
-			possible = append(possible, tmp[1:]...)
+			checkpoints := new_checkpoints(tmp[1:], any(subject), node)
+			possible = append(possible, checkpoints...)
 		}
 
-		history = tmp[0]
+		node = next
 
 		is_done = advance(history, subject)
 		if subject.HasError() {
@@ -131,6 +140,12 @@ func execute_one[T any, S interface {
 		}
 	}
 
+	if is_done {
+		node.set_status(NodeValid)
+	} else {
+		node.set_status(NodeInvalid)
+	}
+
 	return possible, is_done
 }
 
@@ -146,6 +161,11 @@ func execute_one[T any, S interface {
 //	    DetermineNextEvents() ([]T, error)
 //	}
 //
+// If the subject also implements the Snapshotter interface, alternative
+// branches are resumed from a snapshot taken at the branch point instead of
+// being replayed from the start of the history. Subjects that do not
+// implement it are replayed through Align.
+//
 // The function init_fn must return a new instance of the subject.
 //
 // The function returns a sequence of all possible states of the subject. The
@@ -165,6 +185,27 @@ func Subject[T any, S interface {
 	DetermineNextEvents() []T
 	HasError() bool
 }](init_fn func() S) iter.Seq[S] {
+	return TracedSubject(init_fn, nil)
+}
+
+// TracedSubject is like Subject but records the explored tree into the given
+// tracer. Every iteration over the sequence starts a new tree.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - tracer: The tracer to record into. If nil, nothing is recorded.
+//
+// Returns:
+//   - iter.Seq[S]: A sequence of all possible states of the subject.
+//
+// Branches that are still pending when the caller stops iterating are left
+// as pruned in the tree.
+func TracedSubject[T any, S interface {
+	Align(history *History[T]) bool
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](init_fn func() S, tracer *Tracer[T]) iter.Seq[S] {
 	if init_fn == nil {
 		init_fn = func() S {
 			return *new(S)
@@ -173,7 +214,7 @@ func Subject[T any, S interface {
 
 	fn := func(yield func(S) bool) {
 		sbj := init_fn()
-		pair := new_pairing[T](nil, sbj)
+		pair := new_pairing[T](nil, sbj, tracer.reset())
 
 		var invalid_subjects []S
 
@@ -185,20 +226,24 @@ func Subject[T any, S interface {
 
 			ok := top.Subject.Align(top.History)
 			if !ok {
+				top.Node.set_status(NodeInvalid)
 				invalid_subjects = append(invalid_subjects, top.Subject)
 
 				continue
 			}
 
-			possible, ok := execute_one(top.History, top.Subject)
+			top.Node.set_status(NodeExplored)
+
+			possible, ok := execute_one(top.History, top.Subject, top.Node)
 
 			if len(possible) > 0 {
 				pairs := make([]*pairing[T, S], 0, len(possible))
 
-				for _, path := range possible {
+				for _, cp := range possible {
 					sbj := init_fn()
+					cp.resume(any(sbj))
 
-					pair := new_pairing(path, sbj)
+					pair := new_pairing(cp.history, sbj, cp.node)
 					pairs = append(pairs, pair)
 				}
 
@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// MockSubject is a subject that builds every binary sequence of a given length.
+type MockSubject struct {
+	// seq is the sequence built so far.
+	seq []int
+
+	// applied counts the number of applied events.
+	applied *int
+}
+
+func (ms *MockSubject) Align(history *History[int]) bool {
+	for event := range history.Event() {
+		_ = ms.ApplyEvent(event)
+	}
+
+	return true
+}
+
+func (ms *MockSubject) ApplyEvent(event int) bool {
+	*ms.applied++
+	ms.seq = append(ms.seq, event)
+
+	return len(ms.seq) == 3
+}
+
+func (ms *MockSubject) DetermineNextEvents() []int {
+	if len(ms.seq) == 3 {
+		return nil
+	}
+
+	return []int{0, 1}
+}
+
+func (ms *MockSubject) HasError() bool {
+	return false
+}
+
+func (ms MockSubject) String() string {
+	var builder strings.Builder
+
+	for _, e := range ms.seq {
+		builder.WriteByte(byte('0' + e))
+	}
+
+	return builder.String()
+}
+
+// MockSnapshotSubject is a MockSubject that implements the Snapshotter interface.
+type MockSnapshotSubject struct {
+	MockSubject
+}
+
+func (ms *MockSnapshotSubject) Snapshot() any {
+	return slices.Clone(ms.seq)
+}
+
+func (ms *MockSnapshotSubject) Restore(snapshot any) bool {
+	seq, ok := snapshot.([]int)
+	if !ok {
+		return false
+	}
+
+	ms.seq = slices.Clone(seq)
+
+	return true
+}
+
+func collect[S interface{ String() string }](seq func(func(S) bool)) []string {
+	var results []string
+
+	for sbj := range seq {
+		results = append(results, sbj.String())
+	}
+
+	slices.Sort(results)
+
+	return results
+}
+
+func TestSubjectSnapshot(t *testing.T) {
+	var replayed, restored int
+
+	expected := collect(Subject[int](func() *MockSubject {
+		return &MockSubject{applied: &replayed}
+	}))
+
+	got := collect(Subject[int](func() *MockSnapshotSubject {
+		return &MockSnapshotSubject{MockSubject{applied: &restored}}
+	}))
+
+	if !slices.Equal(expected, got) {
+		t.Errorf("expected %v, got %v instead", expected, got)
+	}
+
+	if restored >= replayed {
+		t.Errorf("expected fewer than %d applied events, got %d instead", replayed, restored)
+	}
+}
@@ -0,0 +1,641 @@
+package backup
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+const (
+	// record_event is the kind of the records that hold an event.
+	record_event byte = 1
+
+	// record_snapshot is the kind of the records that hold a snapshot of the
+	// subject.
+	record_snapshot byte = 2
+
+	// record_header_size is the size of the length and kind of a record.
+	record_header_size int = 5
+
+	// record_crc_size is the size of the checksum of a record.
+	record_crc_size int = 4
+
+	// max_record_size is the maximum size of the payload of a record.
+	max_record_size int = 1 << 30
+)
+
+var (
+	// log_magic is the magic number at the start of an event log.
+	log_magic []byte
+
+	// crc_table is the table of the checksums of the records.
+	crc_table *crc32.Table
+)
+
+func init() {
+	log_magic = []byte("GCEL")
+	crc_table = crc32.MakeTable(crc32.Castagnoli)
+}
+
+// ErrSnapshot is an error that is returned when the periodic snapshot that
+// follows an event could not be written. The event itself was applied and
+// recorded, so it must not be applied again.
+type ErrSnapshot struct {
+	// Reason is the reason the snapshot could not be written.
+	Reason error
+}
+
+// Error implements the error interface.
+//
+// Message: "event was recorded but its snapshot failed: <reason>"
+func (e ErrSnapshot) Error() string {
+	msg := "event was recorded but its snapshot failed"
+
+	if e.Reason != nil {
+		msg += ": " + e.Reason.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the reason the snapshot could not be written.
+//
+// Returns:
+//   - error: The reason.
+func (e ErrSnapshot) Unwrap() error {
+	return e.Reason
+}
+
+// NewErrSnapshot creates a new ErrSnapshot error.
+//
+// Parameters:
+//   - reason: The reason the snapshot could not be written.
+//
+// Returns:
+//   - *ErrSnapshot: The new error. Never returns nil.
+func NewErrSnapshot(reason error) *ErrSnapshot {
+	return &ErrSnapshot{
+		Reason: reason,
+	}
+}
+
+// EventLogOption is a type that defines an option of an EventLog.
+//
+// Parameters:
+//   - s: The settings to modify.
+type EventLogOption func(s *event_log_settings)
+
+// event_log_settings is the settings of an EventLog.
+type event_log_settings struct {
+	// snapshot_every is the number of events between two snapshots. 0 to
+	// disable snapshots.
+	snapshot_every int
+
+	// no_sync is true if records are not synced to disk after being written.
+	no_sync bool
+}
+
+// WithSnapshotEvery makes the log write a snapshot of the subject every n
+// events. Snapshots are only written for subjects that implement the
+// encoding.BinaryMarshaler interface. By default, no snapshot is written.
+//
+// Parameters:
+//   - n: The number of events between two snapshots. Non-positive values
+//     disable snapshots.
+//
+// Returns:
+//   - EventLogOption: The option.
+func WithSnapshotEvery(n int) EventLogOption {
+	return func(s *event_log_settings) {
+		s.snapshot_every = max(n, 0)
+	}
+}
+
+// WithoutSync makes the log skip the fsync after every record. Records that
+// were not synced may be lost or torn on a crash. By default, every record is
+// synced.
+//
+// Returns:
+//   - EventLogOption: The option.
+func WithoutSync() EventLogOption {
+	return func(s *event_log_settings) {
+		s.no_sync = true
+	}
+}
+
+// EventLog persists the history of a subject as an append-only log of
+// length-prefixed, checksummed records on disk.
+//
+// Every record is laid out as the length of its payload (4 bytes, little
+// endian), its kind (1 byte), its payload and the CRC-32C of the kind and the
+// payload (4 bytes, little endian).
+type EventLog[T any, S interface {
+	ApplyEvent(event T) bool
+	HasError() bool
+}] struct {
+	// file is the file of the log.
+	file *os.File
+
+	// codec is the codec of the events.
+	codec Codec[T]
+
+	// init_fn creates a new subject.
+	init_fn func() S
+
+	// subject is the subject the events are applied to.
+	subject S
+
+	// history is the history of the subject.
+	history *History[T]
+
+	// since_snapshot is the number of events since the last snapshot.
+	since_snapshot int
+
+	// state is the state of the last snapshot. Nil if there is none.
+	state []byte
+
+	// state_at is the number of events covered by the last snapshot.
+	state_at int
+
+	// truncated is the number of bytes of torn tail removed when opening.
+	truncated int64
+
+	// settings are the settings of the log.
+	settings event_log_settings
+}
+
+// OpenEventLog opens the event log at the given path, creating it if needed.
+// The events of the log are replayed into the subject; if the subject
+// implements the encoding.BinaryUnmarshaler interface, it is restored from
+// the last snapshot and only the events that follow it are replayed.
+//
+// A torn or corrupted tail, as left by a crash in the middle of a write, is
+// truncated.
+//
+// Parameters:
+//   - path: The path of the log.
+//   - codec: The codec of the events.
+//   - init_fn: A function that returns a new instance of the subject. It is
+//     also used to rebuild the subject when an event is rejected.
+//   - opts: The options of the log.
+//
+// Returns:
+//   - *EventLog[T, S]: The log. Nil if an error occurred.
+//   - error: An error if the log could not be opened.
+//
+// Errors:
+//   - *errors.Err: If the codec is nil or the file is not an event log.
+//   - *ErrDivergence: If the subject entered an error state while replaying.
+//   - any error returned by the file system or the codec.
+//
+// If the function 'init_fn' is nil, it defaults to 'var subject S'.
+func OpenEventLog[T any, S interface {
+	ApplyEvent(event T) bool
+	HasError() bool
+}](path string, codec Codec[T], init_fn func() S, opts ...EventLogOption) (*EventLog[T, S], error) {
+	if codec == nil {
+		return nil, gers.NewErrNilParameter("codec")
+	}
+
+	if init_fn == nil {
+		init_fn = func() S {
+			return *new(S)
+		}
+	}
+
+	var settings event_log_settings
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &EventLog[T, S]{
+		file:     file,
+		codec:    codec,
+		init_fn:  init_fn,
+		subject:  init_fn(),
+		history:  &History[T]{},
+		settings: settings,
+	}
+
+	err = l.load()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// load reads the log, truncates its torn tail and replays it into the
+// subject.
+//
+// Returns:
+//   - error: An error if the log could not be loaded.
+func (l *EventLog[T, S]) load() error {
+	data, err := io.ReadAll(l.file)
+	if err != nil {
+		return err
+	}
+
+	if len(data) < len(log_magic) {
+		if !bytes.HasPrefix(log_magic, data) {
+			return gerr.New(gers.BadParameter, "file is not an event log")
+		}
+
+		// Either a new log or a crash while writing the magic number.
+		err = l.truncate(0, int64(len(data)))
+		if err != nil {
+			return err
+		}
+
+		return l.write(log_magic)
+	} else if !bytes.HasPrefix(data, log_magic) {
+		return gerr.New(gers.BadParameter, "file is not an event log")
+	}
+
+	offset := len(log_magic)
+
+	for offset < len(data) {
+		kind, payload, size, ok := read_record(data[offset:])
+		if !ok {
+			break
+		}
+
+		switch kind {
+		case record_event:
+			event, err := l.codec.DecodeEvent(payload)
+			if err != nil {
+				return err
+			}
+
+			l.history.AddEvent(event)
+			l.since_snapshot++
+		case record_snapshot:
+			at, n := binary.Uvarint(payload)
+			if n > 0 && int(at) == len(l.history.timeline) {
+				l.state = payload[n:]
+				l.state_at = int(at)
+				l.since_snapshot = 0
+			}
+		}
+
+		offset += size
+	}
+
+	if offset < len(data) {
+		err = l.truncate(int64(offset), int64(len(data)-offset))
+		if err != nil {
+			return err
+		}
+	}
+
+	return l.replay()
+}
+
+// read_record reads a record.
+//
+// Parameters:
+//   - data: The data that starts with the record.
+//
+// Returns:
+//   - byte: The kind of the record.
+//   - []byte: The payload of the record.
+//   - int: The total size of the record.
+//   - bool: False if the record is torn or corrupted.
+func read_record(data []byte) (byte, []byte, int, bool) {
+	if len(data) < record_header_size {
+		return 0, nil, 0, false
+	}
+
+	length := int(binary.LittleEndian.Uint32(data))
+	if length > max_record_size {
+		return 0, nil, 0, false
+	}
+
+	size := record_header_size + length + record_crc_size
+	if size > len(data) {
+		return 0, nil, 0, false
+	}
+
+	body := data[4 : record_header_size+length]
+	sum := binary.LittleEndian.Uint32(data[record_header_size+length:])
+
+	if crc32.Checksum(body, crc_table) != sum {
+		return 0, nil, 0, false
+	}
+
+	return body[0], body[1:], size, true
+}
+
+// truncate removes the tail of the log.
+//
+// Parameters:
+//   - offset: The offset at which the tail starts.
+//   - size: The size of the tail.
+//
+// Returns:
+//   - error: An error if the file could not be truncated.
+func (l *EventLog[T, S]) truncate(offset, size int64) error {
+	err := l.file.Truncate(offset)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	l.truncated += size
+
+	return nil
+}
+
+// replay brings the subject up to date with the history, starting from the
+// last snapshot if it can be restored.
+//
+// Returns:
+//   - error: An error if the subject entered an error state.
+func (l *EventLog[T, S]) replay() error {
+	start := 0
+
+	if l.state != nil {
+		u, ok := any(l.subject).(encoding.BinaryUnmarshaler)
+		if ok {
+			err := u.UnmarshalBinary(l.state)
+			if err == nil {
+				start = l.state_at
+			} else {
+				// The failed unmarshal may have left the subject half
+				// overwritten.
+				l.subject = l.init_fn()
+			}
+		}
+	}
+
+	if start == 0 {
+		l.since_snapshot = len(l.history.timeline)
+	}
+
+	for i := start; i < len(l.history.timeline); i++ {
+		_ = l.subject.ApplyEvent(l.history.timeline[i])
+		if l.subject.HasError() {
+			return NewErrDivergence(i, SubjectError)
+		}
+	}
+
+	l.history.current = len(l.history.timeline)
+
+	return nil
+}
+
+// write writes data at the end of the log and syncs it unless disabled. If
+// the data could not be written, the log is truncated back to its previous
+// size so that the next record does not follow a torn one.
+//
+// Parameters:
+//   - data: The data to write.
+//
+// Returns:
+//   - error: An error if the data could not be written.
+func (l *EventLog[T, S]) write(data []byte) error {
+	offset, err := l.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	n, err := l.file.Write(data)
+	if err == nil && n != len(data) {
+		err = io.ErrShortWrite
+	}
+
+	if err == nil && !l.settings.no_sync {
+		err = l.file.Sync()
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	terr := l.file.Truncate(offset)
+	_, serr := l.file.Seek(offset, io.SeekStart)
+
+	return errors.Join(err, terr, serr)
+}
+
+// write_record writes a record at the end of the log.
+//
+// Parameters:
+//   - kind: The kind of the record.
+//   - payload: The payload of the record.
+//
+// Returns:
+//   - error: An error if the record could not be written.
+func (l *EventLog[T, S]) write_record(kind byte, payload []byte) error {
+	if len(payload)+1 > max_record_size {
+		return gerr.New(gers.BadParameter, "record is too large")
+	}
+
+	data := make([]byte, 0, record_header_size+len(payload)+record_crc_size)
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(payload)))
+	data = append(data, kind)
+	data = append(data, payload...)
+	data = binary.LittleEndian.AppendUint32(data, crc32.Checksum(data[4:], crc_table))
+
+	return l.write(data)
+}
+
+// Apply applies an event to the subject and appends it to the log. Every
+// configured number of events, a snapshot of the subject is appended too.
+//
+// Parameters:
+//   - event: The event to apply.
+//
+// Returns:
+//   - error: An error if the event could not be applied or written, in which
+//     case the subject is rolled back and nothing is recorded, or if the
+//     snapshot that follows it could not be written.
+//
+// Errors:
+//   - *errors.Err: If the receiver is nil or the subject is in an error state
+//     after applying the event.
+//   - *ErrSnapshot: If the event was recorded but the snapshot that follows
+//     it could not be written. The snapshot is tried again after the next
+//     event.
+//   - any error returned by the codec or the file system.
+//
+// The subject is rolled back by restoring a snapshot taken before the event if
+// it implements the Snapshotter interface. Otherwise, it is rebuilt from
+// scratch with 'init_fn', so that Subject must be called again.
+func (l *EventLog[T, S]) Apply(event T) error {
+	if l == nil {
+		return gers.NewErrNilParameter("EventLog")
+	}
+
+	payload, err := l.codec.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	var snapshot any
+
+	s, ok := any(l.subject).(Snapshotter)
+	if ok {
+		snapshot = s.Snapshot()
+	}
+
+	_ = l.subject.ApplyEvent(event)
+	if l.subject.HasError() {
+		err = gerr.New(gers.OperationFail, "subject rejected the event")
+	} else {
+		err = l.write_record(record_event, payload)
+	}
+
+	if err != nil {
+		return errors.Join(err, l.rollback(snapshot))
+	}
+
+	l.history.AddEvent(event)
+	l.history.current = len(l.history.timeline)
+	l.since_snapshot++
+
+	if l.settings.snapshot_every == 0 || l.since_snapshot < l.settings.snapshot_every {
+		return nil
+	}
+
+	err = l.Snapshot()
+	if err != nil {
+		return NewErrSnapshot(err)
+	}
+
+	return nil
+}
+
+// rollback brings the subject back to the state before the last event.
+//
+// Parameters:
+//   - snapshot: The snapshot of the subject before the event. Nil if there is
+//     none.
+//
+// Returns:
+//   - error: An error if the subject had to be rebuilt and entered an error
+//     state.
+func (l *EventLog[T, S]) rollback(snapshot any) error {
+	if snapshot != nil && any(l.subject).(Snapshotter).Restore(snapshot) {
+		return nil
+	}
+
+	l.subject = l.init_fn()
+
+	since := l.since_snapshot
+
+	err := l.replay()
+
+	l.since_snapshot = since
+
+	return err
+}
+
+// Snapshot appends a snapshot of the subject to the log. Does nothing if the
+// subject does not implement the encoding.BinaryMarshaler interface.
+//
+// Returns:
+//   - error: An error if the snapshot could not be written.
+func (l *EventLog[T, S]) Snapshot() error {
+	if l == nil {
+		return gers.NewErrNilParameter("EventLog")
+	}
+
+	m, ok := any(l.subject).(encoding.BinaryMarshaler)
+	if !ok {
+		return nil
+	}
+
+	state, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	payload := binary.AppendUvarint(nil, uint64(len(l.history.timeline)))
+	payload = append(payload, state...)
+
+	err = l.write_record(record_snapshot, payload)
+	if err != nil {
+		return err
+	}
+
+	l.state = state
+	l.state_at = len(l.history.timeline)
+	l.since_snapshot = 0
+
+	return nil
+}
+
+// Subject returns the subject of the log.
+//
+// Returns:
+//   - S: The subject. The zero value if the receiver is nil.
+func (l *EventLog[T, S]) Subject() S {
+	if l == nil {
+		return *new(S)
+	}
+
+	return l.subject
+}
+
+// History returns a copy of the history recorded in the log.
+//
+// Returns:
+//   - *History[T]: The copy. Never returns nil.
+func (l *EventLog[T, S]) History() *History[T] {
+	if l == nil {
+		return &History[T]{}
+	}
+
+	return l.history.Copy()
+}
+
+// Truncated returns the number of bytes of torn or corrupted tail that were
+// removed when the log was opened.
+//
+// Returns:
+//   - int64: The number of bytes.
+func (l *EventLog[T, S]) Truncated() int64 {
+	if l == nil {
+		return 0
+	}
+
+	return l.truncated
+}
+
+// Close closes the log.
+//
+// Returns:
+//   - error: An error if the file could not be closed.
+func (l *EventLog[T, S]) Close() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	err := l.file.Sync()
+	err = errors.Join(err, l.file.Close())
+
+	l.file = nil
+
+	return err
+}
@@ -0,0 +1,234 @@
+package backup
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// MockSum is a subject that sums its events.
+type MockSum struct {
+	// sum is the sum of the events.
+	sum int
+
+	// applied counts the number of applied events.
+	applied int
+
+	// broken makes the snapshots fail.
+	broken bool
+}
+
+func (ms *MockSum) ApplyEvent(event int) bool {
+	ms.sum += event
+	ms.applied++
+
+	return false
+}
+
+func (ms *MockSum) HasError() bool {
+	return ms.sum < 0
+}
+
+func (ms *MockSum) MarshalBinary() ([]byte, error) {
+	if ms.broken {
+		return nil, errors.New("broken")
+	}
+
+	return binary.AppendVarint(nil, int64(ms.sum)), nil
+}
+
+func (ms *MockSum) UnmarshalBinary(data []byte) error {
+	v, n := binary.Varint(data)
+	if n <= 0 {
+		return errors.New("invalid sum")
+	}
+
+	ms.sum = int(v)
+
+	return nil
+}
+
+func new_mock_sum() *MockSum {
+	return &MockSum{}
+}
+
+func TestEventLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+
+	l, err := OpenEventLog(path, JSONCodec[int]{}, new_mock_sum, WithSnapshotEvery(2))
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	for i := 1; i <= 5; i++ {
+		err = l.Apply(i)
+		if err != nil {
+			t.Fatalf("expected no error, got %s instead", err.Error())
+		}
+	}
+
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	// Simulate a crash in the middle of a write.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	_, _ = f.Write([]byte{10, 0, 0, 0, record_event, '6'})
+	_ = f.Close()
+
+	l, err = OpenEventLog(path, JSONCodec[int]{}, new_mock_sum)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	defer l.Close()
+
+	if l.Truncated() != 6 {
+		t.Errorf("expected 6 truncated bytes, got %d instead", l.Truncated())
+	}
+
+	if !slices.Equal(l.History().timeline, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v instead", l.History().timeline)
+	}
+
+	sbj := l.Subject()
+
+	if sbj.sum != 15 || sbj.applied != 1 {
+		t.Errorf("expected a sum of 15 with 1 replayed event, got %d with %d instead", sbj.sum, sbj.applied)
+	}
+}
+
+func TestEventLogReject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+
+	l, err := OpenEventLog(path, JSONCodec[int]{}, new_mock_sum, WithSnapshotEvery(2))
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	for _, event := range []int{1, 2, 3} {
+		err = l.Apply(event)
+		if err != nil {
+			t.Fatalf("expected no error, got %s instead", err.Error())
+		}
+	}
+
+	err = l.Apply(-10)
+	if err == nil {
+		t.Fatalf("expected an error, got nil instead")
+	}
+
+	if l.Subject().sum != 6 {
+		t.Errorf("expected a sum of 6, got %d instead", l.Subject().sum)
+	}
+
+	err = l.Apply(4)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	l, err = OpenEventLog(path, JSONCodec[int]{}, new_mock_sum)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	defer l.Close()
+
+	if !slices.Equal(l.History().timeline, []int{1, 2, 3, 4}) {
+		t.Errorf("expected [1 2 3 4], got %v instead", l.History().timeline)
+	}
+
+	if l.Subject().sum != 10 {
+		t.Errorf("expected a sum of 10, got %d instead", l.Subject().sum)
+	}
+}
+
+func TestEventLogSnapshotFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+
+	l, err := OpenEventLog(path, JSONCodec[int]{}, func() *MockSum {
+		return &MockSum{broken: true}
+	}, WithSnapshotEvery(1))
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	defer l.Close()
+
+	err = l.Apply(1)
+
+	var serr *ErrSnapshot
+	if !errors.As(err, &serr) {
+		t.Fatalf("expected *ErrSnapshot, got %v instead", err)
+	}
+
+	if !slices.Equal(l.History().timeline, []int{1}) || l.Subject().sum != 1 {
+		t.Errorf("expected the event to be recorded, got %v instead", l.History().timeline)
+	}
+}
+
+// MockHalfSum is a MockSum whose snapshots fail to restore after overwriting
+// its sum.
+type MockHalfSum struct {
+	MockSum
+}
+
+func (ms *MockHalfSum) UnmarshalBinary(data []byte) error {
+	ms.sum = 1000
+	return errors.New("invalid sum")
+}
+
+func TestEventLogRestoreFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+
+	l, err := OpenEventLog(path, JSONCodec[int]{}, new_mock_sum, WithSnapshotEvery(2))
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	for i := 1; i <= 3; i++ {
+		err = l.Apply(i)
+		if err != nil {
+			t.Fatalf("expected no error, got %s instead", err.Error())
+		}
+	}
+
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	h, err := OpenEventLog(path, JSONCodec[int]{}, func() *MockHalfSum {
+		return &MockHalfSum{}
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	defer h.Close()
+
+	if h.Subject().sum != 6 {
+		t.Errorf("expected a sum of 6, got %d instead", h.Subject().sum)
+	}
+}
+
+func TestEventLogNil(t *testing.T) {
+	var l *EventLog[int, *MockSum]
+
+	if l.Subject() != nil || l.Truncated() != 0 || len(l.History().timeline) != 0 {
+		t.Errorf("expected the zero values on a nil log")
+	}
+}
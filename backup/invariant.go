@@ -0,0 +1,385 @@
+package backup
+
+import (
+	"strconv"
+	"strings"
+
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+// Invariant is a property that must hold in every reachable state of a
+// subject.
+type Invariant[S any] struct {
+	// Name is the name of the invariant.
+	Name string
+
+	// Holds checks whether the invariant holds for a subject.
+	Holds func(subject S) bool
+}
+
+// NewInvariant creates a new invariant.
+//
+// Parameters:
+//   - name: The name of the invariant.
+//   - holds: The function that checks whether the invariant holds.
+//
+// Returns:
+//   - *Invariant[S]: The new invariant. Never returns nil.
+func NewInvariant[S any](name string, holds func(subject S) bool) *Invariant[S] {
+	return &Invariant[S]{
+		Name:  name,
+		Holds: holds,
+	}
+}
+
+// ErrInvariantViolated is an error that is returned when an invariant does
+// not hold in a reachable state of a subject.
+type ErrInvariantViolated[T any] struct {
+	// Invariant is the name of the violated invariant.
+	Invariant string
+
+	// History is the history that first reached a violating state.
+	History *History[T]
+
+	// Counterexample is the shrunk history that still reaches a violating
+	// state.
+	Counterexample *History[T]
+}
+
+// Error implements the error interface.
+//
+// Message: "invariant <name> is violated after <n> events"
+func (e ErrInvariantViolated[T]) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString("invariant ")
+	builder.WriteString(strconv.Quote(e.Invariant))
+	builder.WriteString(" is violated after ")
+
+	if e.Counterexample != nil {
+		builder.WriteString(strconv.Itoa(len(e.Counterexample.timeline)))
+	} else {
+		builder.WriteString("some")
+	}
+
+	builder.WriteString(" events")
+
+	return builder.String()
+}
+
+// NewErrInvariantViolated creates a new ErrInvariantViolated error.
+//
+// Parameters:
+//   - invariant: The name of the violated invariant.
+//   - history: The history that first reached a violating state.
+//   - counterexample: The shrunk history.
+//
+// Returns:
+//   - *ErrInvariantViolated[T]: The new error. Never returns nil.
+func NewErrInvariantViolated[T any](invariant string, history, counterexample *History[T]) *ErrInvariantViolated[T] {
+	return &ErrInvariantViolated[T]{
+		Invariant:      invariant,
+		History:        history,
+		Counterexample: counterexample,
+	}
+}
+
+// check_item is a state waiting to be checked.
+type check_item[T any, S any] struct {
+	// history is the history that leads to the state.
+	history *History[T]
+
+	// subject is the subject in that state.
+	subject S
+
+	// is_done is true if the subject is done.
+	is_done bool
+}
+
+// Check explores every reachable state of a subject, in the same order as
+// Subject, and checks that the invariants hold in each of them. When one does
+// not, the history that reached the state is shrunk to a minimal
+// counterexample by dropping and reordering events; every candidate is
+// re-validated through Align.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - max_depth: The maximum number of events explored. Non-positive values
+//     mean no limit.
+//   - invariants: The invariants to check. Nil invariants are ignored.
+//
+// Returns:
+//   - error: An error if an invariant is violated.
+//
+// Errors:
+//   - *ErrInvariantViolated[T]: If an invariant is violated.
+//
+// States in which the subject has an error are not checked.
+//
+// If the function 'init_fn' is nil, it defaults to 'var subject S'.
+func Check[T any, S interface {
+	Align(history *History[T]) bool
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](init_fn func() S, max_depth int, invariants ...*Invariant[S]) error {
+	if init_fn == nil {
+		init_fn = func() S {
+			return *new(S)
+		}
+	}
+
+	checked := make([]*Invariant[S], 0, len(invariants))
+
+	for _, inv := range invariants {
+		if inv != nil && inv.Holds != nil {
+			checked = append(checked, inv)
+		}
+	}
+
+	stack := []*check_item[T, S]{
+		{
+			history: &History[T]{},
+			subject: init_fn(),
+		},
+	}
+
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, inv := range checked {
+			if inv.Holds(item.subject) {
+				continue
+			}
+
+			shrunk := shrink(init_fn, inv, item.history.timeline)
+
+			return NewErrInvariantViolated(inv.Name, item.history, shrunk)
+		}
+
+		if item.is_done || (max_depth > 0 && len(item.history.timeline) >= max_depth) {
+			continue
+		}
+
+		children := expand_check(item, init_fn)
+		stack = append(stack, children...)
+	}
+
+	return nil
+}
+
+// expand_check creates the states that follow a state.
+//
+// Parameters:
+//   - item: The state to expand.
+//   - init_fn: A function that returns a new instance of the subject.
+//
+// Returns:
+//   - []*check_item[T, S]: The following states, in reverse order so that
+//     they can be pushed on a stack.
+func expand_check[T any, S interface {
+	Align(history *History[T]) bool
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](item *check_item[T, S], init_fn func() S) []*check_item[T, S] {
+	events := item.subject.DetermineNextEvents()
+	if item.subject.HasError() || len(events) == 0 {
+		return nil
+	}
+
+	var snapshot any
+
+	if len(events) > 1 {
+		s, ok := any(item.subject).(Snapshotter)
+		if ok {
+			snapshot = s.Snapshot()
+		}
+	}
+
+	children := make([]*check_item[T, S], 0, len(events))
+
+	// The first event takes over the subject of its parent, so it is applied
+	// last.
+	for i := len(events) - 1; i >= 0; i-- {
+		var sbj S
+
+		if i == 0 {
+			sbj = item.subject
+		} else {
+			var ok bool
+
+			sbj, ok = fork(init_fn, item.history, snapshot)
+			if !ok {
+				continue
+			}
+		}
+
+		h := item.history.Copy()
+		h.AddEvent(events[i])
+		h.current = len(h.timeline)
+
+		is_done := sbj.ApplyEvent(events[i])
+		if sbj.HasError() {
+			continue
+		}
+
+		children = append(children, &check_item[T, S]{
+			history: h,
+			subject: sbj,
+			is_done: is_done,
+		})
+	}
+
+	return children
+}
+
+// violates checks whether a sequence of events is a valid history that leads
+// to a state violating the invariant.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - inv: The invariant.
+//   - events: The events.
+//
+// Returns:
+//   - bool: True if the history is valid and violates the invariant.
+func violates[T any, S interface {
+	Align(history *History[T]) bool
+	HasError() bool
+}](init_fn func() S, inv *Invariant[S], events []T) bool {
+	h := &History[T]{
+		timeline: events,
+	}
+
+	sbj := init_fn()
+
+	if !align_history(sbj, h) || sbj.HasError() {
+		return false
+	}
+
+	return !inv.Holds(sbj)
+}
+
+// align_history aligns a subject with a history.
+//
+// Parameters:
+//   - sbj: The subject.
+//   - h: The history.
+//
+// Returns:
+//   - bool: True if the subject is aligned with the history, false otherwise.
+//
+// Histories for which Align panics with InvalidHistory, as when the subject is
+// done before the history, are not aligned. Any other panic is propagated.
+func align_history[T any, S interface {
+	Align(history *History[T]) bool
+}](sbj S, h *History[T]) (ok bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		err, is_err := r.(*gerr.Err)
+		if !is_err || err.Message != InvalidHistory.Error() {
+			panic(r)
+		}
+
+		ok = false
+	}()
+
+	return sbj.Align(h)
+}
+
+// without returns a copy of the events without the given range.
+//
+// Parameters:
+//   - events: The events.
+//   - from: The start of the range, inclusive.
+//   - to: The end of the range, exclusive.
+//
+// Returns:
+//   - []T: The copy.
+func without[T any](events []T, from, to int) []T {
+	result := make([]T, 0, len(events)-(to-from))
+
+	result = append(result, events[:from]...)
+	result = append(result, events[to:]...)
+
+	return result
+}
+
+// drop_events removes as many events as possible while the history still
+// violates the invariant, trying chunks of decreasing size.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - inv: The invariant.
+//   - events: The events of a violating history.
+//
+// Returns:
+//   - []T: The remaining events.
+func drop_events[T any, S interface {
+	Align(history *History[T]) bool
+	HasError() bool
+}](init_fn func() S, inv *Invariant[S], events []T) []T {
+	for size := max(len(events)/2, 1); size > 0; size /= 2 {
+		for i := 0; i+size <= len(events); {
+			candidate := without(events, i, i+size)
+
+			if violates(init_fn, inv, candidate) {
+				events = candidate
+			} else {
+				i++
+			}
+		}
+	}
+
+	return events
+}
+
+// shrink shrinks a violating history to a minimal counterexample.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - inv: The invariant.
+//   - events: The events of a violating history.
+//
+// Returns:
+//   - *History[T]: The counterexample. Never returns nil.
+//
+// Adjacent events are swapped whenever it lets more events be dropped.
+func shrink[T any, S interface {
+	Align(history *History[T]) bool
+	HasError() bool
+}](init_fn func() S, inv *Invariant[S], events []T) *History[T] {
+	events = drop_events(init_fn, inv, append([]T(nil), events...))
+
+	for improved := true; improved; {
+		improved = false
+
+		for i := 0; i+1 < len(events); i++ {
+			candidate := append([]T(nil), events...)
+			candidate[i], candidate[i+1] = candidate[i+1], candidate[i]
+
+			if !violates(init_fn, inv, candidate) {
+				continue
+			}
+
+			candidate = drop_events(init_fn, inv, candidate)
+			if len(candidate) < len(events) {
+				events = candidate
+				improved = true
+
+				break
+			}
+		}
+	}
+
+	return &History[T]{
+		timeline: events,
+		current:  len(events),
+	}
+}
@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	var n int
+
+	init_fn := func() *MockSubject {
+		return &MockSubject{applied: &n}
+	}
+
+	at_most_one := NewInvariant("at most one 1", func(ms *MockSubject) bool {
+		return strings.Count(ms.String(), "1") <= 1
+	})
+
+	err := Check(init_fn, 0, at_most_one)
+
+	var inv *ErrInvariantViolated[int]
+	if !errors.As(err, &inv) {
+		t.Fatalf("expected *ErrInvariantViolated, got %v instead", err)
+	}
+
+	if !slices.Equal(inv.History.timeline, []int{0, 1, 1}) {
+		t.Errorf("expected [0 1 1], got %v instead", inv.History.timeline)
+	}
+
+	if !slices.Equal(inv.Counterexample.timeline, []int{1, 1}) {
+		t.Errorf("expected [1 1], got %v instead", inv.Counterexample.timeline)
+	}
+
+	err = Check(init_fn, 1, at_most_one)
+	if err != nil {
+		t.Errorf("expected no error, got %s instead", err.Error())
+	}
+}
+
+// MockStrict is a subject that panics when it is aligned with a history that
+// goes on after it is done.
+type MockStrict struct {
+	MockSubject
+}
+
+func (ms *MockStrict) Align(history *History[int]) bool {
+	Align(history, &ms.MockSubject)
+	return true
+}
+
+func TestViolates(t *testing.T) {
+	var n int
+
+	init_fn := func() *MockStrict {
+		return &MockStrict{MockSubject{applied: &n}}
+	}
+
+	never := NewInvariant("never holds", func(ms *MockStrict) bool {
+		return false
+	})
+
+	if violates(init_fn, never, []int{0, 1, 1, 0}) {
+		t.Errorf("expected an invalid history not to violate the invariant")
+	}
+
+	if !violates(init_fn, never, []int{0, 1}) {
+		t.Errorf("expected a valid history to violate the invariant")
+	}
+
+	broken := NewInvariant("broken", func(ms *MockStrict) bool {
+		panic("broken invariant")
+	})
+
+	defer func() {
+		r := recover()
+		if r != "broken invariant" {
+			t.Errorf("expected %q, got %v instead", "broken invariant", r)
+		}
+	}()
+
+	_ = violates(init_fn, broken, []int{0, 1})
+
+	t.Errorf("expected a panic")
+}
@@ -0,0 +1,160 @@
+// Package parser provides a backtracking parser built on top of the backup
+// package. The rules of a grammar are the events and the tokens are the input,
+// so every parse tree of an ambiguous grammar can be enumerated.
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+// Token is a token of the input.
+type Token[K comparable] struct {
+	// Type is the type of the token.
+	Type K
+
+	// Data is the text of the token.
+	Data string
+
+	// Pos is the position of the token in the source.
+	Pos int
+}
+
+// NewToken creates a new token.
+//
+// Parameters:
+//   - type_: The type of the token.
+//   - data: The text of the token.
+//   - pos: The position of the token in the source.
+//
+// Returns:
+//   - *Token[K]: The new token. Never returns nil.
+func NewToken[K comparable](type_ K, data string, pos int) *Token[K] {
+	return &Token[K]{
+		Type: type_,
+		Data: data,
+		Pos:  pos,
+	}
+}
+
+// Rule is a production rule of a grammar.
+type Rule[K comparable] struct {
+	// LHS is the symbol that the rule produces.
+	LHS K
+
+	// RHS is the sequence of symbols the LHS is replaced with. Empty for an
+	// epsilon rule.
+	RHS []K
+}
+
+// NewRule creates a new rule.
+//
+// Parameters:
+//   - lhs: The symbol that the rule produces.
+//   - rhs: The sequence of symbols the LHS is replaced with.
+//
+// Returns:
+//   - *Rule[K]: The new rule. Never returns nil.
+func NewRule[K comparable](lhs K, rhs ...K) *Rule[K] {
+	return &Rule[K]{
+		LHS: lhs,
+		RHS: rhs,
+	}
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Format: "<lhs> -> <rhs>"
+func (r Rule[K]) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%v ->", r.LHS)
+
+	for _, sym := range r.RHS {
+		fmt.Fprintf(&builder, " %v", sym)
+	}
+
+	return builder.String()
+}
+
+// Grammar is a context-free grammar. Symbols that are the LHS of at least one
+// rule are non-terminals; every other symbol is a terminal and must match the
+// type of a token.
+//
+// The grammar must not be left-recursive, whether directly or through
+// nullable symbols, as the parser expands rules top-down.
+type Grammar[K comparable] struct {
+	// start is the start symbol.
+	start K
+
+	// rules are the rules of the grammar, in the order they are tried.
+	rules []*Rule[K]
+
+	// by_lhs maps the non-terminals to the indices of their rules.
+	by_lhs map[K][]int
+}
+
+// NewGrammar creates a new grammar. Nil rules are ignored.
+//
+// Parameters:
+//   - start: The start symbol.
+//   - rules: The rules of the grammar, in the order they are tried.
+//
+// Returns:
+//   - *Grammar[K]: The new grammar. Nil if an error occurred.
+//   - error: An error if the start symbol has no rule.
+//
+// Errors:
+//   - *errors.Err: If the start symbol has no rule.
+func NewGrammar[K comparable](start K, rules ...*Rule[K]) (*Grammar[K], error) {
+	g := &Grammar[K]{
+		start:  start,
+		by_lhs: make(map[K][]int),
+	}
+
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+
+		g.by_lhs[rule.LHS] = append(g.by_lhs[rule.LHS], len(g.rules))
+		g.rules = append(g.rules, rule)
+	}
+
+	_, ok := g.by_lhs[start]
+	if !ok {
+		return nil, gerr.New(gers.BadParameter, fmt.Sprintf("start symbol %v has no rule", start))
+	}
+
+	return g, nil
+}
+
+// IsTerminal checks whether a symbol is a terminal.
+//
+// Parameters:
+//   - sym: The symbol to check.
+//
+// Returns:
+//   - bool: True if the symbol is a terminal, false otherwise.
+func (g Grammar[K]) IsTerminal(sym K) bool {
+	_, ok := g.by_lhs[sym]
+	return !ok
+}
+
+// Rule returns the rule at the given index.
+//
+// Parameters:
+//   - idx: The index of the rule, in the order given to NewGrammar.
+//
+// Returns:
+//   - *Rule[K]: The rule. Nil if the index is out of bounds.
+func (g Grammar[K]) Rule(idx int) *Rule[K] {
+	if idx < 0 || idx >= len(g.rules) {
+		return nil
+	}
+
+	return g.rules[idx]
+}
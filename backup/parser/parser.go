@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+
+	bk "github.com/PlayerR9/go-commons/backup"
+	gcstr "github.com/PlayerR9/go-commons/strings"
+	"github.com/dustin/go-humanize"
+)
+
+// Node is a node of a parse tree.
+type Node[K comparable] struct {
+	// Type is the symbol of the node.
+	Type K
+
+	// Token is the matched token. Nil for non-terminals.
+	Token *Token[K]
+
+	// Children are the children of the node. Empty for terminals.
+	Children []*Node[K]
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Format: the data of the token for terminals and "<type>(<children>)" for
+// non-terminals, where the children are separated by spaces.
+func (n Node[K]) String() string {
+	if n.Token != nil {
+		return n.Token.Data
+	}
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%v(", n.Type)
+
+	for i, child := range n.Children {
+		if i > 0 {
+			builder.WriteRune(' ')
+		}
+
+		builder.WriteString(child.String())
+	}
+
+	builder.WriteRune(')')
+
+	return builder.String()
+}
+
+// ErrParse is an error that is returned when no parse tree could be built. It
+// reports the furthest position at which the input failed to match.
+type ErrParse struct {
+	// Pos is the index of the token at which the furthest failure occurred.
+	Pos int
+
+	// Got is the text of the token at that position. Empty at the end of the
+	// input.
+	Got string
+
+	// Expected are the symbols that were expected at that position.
+	Expected []string
+}
+
+// Error implements the error interface.
+//
+// Message: "at the <ordinal> token: expected <expected>, got <got> instead"
+func (e ErrParse) Error() string {
+	got := e.Got
+	if got == "" {
+		got = "end of input"
+	} else {
+		got = gcstr.Quote(got)
+	}
+
+	return "at the " + humanize.Ordinal(e.Pos+1) + " token: " + gcstr.ExpectedValue("", gcstr.EitherOr(e.Expected), got)
+}
+
+// NewErrParse creates a new ErrParse error.
+//
+// Parameters:
+//   - pos: The index of the token at which the furthest failure occurred.
+//   - got: The text of the token at that position.
+//   - expected: The symbols that were expected at that position.
+//
+// Returns:
+//   - *ErrParse: The new error. Never returns nil.
+func NewErrParse(pos int, got string, expected []string) *ErrParse {
+	return &ErrParse{
+		Pos:      pos,
+		Got:      got,
+		Expected: expected,
+	}
+}
+
+// build builds the subtree of a symbol from a leftmost derivation.
+//
+// Parameters:
+//   - sym: The symbol of the subtree.
+//   - rules: The remaining rules of the derivation.
+//   - tokens: The remaining tokens.
+//
+// Returns:
+//   - *Node[K]: The subtree.
+//   - []int: The rules left after the subtree.
+//   - []*Token[K]: The tokens left after the subtree.
+func (g Grammar[K]) build(sym K, rules []int, tokens []*Token[K]) (*Node[K], []int, []*Token[K]) {
+	node := &Node[K]{
+		Type: sym,
+	}
+
+	if g.IsTerminal(sym) {
+		node.Token = tokens[0]
+
+		return node, rules, tokens[1:]
+	}
+
+	rule := g.rules[rules[0]]
+	rules = rules[1:]
+
+	node.Children = make([]*Node[K], 0, len(rule.RHS))
+
+	for _, child_sym := range rule.RHS {
+		var child *Node[K]
+
+		child, rules, tokens = g.build(child_sym, rules, tokens)
+		node.Children = append(node.Children, child)
+	}
+
+	return node, rules, tokens
+}
+
+// Parser is the parse of a sequence of tokens.
+type Parser[K comparable] struct {
+	// grammar is the grammar of the parse.
+	grammar *Grammar[K]
+
+	// tokens are the tokens to parse.
+	tokens []*Token[K]
+
+	// err is the error of the last parse. Nil if there is none.
+	err error
+}
+
+// Parse prepares the parse of tokens. Alternatives are explored by
+// backtracking over the rules, in the order they were given to the grammar.
+//
+// Parameters:
+//   - tokens: The tokens to parse.
+//
+// Returns:
+//   - *Parser[K]: The parse. Never returns nil.
+func (g *Grammar[K]) Parse(tokens []*Token[K]) *Parser[K] {
+	return &Parser[K]{
+		grammar: g,
+		tokens:  tokens,
+	}
+}
+
+// Err returns the error of the last parse.
+//
+// Returns:
+//   - error: An *ErrParse that reports the furthest failure if the last parse
+//     yielded no tree. Nil otherwise, or if the parse was stopped early.
+func (p *Parser[K]) Err() error {
+	return p.err
+}
+
+// Trees parses the tokens. Each iteration parses them again.
+//
+// Returns:
+//   - iter.Seq[*Node[K]]: Every parse tree of the tokens. Never returns nil.
+func (p *Parser[K]) Trees() iter.Seq[*Node[K]] {
+	return func(yield func(*Node[K]) bool) {
+		p.err = nil
+
+		f := &failure{
+			pos: -1,
+		}
+
+		init_fn := func() *subject[K] {
+			return new_subject(p.grammar, p.tokens, f)
+		}
+
+		var found bool
+
+		for s := range bk.Subject[int](init_fn) {
+			// Invalid subjects are only yielded once every valid one was.
+			if !s.accepted {
+				break
+			}
+
+			found = true
+
+			tree, _, _ := p.grammar.build(p.grammar.start, s.rules, p.tokens)
+			if !yield(tree) {
+				return
+			}
+		}
+
+		if found {
+			return
+		}
+
+		pos := max(f.pos, 0)
+
+		var got string
+
+		if pos < len(p.tokens) {
+			got = p.tokens[pos].Data
+		}
+
+		p.err = NewErrParse(pos, got, f.expected)
+	}
+}
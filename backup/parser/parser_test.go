@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func tokenize(input string) []*Token[string] {
+	var tokens []*Token[string]
+
+	for i, field := range strings.Fields(input) {
+		tokens = append(tokens, NewToken(field, field, i))
+	}
+
+	return tokens
+}
+
+func TestParseAmbiguous(t *testing.T) {
+	g, err := NewGrammar("S",
+		NewRule("S", "A", "B"),
+		NewRule("A", "a"),
+		NewRule("A", "a", "a"),
+		NewRule("B", "a"),
+		NewRule("B"),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	var trees []string
+
+	p := g.Parse(tokenize("a a"))
+
+	for tree := range p.Trees() {
+		trees = append(trees, tree.String())
+	}
+
+	err = p.Err()
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	expected := []string{"S(A(a) B(a))", "S(A(a a) B())"}
+	if !slices.Equal(trees, expected) {
+		t.Errorf("expected %v, got %v instead", expected, trees)
+	}
+}
+
+func TestParseFurthestFailure(t *testing.T) {
+	g, err := NewGrammar("S",
+		NewRule("S", "x", "T"),
+		NewRule("T", "y", "z"),
+		NewRule("T", "y", "w"),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	p := g.Parse(tokenize("x y q"))
+
+	for tree := range p.Trees() {
+		t.Fatalf("expected no tree, got %s instead", tree.String())
+	}
+
+	var perr *ErrParse
+	if !errors.As(p.Err(), &perr) {
+		t.Fatalf("expected *ErrParse, got %v instead", p.Err())
+	}
+
+	if perr.Pos != 2 || !slices.Equal(perr.Expected, []string{"w", "z"}) {
+		t.Errorf("expected failure at 2 expecting [w z], got %d expecting %v instead", perr.Pos, perr.Expected)
+	}
+
+	const msg = "at the 3rd token: expected w or z, got \"q\" instead"
+	if perr.Error() != msg {
+		t.Errorf("expected %q, got %q instead", msg, perr.Error())
+	}
+}
@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+
+	bk "github.com/PlayerR9/go-commons/backup"
+)
+
+const (
+	// accept_event is the event that accepts a complete parse. It is only
+	// proposed once every rule was expanded and every token was consumed.
+	accept_event int = -1
+)
+
+// failure records the furthest position at which a parse failed. It is shared
+// by every subject of a parse.
+type failure struct {
+	// pos is the index of the token at which the parse failed. -1 if no
+	// failure was recorded.
+	pos int
+
+	// expected are the symbols that were expected at that position.
+	expected []string
+}
+
+// record records a failure. Failures before the furthest one are ignored.
+// Does nothing if the receiver is nil.
+//
+// Parameters:
+//   - pos: The index of the token at which the parse failed.
+//   - expected: The symbol that was expected.
+func (f *failure) record(pos int, expected string) {
+	if f == nil || pos < f.pos {
+		return
+	}
+
+	if pos > f.pos {
+		f.pos = pos
+		f.expected = f.expected[:0]
+	}
+
+	idx, ok := slices.BinarySearch(f.expected, expected)
+	if !ok {
+		f.expected = slices.Insert(f.expected, idx, expected)
+	}
+}
+
+// snapshot is the snapshot of a subject.
+type snapshot[K comparable] struct {
+	// stack is the stack of symbols to expand.
+	stack []K
+
+	// pos is the index of the next token.
+	pos int
+
+	// rules are the indices of the expanded rules.
+	rules []int
+}
+
+// subject is a parse in progress. The events are the indices of the rules
+// that are expanded, in leftmost derivation order.
+type subject[K comparable] struct {
+	// grammar is the grammar to parse with.
+	grammar *Grammar[K]
+
+	// tokens are the tokens to parse.
+	tokens []*Token[K]
+
+	// stack is the stack of symbols to expand. The top is the last element.
+	stack []K
+
+	// pos is the index of the next token.
+	pos int
+
+	// rules are the indices of the expanded rules.
+	rules []int
+
+	// accepted is true if the parse was accepted.
+	accepted bool
+
+	// has_error is true if the parse failed.
+	has_error bool
+
+	// failure is the furthest failure of the parse.
+	failure *failure
+}
+
+// new_subject creates a new subject at the start of the input.
+//
+// Parameters:
+//   - grammar: The grammar to parse with. Assumed to be non-nil.
+//   - tokens: The tokens to parse.
+//   - f: The furthest failure of the parse.
+//
+// Returns:
+//   - *subject[K]: The new subject. Never returns nil.
+func new_subject[K comparable](grammar *Grammar[K], tokens []*Token[K], f *failure) *subject[K] {
+	s := &subject[K]{
+		grammar: grammar,
+		tokens:  tokens,
+		stack:   []K{grammar.start},
+		failure: f,
+	}
+
+	s.match()
+
+	return s
+}
+
+// fail marks the subject as failed at the current position.
+//
+// Parameters:
+//   - expected: The symbol that was expected.
+func (s *subject[K]) fail(expected string) {
+	s.has_error = true
+	s.failure.record(s.pos, expected)
+}
+
+// match consumes the tokens that match the terminals at the top of the stack.
+func (s *subject[K]) match() {
+	for len(s.stack) > 0 {
+		top := s.stack[len(s.stack)-1]
+
+		if !s.grammar.IsTerminal(top) {
+			break
+		}
+
+		if s.pos >= len(s.tokens) || s.tokens[s.pos].Type != top {
+			s.fail(fmt.Sprintf("%v", top))
+			return
+		}
+
+		s.stack = s.stack[:len(s.stack)-1]
+		s.pos++
+	}
+
+	if len(s.stack) == 0 {
+		if s.pos < len(s.tokens) {
+			s.fail("end of input")
+		}
+
+		return
+	}
+
+	// Every terminal left on the stack needs its own token.
+	var needed int
+
+	for _, sym := range s.stack {
+		if s.grammar.IsTerminal(sym) {
+			needed++
+		}
+	}
+
+	if needed > len(s.tokens)-s.pos {
+		s.has_error = true
+	}
+}
+
+// Align implements the backup.Subject interface.
+func (s *subject[K]) Align(history *bk.History[int]) bool {
+	bk.Align(history, s)
+
+	return !s.has_error
+}
+
+// ApplyEvent implements the backup.Subject interface.
+func (s *subject[K]) ApplyEvent(event int) bool {
+	if event == accept_event {
+		s.accepted = true
+		return true
+	}
+
+	rule := s.grammar.Rule(event)
+	if rule == nil || len(s.stack) == 0 || s.stack[len(s.stack)-1] != rule.LHS {
+		s.has_error = true
+		return false
+	}
+
+	s.stack = s.stack[:len(s.stack)-1]
+
+	for i := len(rule.RHS) - 1; i >= 0; i-- {
+		s.stack = append(s.stack, rule.RHS[i])
+	}
+
+	s.rules = append(s.rules, event)
+	s.match()
+
+	return false
+}
+
+// DetermineNextEvents implements the backup.Subject interface.
+func (s *subject[K]) DetermineNextEvents() []int {
+	if s.has_error || s.accepted {
+		return nil
+	}
+
+	if len(s.stack) == 0 {
+		return []int{accept_event}
+	}
+
+	top := s.stack[len(s.stack)-1]
+
+	return slices.Clone(s.grammar.by_lhs[top])
+}
+
+// HasError implements the backup.Subject interface.
+func (s *subject[K]) HasError() bool {
+	return s.has_error
+}
+
+// Snapshot implements the backup.Snapshotter interface.
+func (s *subject[K]) Snapshot() any {
+	return &snapshot[K]{
+		stack: slices.Clone(s.stack),
+		pos:   s.pos,
+		rules: slices.Clone(s.rules),
+	}
+}
+
+// Restore implements the backup.Snapshotter interface.
+func (s *subject[K]) Restore(snap any) bool {
+	tmp, ok := snap.(*snapshot[K])
+	if !ok {
+		return false
+	}
+
+	s.stack = slices.Clone(tmp.stack)
+	s.pos = tmp.pos
+	s.rules = slices.Clone(tmp.rules)
+
+	return true
+}
@@ -0,0 +1,164 @@
+package backup
+
+import (
+	"errors"
+	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
+	"github.com/dustin/go-humanize"
+)
+
+var (
+	// UnexpectedEvent is an error that is returned when a recorded event is
+	// not one of the events proposed by the subject. Readers must return this
+	// error as is and not wrap it as callers are expected to check for this
+	// error using ==.
+	UnexpectedEvent error
+
+	// SubjectError is an error that is returned when the subject enters an
+	// error state while replaying a history. Readers must return this error
+	// as is and not wrap it as callers are expected to check for this error
+	// using ==.
+	SubjectError error
+)
+
+func init() {
+	UnexpectedEvent = errors.New("event was not proposed by the subject")
+	SubjectError = errors.New("subject is in an error state")
+}
+
+// ErrDivergence is an error that is returned when a recorded history diverges
+// from the behavior of the subject.
+type ErrDivergence struct {
+	// Index is the index of the event at which the history diverges.
+	Index int
+
+	// Reason is the reason of the divergence.
+	Reason error
+}
+
+// Error implements the error interface.
+//
+// Message: "history diverges at the <ordinal> event: <reason>"
+func (e ErrDivergence) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString("history diverges at the ")
+	builder.WriteString(humanize.Ordinal(e.Index + 1))
+	builder.WriteString(" event")
+
+	if e.Reason != nil {
+		builder.WriteString(": ")
+		builder.WriteString(e.Reason.Error())
+	}
+
+	return builder.String()
+}
+
+// Unwrap returns the reason of the divergence.
+//
+// Returns:
+//   - error: The reason of the divergence.
+func (e ErrDivergence) Unwrap() error {
+	return e.Reason
+}
+
+// NewErrDivergence creates a new ErrDivergence error.
+//
+// Parameters:
+//   - index: The index of the event at which the history diverges.
+//   - reason: The reason of the divergence.
+//
+// Returns:
+//   - *ErrDivergence: The new error. Never returns nil.
+func NewErrDivergence(index int, reason error) *ErrDivergence {
+	return &ErrDivergence{
+		Index:  index,
+		Reason: reason,
+	}
+}
+
+// Replay replays a recorded history into a subject from the start of the
+// timeline and reports where the history diverges, if it does.
+//
+// Before every event, the subject is asked for its next events and the
+// recorded event must be one of them.
+//
+// Parameters:
+//   - history: The recorded history. Its cursor is left untouched.
+//   - subject: The subject to replay the history into.
+//
+// Returns:
+//   - error: An error if the history diverges.
+//
+// Errors:
+//   - *errors.Err: If the history is nil.
+//   - *ErrDivergence: If the history diverges. Its reason is either
+//     UnexpectedEvent, SubjectError or InvalidHistory.
+func Replay[T comparable, S interface {
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](history *History[T], subject S) error {
+	eq := func(a, b T) bool {
+		return a == b
+	}
+
+	return ReplayFunc(history, subject, eq)
+}
+
+// ReplayFunc is like Replay but uses the given function to compare events.
+//
+// Parameters:
+//   - history: The recorded history. Its cursor is left untouched.
+//   - subject: The subject to replay the history into.
+//   - eq: The function that tells whether two events are equal.
+//
+// Returns:
+//   - error: An error if the history diverges.
+//
+// Errors:
+//   - *errors.Err: If the history or the function are nil.
+//   - *ErrDivergence: If the history diverges. Its reason is either
+//     UnexpectedEvent, SubjectError or InvalidHistory.
+func ReplayFunc[T any, S interface {
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](history *History[T], subject S, eq func(a, b T) bool) error {
+	if history == nil {
+		return gers.NewErrNilParameter("history")
+	} else if eq == nil {
+		return gers.NewErrNilParameter("eq")
+	}
+
+	for i, event := range history.timeline {
+		nexts := subject.DetermineNextEvents()
+		if subject.HasError() {
+			return NewErrDivergence(i, SubjectError)
+		}
+
+		var found bool
+
+		for _, next := range nexts {
+			if eq(next, event) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return NewErrDivergence(i, UnexpectedEvent)
+		}
+
+		done := subject.ApplyEvent(event)
+		if subject.HasError() {
+			return NewErrDivergence(i, SubjectError)
+		} else if done && i < len(history.timeline)-1 {
+			// The history diverges at the first event past the end.
+			return NewErrDivergence(i+1, InvalidHistory)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package backup
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	var n int
+
+	h := &History[int]{}
+	h.AddEvent(0)
+	h.AddEvent(1)
+	h.AddEvent(1)
+
+	err := Replay(h, &MockSubject{applied: &n})
+	if err != nil {
+		t.Errorf("expected no error, got %s instead", err.Error())
+	}
+
+	h.AddEvent(0)
+
+	err = Replay(h, &MockSubject{applied: &n})
+
+	var div *ErrDivergence
+	if !errors.As(err, &div) || div.Index != 3 || div.Reason != InvalidHistory {
+		t.Errorf("expected divergence at index 3, got %v instead", err)
+	}
+
+	h = &History[int]{}
+	h.AddEvent(2)
+
+	err = Replay(h, &MockSubject{applied: &n})
+	if !errors.As(err, &div) || div.Index != 0 || div.Reason != UnexpectedEvent {
+		t.Errorf("expected unexpected event at index 0, got %v instead", err)
+	}
+}
@@ -0,0 +1,341 @@
+package backup
+
+import (
+	"math/rand/v2"
+	"strconv"
+
+	gch "github.com/PlayerR9/go-commons/helpers"
+)
+
+// Outcome is the outcome of a rollout.
+type Outcome int
+
+const (
+	// OutcomeValid is the outcome of a rollout whose subject is done or
+	// proposes no more events, as in Subject.
+	OutcomeValid Outcome = iota
+
+	// OutcomeError is the outcome of a rollout whose subject entered an
+	// error state.
+	OutcomeError
+
+	// OutcomeDeadEnd is the outcome of a rollout whose subject proposed events
+	// that all weigh zero, so that none could be picked.
+	OutcomeDeadEnd
+
+	// OutcomeTruncated is the outcome of a rollout that reached the maximum
+	// depth.
+	OutcomeTruncated
+)
+
+// String implements the fmt.Stringer interface.
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeValid:
+		return "valid"
+	case OutcomeError:
+		return "error"
+	case OutcomeDeadEnd:
+		return "dead end"
+	case OutcomeTruncated:
+		return "truncated"
+	default:
+		return "Outcome(" + strconv.Itoa(int(o)) + ")"
+	}
+}
+
+// SampleOption is a type that defines an option of Sample.
+//
+// Parameters:
+//   - s: The settings to modify.
+type SampleOption[T any] func(s *sample_settings[T])
+
+// sample_settings is the settings of Sample.
+type sample_settings[T any] struct {
+	// weight is the weight of the events. Nil for uniform weights.
+	weight gch.WeightFunc[T]
+
+	// max_depth is the maximum number of events of a rollout. 0 for no limit.
+	max_depth int
+
+	// kept is the number of rollouts kept per outcome.
+	kept int
+}
+
+// WithEventWeights makes rollouts pick events proportionally to their weight.
+// Events for which the function returns false or a non-positive weight are
+// never picked. By default, events are picked uniformly.
+//
+// Parameters:
+//   - weight: The weight of the events.
+//
+// Returns:
+//   - SampleOption[T]: The option.
+func WithEventWeights[T any](weight gch.WeightFunc[T]) SampleOption[T] {
+	return func(s *sample_settings[T]) {
+		s.weight = weight
+	}
+}
+
+// WithMaxDepth stops rollouts after the given number of events. By default,
+// rollouts run until the subject is done or stuck.
+//
+// Parameters:
+//   - depth: The maximum number of events. Non-positive values mean no limit.
+//
+// Returns:
+//   - SampleOption[T]: The option.
+func WithMaxDepth[T any](depth int) SampleOption[T] {
+	return func(s *sample_settings[T]) {
+		s.max_depth = max(depth, 0)
+	}
+}
+
+// WithSamplesKept sets how many rollouts are kept in the report for each
+// outcome. By default, one rollout per outcome is kept.
+//
+// Parameters:
+//   - count: The number of rollouts kept per outcome. Negative values are
+//     treated as 0.
+//
+// Returns:
+//   - SampleOption[T]: The option.
+func WithSamplesKept[T any](count int) SampleOption[T] {
+	return func(s *sample_settings[T]) {
+		s.kept = max(count, 0)
+	}
+}
+
+// Rollout is a single random walk through the states of a subject.
+type Rollout[T any, S any] struct {
+	// Outcome is the outcome of the rollout.
+	Outcome Outcome
+
+	// History is the sequence of events that was picked.
+	History *History[T]
+
+	// Subject is the subject at the end of the rollout.
+	Subject S
+}
+
+// SampleReport is the report of Sample.
+type SampleReport[T any, S any] struct {
+	// Seed is the seed the rollouts were run with.
+	Seed uint64
+
+	// Rollouts is the number of rollouts that were run.
+	Rollouts int
+
+	// Counts is the number of rollouts per outcome.
+	Counts map[Outcome]int
+
+	// MinDepth is the number of events of the shortest rollout.
+	MinDepth int
+
+	// MaxDepth is the number of events of the longest rollout.
+	MaxDepth int
+
+	// MeanDepth is the average number of events of a rollout.
+	MeanDepth float64
+
+	// Samples are the first rollouts of each outcome, in the order they were
+	// run.
+	Samples []*Rollout[T, S]
+}
+
+// Rate returns the fraction of the rollouts that had the given outcome.
+//
+// Parameters:
+//   - outcome: The outcome.
+//
+// Returns:
+//   - float64: The fraction, between 0 and 1. 0 if no rollout was run.
+func (r SampleReport[T, S]) Rate(outcome Outcome) float64 {
+	if r.Rollouts == 0 {
+		return 0
+	}
+
+	return float64(r.Counts[outcome]) / float64(r.Rollouts)
+}
+
+// pick picks one of the events at random.
+//
+// Parameters:
+//   - rng: The random number generator.
+//   - events: The events to pick from. Assumed to be non-empty.
+//   - weight: The weight of the events. Nil for uniform weights.
+//
+// Returns:
+//   - T: The picked event.
+//   - bool: True if an event could be picked, false if every event has a
+//     non-positive weight.
+func pick[T any](rng *rand.Rand, events []T, weight gch.WeightFunc[T]) (T, bool) {
+	if weight == nil {
+		return events[rng.IntN(len(events))], true
+	}
+
+	weights := make([]float64, len(events))
+
+	var total float64
+
+	for i, event := range events {
+		w, ok := weight(event)
+		if ok && w > 0 {
+			weights[i] = w
+			total += w
+		}
+	}
+
+	if total <= 0 {
+		return *new(T), false
+	}
+
+	target := rng.Float64() * total
+
+	last := -1
+
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+
+		last = i
+
+		target -= w
+		if target < 0 {
+			break
+		}
+	}
+
+	return events[last], true
+}
+
+// Sample explores the states of a subject by running random rollouts instead
+// of enumerating every state. At every branch point, one of the proposed
+// events is picked at random.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - rollouts: The number of rollouts to run.
+//   - seed: The seed of the random number generator. The same seed always
+//     yields the same report for deterministic subjects.
+//   - opts: The options of the exploration.
+//
+// Returns:
+//   - *SampleReport[T, S]: The report. Never returns nil.
+//
+// If the function 'init_fn' is nil, it defaults to 'var subject S'.
+func Sample[T any, S interface {
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](init_fn func() S, rollouts int, seed uint64, opts ...SampleOption[T]) *SampleReport[T, S] {
+	if init_fn == nil {
+		init_fn = func() S {
+			return *new(S)
+		}
+	}
+
+	settings := sample_settings[T]{
+		kept: 1,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+
+	report := &SampleReport[T, S]{
+		Seed:   seed,
+		Counts: make(map[Outcome]int),
+	}
+
+	var total_depth int
+
+	for i := 0; i < rollouts; i++ {
+		r := rollout(init_fn(), rng, settings)
+
+		depth := len(r.History.timeline)
+		total_depth += depth
+
+		if report.Rollouts == 0 || depth < report.MinDepth {
+			report.MinDepth = depth
+		}
+
+		if depth > report.MaxDepth {
+			report.MaxDepth = depth
+		}
+
+		report.Rollouts++
+		report.Counts[r.Outcome]++
+
+		if report.Counts[r.Outcome] <= settings.kept {
+			report.Samples = append(report.Samples, r)
+		}
+	}
+
+	if report.Rollouts > 0 {
+		report.MeanDepth = float64(total_depth) / float64(report.Rollouts)
+	}
+
+	return report
+}
+
+// rollout runs a single rollout.
+//
+// Parameters:
+//   - subject: The subject to run the rollout on.
+//   - rng: The random number generator.
+//   - settings: The settings of the exploration.
+//
+// Returns:
+//   - *Rollout[T, S]: The rollout. Never returns nil.
+func rollout[T any, S interface {
+	ApplyEvent(event T) bool
+	DetermineNextEvents() []T
+	HasError() bool
+}](subject S, rng *rand.Rand, settings sample_settings[T]) *Rollout[T, S] {
+	r := &Rollout[T, S]{
+		History: &History[T]{},
+		Subject: subject,
+	}
+
+	for {
+		if settings.max_depth > 0 && len(r.History.timeline) >= settings.max_depth {
+			r.Outcome = OutcomeTruncated
+			break
+		}
+
+		events := subject.DetermineNextEvents()
+		if subject.HasError() {
+			r.Outcome = OutcomeError
+			break
+		}
+
+		if len(events) == 0 {
+			r.Outcome = OutcomeValid
+			break
+		}
+
+		event, ok := pick(rng, events, settings.weight)
+		if !ok {
+			r.Outcome = OutcomeDeadEnd
+			break
+		}
+
+		r.History.AddEvent(event)
+		r.History.current++
+
+		done := subject.ApplyEvent(event)
+		if subject.HasError() {
+			r.Outcome = OutcomeError
+			break
+		} else if done {
+			r.Outcome = OutcomeValid
+			break
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSampleReproducible(t *testing.T) {
+	var n int
+
+	init_fn := func() *MockSubject {
+		return &MockSubject{applied: &n}
+	}
+
+	first := Sample(init_fn, 50, 42, WithSamplesKept[int](50))
+	second := Sample(init_fn, 50, 42, WithSamplesKept[int](50))
+
+	if first.Counts[OutcomeValid] != 50 || first.MeanDepth != 3 {
+		t.Errorf("expected 50 valid rollouts of depth 3, got %v with mean %f instead", first.Counts, first.MeanDepth)
+	}
+
+	if len(first.Samples) != len(second.Samples) {
+		t.Fatalf("expected %d samples, got %d instead", len(first.Samples), len(second.Samples))
+	}
+
+	for i := range first.Samples {
+		if !slices.Equal(first.Samples[i].History.timeline, second.Samples[i].History.timeline) {
+			t.Errorf("expected rollout %d to be reproducible", i)
+		}
+	}
+}
+
+func TestSampleWeights(t *testing.T) {
+	var n int
+
+	weight := func(event int) (float64, bool) {
+		return float64(event), true
+	}
+
+	report := Sample(func() *MockSubject {
+		return &MockSubject{applied: &n}
+	}, 10, 7, WithEventWeights(weight))
+
+	if report.Rate(OutcomeValid) != 1 || len(report.Samples) != 1 {
+		t.Fatalf("expected every rollout to be valid, got %v instead", report.Counts)
+	}
+
+	if report.Samples[0].Subject.String() != "111" {
+		t.Errorf("expected %q, got %q instead", "111", report.Samples[0].Subject.String())
+	}
+}
+
+func TestSampleOutcomes(t *testing.T) {
+	report := Sample(func() *MockExhaust {
+		return &MockExhaust{}
+	}, 10, 1)
+
+	if report.Rate(OutcomeValid) != 1 {
+		t.Errorf("expected subjects that run out of events to be valid, got %v instead", report.Counts)
+	}
+
+	zero := func(event int) (float64, bool) {
+		return 0, true
+	}
+
+	report = Sample(func() *MockExhaust {
+		return &MockExhaust{}
+	}, 10, 1, WithEventWeights(zero))
+
+	if report.Rate(OutcomeDeadEnd) != 1 {
+		t.Errorf("expected rollouts without weighted events to be dead ends, got %v instead", report.Counts)
+	}
+}
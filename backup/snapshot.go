@@ -0,0 +1,133 @@
+package backup
+
+// Snapshotter is an optional interface that subjects can implement to let the
+// explorer resume alternative branches from a checkpoint instead of replaying
+// the whole history from scratch.
+type Snapshotter interface {
+	// Snapshot returns a snapshot of the current state of the subject.
+	//
+	// Returns:
+	//   - any: The snapshot. It must not share mutable state with the subject
+	//     as the subject keeps being modified after the snapshot is taken.
+	Snapshot() any
+
+	// Restore restores the state of the subject from a snapshot previously
+	// returned by Snapshot.
+	//
+	// Parameters:
+	//   - snapshot: The snapshot to restore. The same snapshot may be restored
+	//     into several subjects, so it must not be modified.
+	//
+	// Returns:
+	//   - bool: True if the subject was restored, false otherwise. On failure,
+	//     the subject must be left untouched.
+	Restore(snapshot any) bool
+}
+
+// checkpoint is an alternative history that is yet to be explored.
+type checkpoint[T any] struct {
+	// history is the alternative history.
+	history *History[T]
+
+	// snapshot is the snapshot of the subject at the branch point. Nil if
+	// the subject does not implement the Snapshotter interface.
+	snapshot any
+
+	// node is the node of the explored tree that the checkpoint leads to.
+	// Nil if the exploration is not traced.
+	node *TraceNode[T]
+}
+
+// new_checkpoints creates the checkpoints of the alternative histories.
+//
+// Parameters:
+//   - histories: The alternative histories. Their cursor must point to the
+//     branch point.
+//   - subject: The subject at the branch point.
+//   - parent: The node of the explored tree at the branch point.
+//
+// Returns:
+//   - []*checkpoint[T]: The checkpoints.
+//
+// If the subject does not implement the Snapshotter interface, the histories
+// are restarted so that they are replayed from scratch.
+func new_checkpoints[T any](histories []*History[T], subject any, parent *TraceNode[T]) []*checkpoint[T] {
+	if len(histories) == 0 {
+		return nil
+	}
+
+	var snapshot any
+
+	s, ok := subject.(Snapshotter)
+	if ok {
+		snapshot = s.Snapshot()
+	}
+
+	checkpoints := make([]*checkpoint[T], 0, len(histories))
+
+	for _, h := range histories {
+		if snapshot == nil {
+			h.Restart()
+		}
+
+		event := h.timeline[len(h.timeline)-1]
+
+		checkpoints = append(checkpoints, &checkpoint[T]{
+			history:  h,
+			snapshot: snapshot,
+			node:     parent.add_child(event, NodePruned),
+		})
+	}
+
+	return checkpoints
+}
+
+// resume prepares the subject so that it can continue the checkpoint.
+//
+// Parameters:
+//   - subject: The freshly created subject.
+//
+// If the snapshot cannot be restored, the history is restarted and the subject
+// falls back to replaying the whole history.
+func (c *checkpoint[T]) resume(subject any) {
+	if c.snapshot == nil {
+		return
+	}
+
+	s, ok := subject.(Snapshotter)
+	if !ok || !s.Restore(c.snapshot) {
+		c.history.Restart()
+	}
+}
+
+// fork creates a new subject in the same state as another one.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject.
+//   - history: The history that leads to the state. It is not modified.
+//   - snapshot: The snapshot of the state. Nil if there is none.
+//
+// Returns:
+//   - S: The new subject.
+//   - bool: True if the subject could be aligned, false otherwise.
+//
+// The snapshot is restored if possible; otherwise, the history is replayed
+// through Align.
+func fork[T any, S interface {
+	Align(history *History[T]) bool
+}](init_fn func() S, history *History[T], snapshot any) (S, bool) {
+	sbj := init_fn()
+
+	if snapshot != nil {
+		s, ok := any(sbj).(Snapshotter)
+		if ok && s.Restore(snapshot) {
+			return sbj, true
+		}
+	}
+
+	prefix := history.Copy()
+	prefix.Restart()
+
+	ok := sbj.Align(prefix)
+	return sbj, ok
+}
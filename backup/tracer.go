@@ -0,0 +1,311 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	gcfr "github.com/PlayerR9/go-commons/Formatting/runes"
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+// NodeStatus is the status of a node in the explored tree.
+type NodeStatus int
+
+const (
+	// NodeExplored is the status of a node that was explored and has
+	// children.
+	NodeExplored NodeStatus = iota
+
+	// NodeValid is the status of a node at which a valid subject was found.
+	NodeValid
+
+	// NodeInvalid is the status of a node at which an invalid subject was
+	// found.
+	NodeInvalid
+
+	// NodePruned is the status of a node that was never explored.
+	NodePruned
+)
+
+// String implements the fmt.Stringer interface.
+func (s NodeStatus) String() string {
+	switch s {
+	case NodeExplored:
+		return "explored"
+	case NodeValid:
+		return "valid"
+	case NodeInvalid:
+		return "invalid"
+	case NodePruned:
+		return "pruned"
+	default:
+		return "NodeStatus(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// TraceNode is a node of the explored tree.
+type TraceNode[T any] struct {
+	// Event is the event on the edge from the parent. Zero for the root.
+	Event T
+
+	// Status is the status of the node.
+	Status NodeStatus
+
+	// Children are the children of the node, in the order they were found.
+	Children []*TraceNode[T]
+}
+
+// add_child adds a child to the node. Does nothing if the receiver is nil.
+//
+// Parameters:
+//   - event: The event on the edge to the child.
+//   - status: The initial status of the child.
+//
+// Returns:
+//   - *TraceNode[T]: The child. Nil if the receiver is nil.
+func (n *TraceNode[T]) add_child(event T, status NodeStatus) *TraceNode[T] {
+	if n == nil {
+		return nil
+	}
+
+	child := &TraceNode[T]{
+		Event:  event,
+		Status: status,
+	}
+
+	n.Children = append(n.Children, child)
+
+	return child
+}
+
+// set_status sets the status of the node. Does nothing if the receiver is nil.
+//
+// Parameters:
+//   - status: The new status.
+func (n *TraceNode[T]) set_status(status NodeStatus) {
+	if n == nil {
+		return
+	}
+
+	n.Status = status
+}
+
+// Tracer records the tree explored by TracedSubject.
+type Tracer[T any] struct {
+	// root is the root of the explored tree.
+	root *TraceNode[T]
+
+	// label is the function that turns events into labels.
+	label func(event T) string
+}
+
+// NewTracer creates a new tracer.
+//
+// Parameters:
+//   - label: The function that turns events into labels. If nil, events are
+//     formatted with the %v verb.
+//
+// Returns:
+//   - *Tracer[T]: The new tracer. Never returns nil.
+func NewTracer[T any](label func(event T) string) *Tracer[T] {
+	if label == nil {
+		label = func(event T) string {
+			return fmt.Sprintf("%v", event)
+		}
+	}
+
+	return &Tracer[T]{
+		label: label,
+	}
+}
+
+// Root returns the root of the last explored tree.
+//
+// Returns:
+//   - *TraceNode[T]: The root. Nil if nothing was explored yet.
+func (t *Tracer[T]) Root() *TraceNode[T] {
+	if t == nil {
+		return nil
+	}
+
+	return t.root
+}
+
+// reset discards the recorded tree and starts a new one. Does nothing if the
+// receiver is nil.
+//
+// Returns:
+//   - *TraceNode[T]: The new root. Nil if the receiver is nil.
+func (t *Tracer[T]) reset() *TraceNode[T] {
+	if t == nil {
+		return nil
+	}
+
+	t.root = &TraceNode[T]{
+		Status: NodeExplored,
+	}
+
+	return t.root
+}
+
+// WriteDOT writes the explored tree in the Graphviz DOT format.
+//
+// Parameters:
+//   - w: The writer to write to.
+//
+// Returns:
+//   - error: An error if the tree could not be written.
+//
+// Errors:
+//   - io.ErrShortWrite if the writer is nil or the data is not fully written.
+//   - any other error returned by the writer.
+func (t *Tracer[T]) WriteDOT(w io.Writer) error {
+	err := gcstr.Write(w, "digraph history {\n\tnode [shape=circle, label=\"\"];\n")
+	if err != nil {
+		return err
+	}
+
+	if t != nil && t.root != nil {
+		var id int
+
+		err = t.write_dot_node(w, t.root, &id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return gcstr.Write(w, "}\n")
+}
+
+// write_dot_node writes a node and its subtree in the DOT format.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - node: The node to write.
+//   - id: The counter used to name the nodes.
+//
+// Returns:
+//   - error: An error if the node could not be written.
+func (t *Tracer[T]) write_dot_node(w io.Writer, node *TraceNode[T], id *int) error {
+	name := "n" + strconv.Itoa(*id)
+	*id++
+
+	var attrs string
+
+	switch node.Status {
+	case NodeValid:
+		attrs = "style=filled, fillcolor=palegreen"
+	case NodeInvalid:
+		attrs = "style=filled, fillcolor=salmon"
+	case NodePruned:
+		attrs = "style=dashed, color=gray"
+	default:
+		attrs = "shape=point"
+	}
+
+	err := gcstr.Write(w, fmt.Sprintf("\t%s [%s, tooltip=%q];\n", name, attrs, node.Status.String()))
+	if err != nil {
+		return err
+	}
+
+	for _, child := range node.Children {
+		child_name := "n" + strconv.Itoa(*id)
+
+		err := gcstr.Write(w, fmt.Sprintf("\t%s -> %s [label=%s];\n", name, child_name, strconv.Quote(t.label(child.Event))))
+		if err != nil {
+			return err
+		}
+
+		err = t.write_dot_node(w, child, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TextTree draws the explored tree with box-drawing characters. For example:
+//
+//	●
+//	├── a
+//	│   ├── b [valid]
+//	│   └── c [invalid]
+//	└── d [pruned]
+//
+// Parameters:
+//   - style: The style of the lines. If nil, the default box style is used.
+//
+// Returns:
+//   - *gcfr.RuneTable: The drawn tree. Never returns nil.
+func (t *Tracer[T]) TextTree(style *gcfr.BoxStyle) *gcfr.RuneTable {
+	if style == nil {
+		style = gcfr.DefaultBoxStyle
+	}
+
+	var table gcfr.RuneTable
+
+	if t == nil || t.root == nil {
+		return &table
+	}
+
+	root := []rune("●")
+	if t.root.Status != NodeExplored {
+		root = append(root, []rune(" ["+t.root.Status.String()+"]")...)
+	}
+
+	lines := [][]rune{root}
+	lines = t.text_children(lines, t.root, nil, style)
+
+	_ = table.FromRunes(lines)
+
+	return &table
+}
+
+// text_children draws the children of a node.
+//
+// Parameters:
+//   - lines: The lines drawn so far.
+//   - node: The node whose children to draw.
+//   - prefix: The prefix of the lines of the children.
+//   - style: The style of the lines.
+//
+// Returns:
+//   - [][]rune: The lines drawn so far.
+func (t *Tracer[T]) text_children(lines [][]rune, node *TraceNode[T], prefix []rune, style *gcfr.BoxStyle) [][]rune {
+	horizontal := style.TopBorder()
+
+	for i, child := range node.Children {
+		is_last := i == len(node.Children)-1
+
+		var junction, continuation rune
+
+		if is_last {
+			junction = style.Corners()[2]
+			continuation = ' '
+		} else {
+			junction = style.LeftTee()
+			continuation = style.SideBorder()
+		}
+
+		line := make([]rune, 0, len(prefix)+4)
+		line = append(line, prefix...)
+		line = append(line, junction, horizontal, horizontal, ' ')
+		line = append(line, []rune(t.label(child.Event))...)
+
+		if child.Status != NodeExplored {
+			line = append(line, []rune(" ["+child.Status.String()+"]")...)
+		}
+
+		lines = append(lines, line)
+
+		child_prefix := make([]rune, 0, len(prefix)+4)
+		child_prefix = append(child_prefix, prefix...)
+		child_prefix = append(child_prefix, continuation, ' ', ' ', ' ')
+
+		lines = t.text_children(lines, child, child_prefix, style)
+	}
+
+	return lines
+}
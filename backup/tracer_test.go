@@ -0,0 +1,43 @@
+package backup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	var n int
+
+	tracer := NewTracer[int](nil)
+
+	for range TracedSubject[int](func() *MockSubject {
+		return &MockSubject{applied: &n}
+	}, tracer) {
+		break
+	}
+
+	const expected = "●\n" +
+		"├── 0\n" +
+		"│   ├── 0\n" +
+		"│   │   ├── 0 [valid]\n" +
+		"│   │   └── 1 [pruned]\n" +
+		"│   └── 1 [pruned]\n" +
+		"└── 1 [pruned]"
+
+	got := tracer.TextTree(nil).String()
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s\ninstead", expected, got)
+	}
+
+	var builder strings.Builder
+
+	err := tracer.WriteDOT(&builder)
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	dot := builder.String()
+	if !strings.HasPrefix(dot, "digraph history {") || strings.Count(dot, "->") != 6 {
+		t.Errorf("unexpected DOT output:\n%s", dot)
+	}
+}
@@ -0,0 +1,402 @@
+package backup
+
+import (
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+// Reverter is an optional interface that subjects managed by an UndoManager
+// can implement to revert events in place instead of being rebuilt from
+// scratch.
+type Reverter[T any] interface {
+	// RevertEvent reverts an event that was the last one applied to the
+	// subject.
+	//
+	// Parameters:
+	//   - event: The event to revert.
+	//
+	// Returns:
+	//   - bool: True if the event was reverted, false otherwise. On failure,
+	//     the subject is rebuilt by replaying its history.
+	RevertEvent(event T) bool
+}
+
+// UndoOption is a type that defines an option of an UndoManager.
+//
+// Parameters:
+//   - s: The settings to modify.
+type UndoOption func(s *undo_settings)
+
+// undo_settings is the settings of an UndoManager.
+type undo_settings struct {
+	// keep_branches is true if alternative futures are kept.
+	keep_branches bool
+}
+
+// WithUndoTree makes the manager keep the futures that are abandoned when a
+// new event is done after an undo, like vim's undo tree. By default, the
+// future is truncated.
+//
+// Returns:
+//   - UndoOption: The option.
+func WithUndoTree() UndoOption {
+	return func(s *undo_settings) {
+		s.keep_branches = true
+	}
+}
+
+// undo_node is a node of the undo tree.
+type undo_node[T any] struct {
+	// event is the event that leads to the node. Zero for the root.
+	event T
+
+	// parent is the parent of the node. Nil for the root.
+	parent *undo_node[T]
+
+	// children are the futures of the node.
+	children []*undo_node[T]
+
+	// redo is the index of the child that Redo follows.
+	redo int
+
+	// depth is the number of events from the root to the node.
+	depth int
+}
+
+// UndoManager manages the undo and redo of the events applied to a subject.
+type UndoManager[T any, S interface {
+	ApplyEvent(event T) bool
+	HasError() bool
+}] struct {
+	// init_fn creates a new subject.
+	init_fn func() S
+
+	// subject is the managed subject.
+	subject S
+
+	// root is the root of the undo tree.
+	root *undo_node[T]
+
+	// current is the node the subject is at.
+	current *undo_node[T]
+
+	// checkpoints are the named checkpoints.
+	checkpoints map[string]*undo_node[T]
+
+	// settings are the settings of the manager.
+	settings undo_settings
+}
+
+// NewUndoManager creates a new undo manager.
+//
+// Parameters:
+//   - init_fn: A function that returns a new instance of the subject. It is
+//     also used to rebuild the subject when an event cannot be reverted.
+//   - opts: The options of the manager.
+//
+// Returns:
+//   - *UndoManager[T, S]: The new manager. Never returns nil.
+//
+// If the function 'init_fn' is nil, it defaults to 'var subject S'.
+func NewUndoManager[T any, S interface {
+	ApplyEvent(event T) bool
+	HasError() bool
+}](init_fn func() S, opts ...UndoOption) *UndoManager[T, S] {
+	if init_fn == nil {
+		init_fn = func() S {
+			return *new(S)
+		}
+	}
+
+	var settings undo_settings
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	root := &undo_node[T]{}
+
+	return &UndoManager[T, S]{
+		init_fn:     init_fn,
+		subject:     init_fn(),
+		root:        root,
+		current:     root,
+		checkpoints: make(map[string]*undo_node[T]),
+		settings:    settings,
+	}
+}
+
+// Subject returns the managed subject.
+//
+// Returns:
+//   - S: The subject. The zero value if the receiver is nil.
+func (m *UndoManager[T, S]) Subject() S {
+	if m == nil {
+		return *new(S)
+	}
+
+	return m.subject
+}
+
+// History returns the events that lead to the current state of the subject.
+//
+// Returns:
+//   - *History[T]: The history, with its cursor at the end. Never returns nil.
+func (m *UndoManager[T, S]) History() *History[T] {
+	if m == nil {
+		return &History[T]{}
+	}
+
+	timeline := make([]T, m.current.depth)
+
+	for node := m.current; node.parent != nil; node = node.parent {
+		timeline[node.depth-1] = node.event
+	}
+
+	return &History[T]{
+		timeline: timeline,
+		current:  len(timeline),
+	}
+}
+
+// rebuild rebuilds the subject from scratch by replaying the current history.
+func (m *UndoManager[T, S]) rebuild() {
+	m.subject = m.init_fn()
+
+	for _, event := range m.History().timeline {
+		_ = m.subject.ApplyEvent(event)
+		if m.subject.HasError() {
+			break
+		}
+	}
+}
+
+// Do applies an event to the subject and records it. Unless the manager keeps
+// an undo tree, the future is truncated.
+//
+// Parameters:
+//   - event: The event to apply.
+//
+// Returns:
+//   - error: An error if the subject rejected the event, in which case it is
+//     rolled back and nothing is recorded.
+//
+// Errors:
+//   - *errors.Err: If the receiver is nil or the subject is in an error state
+//     after applying the event.
+func (m *UndoManager[T, S]) Do(event T) error {
+	if m == nil {
+		return gers.NewErrNilParameter("UndoManager")
+	}
+
+	_ = m.subject.ApplyEvent(event)
+	if m.subject.HasError() {
+		m.rebuild()
+
+		return gerr.New(gers.OperationFail, "subject rejected the event")
+	}
+
+	if !m.settings.keep_branches {
+		m.discard_future(m.current)
+	}
+
+	node := &undo_node[T]{
+		event:  event,
+		parent: m.current,
+		depth:  m.current.depth + 1,
+	}
+
+	m.current.children = append(m.current.children, node)
+	m.current.redo = len(m.current.children) - 1
+	m.current = node
+
+	return nil
+}
+
+// discard_future removes the children of a node along with the checkpoints
+// that point into them.
+//
+// Parameters:
+//   - node: The node whose future to discard.
+func (m *UndoManager[T, S]) discard_future(node *undo_node[T]) {
+	if len(node.children) == 0 {
+		return
+	}
+
+	for name, cp := range m.checkpoints {
+		for n := cp; n != nil; n = n.parent {
+			if n.parent == node {
+				delete(m.checkpoints, name)
+				break
+			}
+		}
+	}
+
+	node.children = nil
+	node.redo = 0
+}
+
+// CanUndo checks whether there is an event to undo.
+//
+// Returns:
+//   - bool: True if there is an event to undo, false otherwise.
+func (m *UndoManager[T, S]) CanUndo() bool {
+	return m != nil && m.current.parent != nil
+}
+
+// CanRedo checks whether there is an event to redo.
+//
+// Returns:
+//   - bool: True if there is an event to redo, false otherwise.
+func (m *UndoManager[T, S]) CanRedo() bool {
+	return m != nil && len(m.current.children) > 0
+}
+
+// Undo reverts the last event. If the subject implements the Reverter
+// interface, the event is reverted in place; otherwise, the subject is rebuilt
+// by replaying its history.
+//
+// Returns:
+//   - bool: True if an event was undone, false otherwise.
+func (m *UndoManager[T, S]) Undo() bool {
+	if !m.CanUndo() {
+		return false
+	}
+
+	node := m.current
+	m.current = node.parent
+
+	for i, child := range m.current.children {
+		if child == node {
+			m.current.redo = i
+			break
+		}
+	}
+
+	r, ok := any(m.subject).(Reverter[T])
+	if !ok || !r.RevertEvent(node.event) || m.subject.HasError() {
+		m.rebuild()
+	}
+
+	return true
+}
+
+// Redo reapplies the last undone event, following the most recently
+// visited branch.
+//
+// Returns:
+//   - bool: True if an event was redone, false otherwise.
+func (m *UndoManager[T, S]) Redo() bool {
+	if !m.CanRedo() {
+		return false
+	}
+
+	node := m.current.children[m.current.redo]
+	m.current = node
+
+	_ = m.subject.ApplyEvent(node.event)
+	if m.subject.HasError() {
+		m.rebuild()
+	}
+
+	return true
+}
+
+// Branches returns the number of futures that can be redone from the current
+// state. It is only greater than one when the manager keeps an undo tree.
+//
+// Returns:
+//   - int: The number of futures.
+func (m *UndoManager[T, S]) Branches() int {
+	if m == nil {
+		return 0
+	}
+
+	return len(m.current.children)
+}
+
+// SelectBranch selects the future that Redo follows.
+//
+// Parameters:
+//   - idx: The index of the future, from the oldest to the newest.
+//
+// Returns:
+//   - bool: True if the future exists, false otherwise.
+func (m *UndoManager[T, S]) SelectBranch(idx int) bool {
+	if m == nil || idx < 0 || idx >= len(m.current.children) {
+		return false
+	}
+
+	m.current.redo = idx
+
+	return true
+}
+
+// Mark records a named checkpoint at the current state. An existing
+// checkpoint with the same name is overwritten. Does nothing if the receiver
+// is nil.
+//
+// Parameters:
+//   - name: The name of the checkpoint.
+func (m *UndoManager[T, S]) Mark(name string) {
+	if m == nil {
+		return
+	}
+
+	m.checkpoints[name] = m.current
+}
+
+// GoTo undoes and redoes events until the subject is at the given checkpoint.
+//
+// Parameters:
+//   - name: The name of the checkpoint.
+//
+// Returns:
+//   - error: An error if the checkpoint does not exist.
+//
+// Errors:
+//   - *errors.Err: If the receiver is nil or the checkpoint does not exist.
+//     Checkpoints in a truncated future no longer exist.
+func (m *UndoManager[T, S]) GoTo(name string) error {
+	if m == nil {
+		return gers.NewErrNilParameter("UndoManager")
+	}
+
+	target, ok := m.checkpoints[name]
+	if !ok {
+		return gers.NewErrNoSuchKey(name)
+	}
+
+	var path []*undo_node[T]
+
+	ancestor := target
+
+	for ancestor.depth > m.current.depth {
+		path = append(path, ancestor)
+		ancestor = ancestor.parent
+	}
+
+	for m.current.depth > ancestor.depth {
+		_ = m.Undo()
+	}
+
+	for m.current != ancestor {
+		_ = m.Undo()
+
+		path = append(path, ancestor)
+		ancestor = ancestor.parent
+	}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		for j, child := range m.current.children {
+			if child == path[i] {
+				m.current.redo = j
+				break
+			}
+		}
+
+		_ = m.Redo()
+	}
+
+	return nil
+}
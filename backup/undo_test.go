@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"strings"
+	"testing"
+)
+
+// MockText is a subject that appends strings to a text.
+type MockText struct {
+	// text is the text built so far.
+	text string
+}
+
+func (mt *MockText) ApplyEvent(event string) bool {
+	mt.text += event
+	return false
+}
+
+func (mt *MockText) HasError() bool {
+	return strings.Contains(mt.text, "!")
+}
+
+// MockRevertText is a MockText that implements the Reverter interface.
+type MockRevertText struct {
+	MockText
+
+	// reverted counts the number of reverted events.
+	reverted int
+}
+
+func (mt *MockRevertText) RevertEvent(event string) bool {
+	if !strings.HasSuffix(mt.text, event) {
+		return false
+	}
+
+	mt.text = strings.TrimSuffix(mt.text, event)
+	mt.reverted++
+
+	return true
+}
+
+func TestUndoManager(t *testing.T) {
+	m := NewUndoManager[string](func() *MockText {
+		return &MockText{}
+	})
+
+	_ = m.Do("a")
+	_ = m.Do("b")
+	m.Mark("ab")
+	_ = m.Do("c")
+
+	if !m.Undo() || !m.Undo() || m.Subject().text != "a" {
+		t.Fatalf("expected %q, got %q instead", "a", m.Subject().text)
+	}
+
+	if !m.Redo() || m.Subject().text != "ab" {
+		t.Fatalf("expected %q, got %q instead", "ab", m.Subject().text)
+	}
+
+	_ = m.Undo()
+	_ = m.Do("x")
+
+	if m.CanRedo() {
+		t.Errorf("expected the future to be truncated")
+	}
+
+	err := m.GoTo("ab")
+	if err == nil {
+		t.Errorf("expected the checkpoint to be discarded")
+	}
+
+	err = m.Do("!")
+	if err == nil || m.Subject().text != "ax" {
+		t.Errorf("expected the event to be rejected, got %q instead", m.Subject().text)
+	}
+}
+
+func TestUndoTree(t *testing.T) {
+	m := NewUndoManager[string](func() *MockRevertText {
+		return &MockRevertText{}
+	}, WithUndoTree())
+
+	_ = m.Do("a")
+	_ = m.Do("b")
+	m.Mark("ab")
+	_ = m.Undo()
+	_ = m.Do("c")
+	m.Mark("ac")
+
+	if m.Subject().reverted != 1 {
+		t.Errorf("expected 1 reverted event, got %d instead", m.Subject().reverted)
+	}
+
+	_ = m.Undo()
+
+	if m.Branches() != 2 {
+		t.Fatalf("expected 2 branches, got %d instead", m.Branches())
+	}
+
+	_ = m.Redo()
+
+	if m.Subject().text != "ac" {
+		t.Errorf("expected %q, got %q instead", "ac", m.Subject().text)
+	}
+
+	err := m.GoTo("ab")
+	if err != nil {
+		t.Fatalf("expected no error, got %s instead", err.Error())
+	}
+
+	if m.Subject().text != "ab" {
+		t.Errorf("expected %q, got %q instead", "ab", m.Subject().text)
+	}
+
+	events := m.History().timeline
+	if len(events) != 2 || events[0] != "a" || events[1] != "b" {
+		t.Errorf("expected [a b], got %v instead", events)
+	}
+}
+
+func TestUndoManagerNil(t *testing.T) {
+	var m *UndoManager[string, *MockText]
+
+	if m.Subject() != nil {
+		t.Errorf("expected nil, got %v instead", m.Subject())
+	}
+
+	if m.CanUndo() || m.CanRedo() || m.Branches() != 0 {
+		t.Errorf("expected nothing to undo or redo")
+	}
+
+	if len(m.History().timeline) != 0 {
+		t.Errorf("expected an empty history, got %v instead", m.History().timeline)
+	}
+}
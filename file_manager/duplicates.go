@@ -0,0 +1,594 @@
+package file_manager
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// HashFile hashes the content of a file.
+//
+// Parameters:
+//   - path: The path of the file.
+//   - h: The hash. It is reset first, so it can be reused across files, but
+//     not by several goroutines at once.
+//
+// Returns:
+//   - []byte: The hash of the content.
+//   - error: An error if the file could not be read.
+//
+// Errors:
+//   - *errors.Err: If the hash is nil.
+//   - any error returned by the file system.
+func HashFile(path string, h hash.Hash) ([]byte, error) {
+	if h == nil {
+		return nil, gers.NewErrNilParameter("h")
+	}
+
+	return hash_file(path, h, -1)
+}
+
+// hash_file hashes the start of a file.
+//
+// Parameters:
+//   - path: The path of the file.
+//   - h: The hash.
+//   - limit: The number of bytes to hash. Negative to hash the whole file.
+//
+// Returns:
+//   - []byte: The hash.
+//   - error: An error if the file could not be read.
+func hash_file(path string, h hash.Hash, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var r io.Reader = f
+
+	if limit >= 0 {
+		r = io.LimitReader(f, limit)
+	}
+
+	h.Reset()
+
+	_, err = io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// DuplicateOption is a type that defines an option of FindDuplicates.
+//
+// Parameters:
+//   - s: The settings to modify.
+type DuplicateOption func(s *duplicate_settings)
+
+// duplicate_settings is the settings of FindDuplicates.
+type duplicate_settings struct {
+	// new_hash creates the hashes.
+	new_hash func() hash.Hash
+
+	// partial_size is the number of bytes of the partial hash.
+	partial_size int64
+
+	// min_size is the size of the smallest file compared.
+	min_size int64
+
+	// workers is the number of files hashed at once.
+	workers int
+
+	// walk_opts are the options of the walk.
+	walk_opts []WalkOption
+}
+
+// WithHash sets the hash used to compare contents. By default, SHA-256 is
+// used.
+//
+// Parameters:
+//   - new_hash: The function that creates a hash, such as sha256.New. It is
+//     called once per worker.
+//
+// Returns:
+//   - DuplicateOption: The option.
+func WithHash(new_hash func() hash.Hash) DuplicateOption {
+	return func(s *duplicate_settings) {
+		if new_hash != nil {
+			s.new_hash = new_hash
+		}
+	}
+}
+
+// WithPartialSize sets how many bytes from the start of the files are hashed
+// to rule out most files of the same size before hashing them whole. By
+// default, 4096 bytes are.
+//
+// Parameters:
+//   - n: The number of bytes.
+//
+// Returns:
+//   - DuplicateOption: The option.
+func WithPartialSize(n int64) DuplicateOption {
+	return func(s *duplicate_settings) {
+		s.partial_size = max(n, 1)
+	}
+}
+
+// WithMinSize skips the files smaller than the given size. By default, only
+// empty files are skipped.
+//
+// Parameters:
+//   - n: The size, in bytes.
+//
+// Returns:
+//   - DuplicateOption: The option.
+func WithMinSize(n int64) DuplicateOption {
+	return func(s *duplicate_settings) {
+		s.min_size = max(n, 1)
+	}
+}
+
+// WithHashWorkers sets how many files are hashed at once. By default,
+// runtime.GOMAXPROCS(0) files are.
+//
+// Parameters:
+//   - n: The number of files.
+//
+// Returns:
+//   - DuplicateOption: The option.
+func WithHashWorkers(n int) DuplicateOption {
+	return func(s *duplicate_settings) {
+		s.workers = max(n, 1)
+	}
+}
+
+// WithWalkOptions sets the options of the walk that lists the files, such as
+// WithExclude or WithFileSettings. Ignore files are not honoured unless
+// WithIgnoreFiles is given, and symbolic links are always skipped.
+//
+// Parameters:
+//   - opts: The options of the walk.
+//
+// Returns:
+//   - DuplicateOption: The option.
+func WithWalkOptions(opts ...WalkOption) DuplicateOption {
+	opts = slices.Clone(opts)
+
+	return func(s *duplicate_settings) {
+		s.walk_opts = append(s.walk_opts, opts...)
+	}
+}
+
+// DuplicateGroup is a set of files with the same content.
+type DuplicateGroup struct {
+	// Size is the size of each file.
+	Size int64
+
+	// Hash is the hash of the content. Groups that were not returned by
+	// FindDuplicates must use SHA-256.
+	Hash []byte
+
+	// Paths are the paths of the files, in lexical order. Actions keep the
+	// first one.
+	Paths []string
+
+	// new_hash creates the hash, to check the files again before acting. Nil
+	// for sha256.New.
+	new_hash func() hash.Hash
+}
+
+// Wasted returns the space taken by the copies.
+//
+// Returns:
+//   - int64: The size of every file but one, in bytes.
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Paths)-1)
+}
+
+// FindDuplicates finds the files with the same content under a directory.
+// Files are first grouped by size, then by the hash of their start, and only
+// then by the hash of their whole content, so that most files are never read
+// in full. Files that are already hard links of each other count once.
+//
+// Parameters:
+//   - root: The directory.
+//   - opts: The options.
+//
+// Returns:
+//   - []DuplicateGroup: The groups of two files or more, the ones that waste
+//     the most space first.
+//   - error: An error if some files could not be read. They are left out of
+//     the groups, which are still returned.
+//
+// Errors:
+//   - *errors.Err: If root is empty.
+//   - any error returned by the walk or the file system, joined with
+//     errors.Join.
+func FindDuplicates(root string, opts ...DuplicateOption) ([]DuplicateGroup, error) {
+	if root == "" {
+		return nil, gers.NewErrInvalidParameter("root must not be empty")
+	}
+
+	settings := duplicate_settings{
+		new_hash:     sha256.New,
+		partial_size: 4096,
+		min_size:     1,
+		workers:      runtime.GOMAXPROCS(0),
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	walk_opts := append([]WalkOption{WithIgnoreFiles()}, settings.walk_opts...)
+
+	// Sorting makes the path kept for files that are hard links of each
+	// other deterministic.
+	walk_opts = append(walk_opts, WithSymlinks(SymlinkSkip), WithSorted())
+
+	w, err := NewWalker(os.DirFS(root), ".", walk_opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+
+	by_size := make(map[int64][]os.FileInfo)
+	paths := make(map[int64][]string)
+
+	for e := range w.Entries() {
+		path := filepath.Join(root, filepath.FromSlash(e.Path))
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		} else if !info.Mode().IsRegular() || info.Size() < settings.min_size {
+			continue
+		}
+
+		size := info.Size()
+
+		same := slices.ContainsFunc(by_size[size], func(other os.FileInfo) bool {
+			return os.SameFile(info, other)
+		})
+
+		if !same {
+			by_size[size] = append(by_size[size], info)
+			paths[size] = append(paths[size], path)
+		}
+	}
+
+	errs = append(errs, w.Err())
+
+	var candidates []dup_candidate
+
+	for size, group := range paths {
+		if len(group) > 1 {
+			candidates = append(candidates, dup_candidate{size: size, paths: group})
+		}
+	}
+
+	f := &duplicate_finder{
+		settings: settings,
+		hashes:   make(map[string][]byte),
+	}
+
+	candidates = f.split(candidates, settings.partial_size)
+
+	// Files no bigger than the partial size were hashed whole already.
+	var done, todo []dup_candidate
+
+	for _, c := range candidates {
+		if c.size <= settings.partial_size {
+			done = append(done, c)
+		} else {
+			todo = append(todo, c)
+		}
+	}
+
+	candidates = append(done, f.split(todo, -1)...)
+
+	groups := make([]DuplicateGroup, 0, len(candidates))
+
+	for _, c := range candidates {
+		slices.Sort(c.paths)
+
+		groups = append(groups, DuplicateGroup{
+			Size:     c.size,
+			Hash:     f.hashes[c.paths[0]],
+			Paths:    c.paths,
+			new_hash: settings.new_hash,
+		})
+	}
+
+	slices.SortFunc(groups, func(a, b DuplicateGroup) int {
+		c := cmp.Compare(b.Wasted(), a.Wasted())
+		if c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Paths[0], b.Paths[0])
+	})
+
+	errs = append(errs, f.errs...)
+
+	return groups, errors.Join(errs...)
+}
+
+// dup_candidate is a group of files that may have the same content.
+type dup_candidate struct {
+	// size is the size of each file.
+	size int64
+
+	// paths are the paths of the files.
+	paths []string
+}
+
+// duplicate_finder hashes the candidates of FindDuplicates.
+type duplicate_finder struct {
+	// settings are the settings of the search.
+	settings duplicate_settings
+
+	// mu protects hashes and errs.
+	mu sync.Mutex
+
+	// hashes are the last hash of each file.
+	hashes map[string][]byte
+
+	// errs are the errors of the files that could not be read.
+	errs []error
+}
+
+// split hashes the files of each group in parallel and splits the groups by
+// hash, dropping the files left alone.
+//
+// Parameters:
+//   - groups: The groups of files.
+//   - limit: The number of bytes to hash. Negative to hash the whole files.
+//
+// Returns:
+//   - []dup_candidate: The groups of two files or more with the same hash.
+func (f *duplicate_finder) split(groups []dup_candidate, limit int64) []dup_candidate {
+	jobs := make(chan string)
+
+	var wg sync.WaitGroup
+
+	for range min(f.settings.workers, max(len(groups), 1)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			h := f.settings.new_hash()
+
+			for path := range jobs {
+				sum, err := hash_file(path, h, limit)
+
+				f.mu.Lock()
+
+				if err != nil {
+					// The hash of an earlier pass must not be used instead.
+					delete(f.hashes, path)
+					f.errs = append(f.errs, err)
+				} else {
+					f.hashes[path] = sum
+				}
+
+				f.mu.Unlock()
+			}
+		}()
+	}
+
+	for _, group := range groups {
+		for _, path := range group.paths {
+			jobs <- path
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	var result []dup_candidate
+
+	for _, group := range groups {
+		by_hash := make(map[string][]string)
+
+		var order []string
+
+		for _, path := range group.paths {
+			sum, ok := f.hashes[path]
+			if !ok {
+				continue
+			}
+
+			key := string(sum)
+
+			if _, ok := by_hash[key]; !ok {
+				order = append(order, key)
+			}
+
+			by_hash[key] = append(by_hash[key], path)
+		}
+
+		for _, key := range order {
+			if len(by_hash[key]) > 1 {
+				result = append(result, dup_candidate{size: group.size, paths: by_hash[key]})
+			}
+		}
+	}
+
+	return result
+}
+
+// ErrContentChanged is an error that is returned when a file of a duplicate
+// group no longer has the content of the group.
+type ErrContentChanged struct {
+	// Path is the path of the file.
+	Path string
+}
+
+// Error implements the error interface.
+//
+// Message: "content of <path> changed since it was found"
+func (e ErrContentChanged) Error() string {
+	return "content of " + strconv.Quote(e.Path) + " changed since it was found"
+}
+
+// NewErrContentChanged creates a new ErrContentChanged error.
+//
+// Parameters:
+//   - path: The path of the file.
+//
+// Returns:
+//   - *ErrContentChanged: The new error. Never returns nil.
+func NewErrContentChanged(path string) *ErrContentChanged {
+	return &ErrContentChanged{
+		Path: path,
+	}
+}
+
+// verify checks that a file still has the content of the group.
+//
+// Parameters:
+//   - path: The path of the file.
+//
+// Returns:
+//   - error: An error if the content changed or could not be read.
+func (g DuplicateGroup) verify(path string) error {
+	new_hash := g.new_hash
+	if new_hash == nil {
+		new_hash = sha256.New
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	} else if !info.Mode().IsRegular() || info.Size() != g.Size {
+		return NewErrContentChanged(path)
+	}
+
+	sum, err := hash_file(path, new_hash(), -1)
+	if err != nil {
+		return err
+	} else if !bytes.Equal(sum, g.Hash) {
+		return NewErrContentChanged(path)
+	}
+
+	return nil
+}
+
+// Hardlink replaces every file of the group but the first with a hard link to
+// the first, so that they share their storage. Each file is checked again
+// before it is replaced, and is replaced atomically.
+//
+// Parameters:
+//   - dry_run: True to only report the files that would be replaced.
+//
+// Returns:
+//   - []string: The paths that were, or would be, replaced.
+//   - error: An error if a file could not be replaced. The other files are
+//     still processed.
+//
+// Errors:
+//   - *errors.Err: If the group has no hash.
+//   - *ErrContentChanged: If a file no longer has the content of the group.
+//   - any error returned by the file system, joined with errors.Join.
+func (g DuplicateGroup) Hardlink(dry_run bool) ([]string, error) {
+	return g.act(dry_run, func(keep, path string) error {
+		tmp := path + ".link-" + strconv.Itoa(os.Getpid())
+
+		err := os.Link(keep, tmp)
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(tmp, path)
+		if err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Delete removes every file of the group but the first. Each file is checked
+// again before it is removed.
+//
+// Parameters:
+//   - dry_run: True to only report the files that would be removed.
+//
+// Returns:
+//   - []string: The paths that were, or would be, removed.
+//   - error: An error if a file could not be removed. The other files are
+//     still processed.
+//
+// Errors:
+//   - *errors.Err: If the group has no hash.
+//   - *ErrContentChanged: If a file no longer has the content of the group.
+//   - any error returned by the file system, joined with errors.Join.
+func (g DuplicateGroup) Delete(dry_run bool) ([]string, error) {
+	return g.act(dry_run, func(keep, path string) error {
+		return os.Remove(path)
+	})
+}
+
+// act applies an action to every file of the group but the first.
+//
+// Parameters:
+//   - dry_run: True to only report the files the action would apply to.
+//   - action: The action.
+//
+// Returns:
+//   - []string: The paths the action applied, or would apply, to.
+//   - error: The errors of the files the action failed on.
+func (g DuplicateGroup) act(dry_run bool, action func(keep, path string) error) ([]string, error) {
+	if len(g.Paths) < 2 {
+		return nil, nil
+	} else if len(g.Hash) == 0 {
+		// The files cannot be checked again without it.
+		return nil, gers.NewErrInvalidParameter("group has no hash")
+	}
+
+	keep := g.Paths[0]
+
+	err := g.verify(keep)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	var errs []error
+
+	for _, path := range g.Paths[1:] {
+		err := g.verify(path)
+		if err == nil && !dry_run {
+			err = action(keep, path)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		done = append(done, path)
+	}
+
+	return done, errors.Join(errs...)
+}
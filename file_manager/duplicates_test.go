@@ -0,0 +1,228 @@
+package file_manager
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// make_tree creates files under a temporary directory and returns it.
+func make_tree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		write_file(t, path, data)
+	}
+
+	return root
+}
+
+// TestHashFile tests HashFile with several hashes.
+func TestHashFile(t *testing.T) {
+	root := make_tree(t, map[string]string{"a.txt": "hello"})
+
+	sum, err := HashFile(filepath.Join(root, "a.txt"), sha256.New())
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if hex.EncodeToString(sum) != expected {
+		t.Errorf("expected %s, got %x instead", expected, sum)
+	}
+
+	h := md5.New()
+	h.Write([]byte("leftover"))
+
+	sum, err = HashFile(filepath.Join(root, "a.txt"), h)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected = "5d41402abc4b2a76b9719d911017c592"
+	if hex.EncodeToString(sum) != expected {
+		t.Errorf("expected %s, got %x instead", expected, sum)
+	}
+}
+
+// TestFindDuplicates tests the grouping of the files.
+func TestFindDuplicates(t *testing.T) {
+	big := string(bytes.Repeat([]byte("x"), 100))
+
+	root := make_tree(t, map[string]string{
+		"a/small.txt":  "same",
+		"b/small.txt":  "same",
+		"c/other.txt":  "diff",
+		"big1.bin":     big + "A",
+		"big2.bin":     big + "A",
+		"big3.bin":     big + "B",
+		"empty1":       "",
+		"empty2":       "",
+		"skip/dup.txt": "same",
+	})
+
+	err := os.Link(filepath.Join(root, "big1.bin"), filepath.Join(root, "big1.link"))
+	if err != nil {
+		t.Skipf("hard links are not supported: %v", err)
+	}
+
+	groups, err := FindDuplicates(root,
+		WithPartialSize(10),
+		WithHashWorkers(2),
+		WithWalkOptions(WithExclude("skip")),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("expected %d groups, got %d instead", 2, len(groups))
+	}
+
+	first := []string{filepath.Join(root, "big1.bin"), filepath.Join(root, "big2.bin")}
+	if !slices.Equal(groups[0].Paths, first) {
+		t.Errorf("expected %v, got %v instead", first, groups[0].Paths)
+	}
+
+	if groups[0].Wasted() != 101 {
+		t.Errorf("expected %d, got %d instead", 101, groups[0].Wasted())
+	}
+
+	second := []string{filepath.Join(root, "a/small.txt"), filepath.Join(root, "b/small.txt")}
+	if !slices.Equal(groups[1].Paths, second) {
+		t.Errorf("expected %v, got %v instead", second, groups[1].Paths)
+	}
+}
+
+// TestDuplicateActions tests the dry-run mode and the actions.
+func TestDuplicateActions(t *testing.T) {
+	root := make_tree(t, map[string]string{
+		"b.txt": "content",
+		"a.txt": "content",
+		"c.txt": "content",
+	})
+
+	groups, err := FindDuplicates(root)
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("expected one group, got %v and %v instead", groups, err)
+	}
+
+	g := groups[0]
+
+	// The lexically first file is kept.
+	keep := filepath.Join(root, "a.txt")
+	dup := filepath.Join(root, "b.txt")
+	more := filepath.Join(root, "c.txt")
+
+	paths, err := g.Hardlink(true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if !slices.Equal(paths, []string{dup, more}) {
+		t.Errorf("expected %v, got %v instead", []string{dup, more}, paths)
+	}
+
+	if same_inode(t, keep, dup) {
+		t.Errorf("expected the dry run to leave the files untouched")
+	}
+
+	write_file(t, more, "changed")
+
+	paths, err = g.Hardlink(false)
+
+	var changed *ErrContentChanged
+
+	if !errors.As(err, &changed) || changed.Path != more {
+		t.Errorf("expected an ErrContentChanged for %s, got %v instead", more, err)
+	}
+
+	if !slices.Equal(paths, []string{dup}) || !same_inode(t, keep, dup) {
+		t.Errorf("expected %s to be linked, got %v instead", dup, paths)
+	}
+
+	write_file(t, more, "content")
+
+	paths, err = g.Delete(false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if !slices.Equal(paths, []string{dup, more}) {
+		t.Errorf("expected %v, got %v instead", []string{dup, more}, paths)
+	}
+
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 1 {
+		t.Errorf("expected %d, got %d instead", 1, len(entries))
+	}
+}
+
+// same_inode checks whether two paths are the same file.
+func same_inode(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	ia, err := os.Stat(a)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	ib, err := os.Stat(b)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	return os.SameFile(ia, ib)
+}
+
+// TestDuplicateActionsManual tests that groups built by hand are checked too.
+func TestDuplicateActionsManual(t *testing.T) {
+	root := make_tree(t, map[string]string{
+		"a.txt": "content",
+		"b.txt": "other!!",
+	})
+
+	keep := filepath.Join(root, "a.txt")
+	dup := filepath.Join(root, "b.txt")
+
+	g := DuplicateGroup{
+		Size:  7,
+		Paths: []string{keep, dup},
+	}
+
+	_, err := g.Delete(false)
+	if err == nil {
+		t.Errorf("expected an error for a group without hash, got nil instead")
+	}
+
+	sum := sha256.Sum256([]byte("content"))
+	g.Hash = sum[:]
+
+	paths, err := g.Delete(false)
+
+	var changed *ErrContentChanged
+
+	if !errors.As(err, &changed) || len(paths) != 0 {
+		t.Errorf("expected an ErrContentChanged for %s, got %v instead", dup, err)
+	}
+
+	_, err = os.Stat(dup)
+	if err != nil {
+		t.Errorf("expected %s to be kept, got %v instead", dup, err)
+	}
+}
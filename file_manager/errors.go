@@ -0,0 +1,71 @@
+package file_manager
+
+import (
+	"strconv"
+
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+// ErrInvalidExt is an error that is returned when a file does not have one of
+// the allowed extensions.
+type ErrInvalidExt struct {
+	// Name is the name of the file.
+	Name string
+
+	// Allowed are the allowed extensions.
+	Allowed []string
+}
+
+// Error implements the error interface.
+//
+// Message: "file <name> has an invalid extension: expected <allowed>"
+func (e ErrInvalidExt) Error() string {
+	allowed := make([]string, len(e.Allowed))
+	copy(allowed, e.Allowed)
+
+	gcstr.QuoteStrings(allowed)
+
+	return "file " + strconv.Quote(e.Name) + " has an invalid extension: expected " + gcstr.OrString(allowed, false)
+}
+
+// NewErrInvalidExt creates a new ErrInvalidExt error.
+//
+// Parameters:
+//   - name: The name of the file.
+//   - allowed: The allowed extensions.
+//
+// Returns:
+//   - *ErrInvalidExt: The new error. Never returns nil.
+func NewErrInvalidExt(name string, allowed []string) *ErrInvalidExt {
+	return &ErrInvalidExt{
+		Name:    name,
+		Allowed: allowed,
+	}
+}
+
+// ErrNoExt is an error that is returned when a path is expected to name a file
+// with an extension but has none.
+type ErrNoExt struct {
+	// Path is the path.
+	Path string
+}
+
+// Error implements the error interface.
+//
+// Message: "path <path> has no extension"
+func (e ErrNoExt) Error() string {
+	return "path " + strconv.Quote(e.Path) + " has no extension"
+}
+
+// NewErrNoExt creates a new ErrNoExt error.
+//
+// Parameters:
+//   - path: The path.
+//
+// Returns:
+//   - *ErrNoExt: The new error. Never returns nil.
+func NewErrNoExt(path string) *ErrNoExt {
+	return &ErrNoExt{
+		Path: path,
+	}
+}
@@ -1 +1,225 @@
+// Package file_manager provides helpers to check, list and filter files of an
+// io/fs.FS, such as os.DirFS, embed.FS or fstest.MapFS.
+//
+// Paths are slash-separated, unrooted paths, as in io/fs.
 package file_manager
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// FileExists checks if a file exists.
+//
+// Parameters:
+//   - fsys: The file system.
+//   - name: The path of the file.
+//   - opts: The file settings options.
+//
+// Returns:
+//   - bool: True if the file exists and is allowed by the options, false
+//     otherwise.
+//   - error: An error if the file could not be checked.
+//
+// Errors:
+//   - *errors.Err: If the file system is nil.
+//   - any error returned by fs.Stat other than fs.ErrNotExist.
+//
+// By default, it will allow directories and files with any extension.
+func FileExists(fsys fs.FS, name string, opts ...FileSettingsOption) (bool, error) {
+	if fsys == nil {
+		return false, gers.NewErrNilParameter("fsys")
+	}
+
+	settings := new_file_settings(opts)
+
+	info, err := fs.Stat(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if info.IsDir() {
+		return settings.allow_dir, nil
+	}
+
+	return settings.allow_file && settings.match_ext(info.Name()), nil
+}
+
+// ReadDir reads a directory and returns the paths of the files in it and in
+// its sub-directories, in lexical order.
+//
+// Parameters:
+//   - fsys: The file system.
+//   - root: The directory to read. Use "." for the root of the file system.
+//   - opts: The file settings options. Only the extension options apply, as
+//     directories are never returned.
+//
+// Returns:
+//   - []string: The paths of the files, prefixed with root.
+//   - error: An error if a directory could not be read.
+//
+// Errors:
+//   - *errors.Err: If the file system is nil or root is empty.
+//   - any error returned by fs.WalkDir.
+func ReadDir(fsys fs.FS, root string, opts ...FileSettingsOption) ([]string, error) {
+	if fsys == nil {
+		return nil, gers.NewErrNilParameter("fsys")
+	} else if root == "" {
+		return nil, gers.NewErrInvalidParameter("root must not be empty")
+	}
+
+	settings := new_file_settings(opts)
+
+	var files []string
+
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && settings.match_ext(name) {
+			files = append(files, name)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// FilterFiles returns the paths that are allowed by the options. As only the
+// paths are known, a path with an extension is taken for a file and a path
+// without one for a directory.
+//
+// Parameters:
+//   - files: The list of paths to filter. It is not modified.
+//   - opts: The file settings options.
+//
+// Returns:
+//   - []string: The filtered list of paths. Nil if none is allowed.
+func FilterFiles(files []string, opts ...FileSettingsOption) []string {
+	if len(files) == 0 {
+		return nil
+	}
+
+	settings := new_file_settings(opts)
+
+	var filtered []string
+
+	for _, file := range files {
+		var ok bool
+
+		if path.Ext(file) == "" {
+			ok = settings.allow_dir
+		} else {
+			ok = settings.allow_file && settings.match_ext(file)
+		}
+
+		if ok {
+			filtered = append(filtered, file)
+		}
+	}
+
+	return filtered
+}
+
+// ErrIfInvalidExt returns an error if the file name does not have one of the
+// given extensions.
+//
+// Parameters:
+//   - name: The name of the file.
+//   - opts: The file settings options. Only the extension options apply.
+//
+// Returns:
+//   - error: An error if the file name does not have one of the allowed
+//     extensions.
+//
+// Errors:
+//   - *errors.Err: If the name is empty.
+//   - *ErrInvalidExt: If the name does not have one of the allowed extensions.
+func ErrIfInvalidExt(name string, opts ...FileSettingsOption) error {
+	if name == "" {
+		return gers.NewErrInvalidParameter("name must not be empty")
+	}
+
+	settings := new_file_settings(opts)
+
+	if !settings.match_ext(name) {
+		return NewErrInvalidExt(name, settings.allowed_exts)
+	}
+
+	return nil
+}
+
+// AddSuffixToFileName adds a suffix to a file name, before its extension.
+//
+// Parameters:
+//   - filename: The name of the file.
+//   - new_suffix: The new suffix to add.
+//   - ext: The extension of the file. If not provided, it will be inferred
+//     from the filename.
+//
+// Returns:
+//   - string: The new file name with the suffix added.
+//
+// This function returns the filename as is if either the filename or the
+// suffix is empty.
+func AddSuffixToFileName(filename, new_suffix string, ext string) string {
+	if filename == "" || new_suffix == "" {
+		return filename
+	}
+
+	if ext == "" {
+		ext = path.Ext(filename)
+	}
+
+	return strings.TrimSuffix(filename, ext) + new_suffix + ext
+}
+
+// ModifyPath modifies a path based on the given suffix and sub_directories.
+//
+// Parameters:
+//   - name: The path to modify.
+//   - suffix: The suffix to add before the extension.
+//   - sub_directories: The sub directories to add between the directory and
+//     the file name.
+//
+// Returns:
+//   - string: The modified path.
+//   - error: An error if a suffix is given and the path has no extension.
+//
+// Errors:
+//   - *ErrNoExt: If a suffix is given and the path has no extension.
+//
+// This function returns an empty string if the path is empty.
+func ModifyPath(name, suffix string, sub_directories ...string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	if len(sub_directories) > 0 {
+		dir, file := path.Split(name)
+		name = path.Join(dir, path.Join(sub_directories...), file)
+	}
+
+	if suffix != "" {
+		ext := path.Ext(name)
+		if ext == "" {
+			return "", NewErrNoExt(name)
+		}
+
+		name = AddSuffixToFileName(name, suffix, ext)
+	}
+
+	return name, nil
+}
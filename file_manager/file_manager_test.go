@@ -0,0 +1,150 @@
+package file_manager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// test_fs is the file system used by the tests.
+var test_fs = fstest.MapFS{
+	"main.go":               {Data: []byte("package main")},
+	"README.md":             {Data: []byte("# readme")},
+	"docs/GUIDE.MD":         {Data: []byte("# guide")},
+	"docs/archive.tar.gz":   {Data: []byte{}},
+	"internal/util/util.go": {Data: []byte("package util")},
+	"internal/util/.go":     {Data: []byte{}},
+}
+
+// TestFileExists tests FileExists.
+func TestFileExists(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []FileSettingsOption
+		expected bool
+	}{
+		{"main.go", nil, true},
+		{"missing.go", nil, false},
+		{"docs", nil, true},
+		{"docs", []FileSettingsOption{WithDir(false)}, false},
+		{"main.go", []FileSettingsOption{WithoutFile()}, false},
+		{"main.go", []FileSettingsOption{WithFileExts("md")}, false},
+		{"docs/GUIDE.MD", []FileSettingsOption{WithFileExts(".md")}, false},
+		{"docs/GUIDE.MD", []FileSettingsOption{WithFileExts(".md"), WithCaseInsensitive()}, true},
+		{"docs/archive.tar.gz", []FileSettingsOption{WithFileExts(" tar.gz ")}, true},
+	}
+
+	for _, test := range tests {
+		ok, err := FileExists(test_fs, test.name, test.opts...)
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if ok != test.expected {
+			t.Errorf("%s: expected %t, got %t instead", test.name, test.expected, ok)
+		}
+	}
+}
+
+// TestReadDir tests ReadDir.
+func TestReadDir(t *testing.T) {
+	files, err := ReadDir(test_fs, ".", WithFileExts("GO"), WithCaseInsensitive())
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected := []string{"internal/util/util.go", "main.go"}
+	if !slices.Equal(files, expected) {
+		t.Errorf("expected %v, got %v instead", expected, files)
+	}
+
+	files, err = ReadDir(test_fs, "docs")
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected = []string{"docs/GUIDE.MD", "docs/archive.tar.gz"}
+	if !slices.Equal(files, expected) {
+		t.Errorf("expected %v, got %v instead", expected, files)
+	}
+
+	_, err = ReadDir(test_fs, "missing")
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+}
+
+// TestFilterFiles tests FilterFiles.
+func TestFilterFiles(t *testing.T) {
+	files := []string{"a.go", "b.txt", "dir", "c.Go"}
+
+	filtered := FilterFiles(files, WithDir(false), WithFileExts(".go"))
+
+	expected := []string{"a.go"}
+	if !slices.Equal(filtered, expected) {
+		t.Errorf("expected %v, got %v instead", expected, filtered)
+	}
+
+	filtered = FilterFiles(files, WithFileExts(".go"), WithCaseInsensitive())
+
+	expected = []string{"a.go", "dir", "c.Go"}
+	if !slices.Equal(filtered, expected) {
+		t.Errorf("expected %v, got %v instead", expected, filtered)
+	}
+
+	if files[1] != "b.txt" {
+		t.Errorf("expected the input to be left untouched")
+	}
+}
+
+// TestFixExts tests fix_exts.
+func TestFixExts(t *testing.T) {
+	exts := fix_exts([]string{"go", ".GO", " .md ", "", ".", "a/b", ".go"}, true)
+
+	expected := []string{".go", ".md"}
+	if !slices.Equal(exts, expected) {
+		t.Errorf("expected %v, got %v instead", expected, exts)
+	}
+}
+
+// TestErrIfInvalidExt tests ErrIfInvalidExt.
+func TestErrIfInvalidExt(t *testing.T) {
+	err := ErrIfInvalidExt("main.go", WithFileExts(".go"))
+	if err != nil {
+		t.Errorf("expected no error, got %v instead", err)
+	}
+
+	err = ErrIfInvalidExt("notes.txt", WithFileExts(".go", ".md"))
+
+	var invalid *ErrInvalidExt
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected an ErrInvalidExt, got %v instead", err)
+	}
+
+	expected := []string{".go", ".md"}
+	if !slices.Equal(invalid.Allowed, expected) {
+		t.Errorf("expected %v, got %v instead", expected, invalid.Allowed)
+	}
+}
+
+// TestModifyPath tests ModifyPath.
+func TestModifyPath(t *testing.T) {
+	got, err := ModifyPath("out/report.csv", "_v2", "old", "2024")
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if got != "out/old/2024/report_v2.csv" {
+		t.Errorf("expected %q, got %q instead", "out/old/2024/report_v2.csv", got)
+	}
+
+	_, err = ModifyPath("out/report", "_v2")
+
+	var no_ext *ErrNoExt
+
+	if !errors.As(err, &no_ext) {
+		t.Errorf("expected an ErrNoExt, got %v instead", err)
+	}
+}
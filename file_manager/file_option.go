@@ -0,0 +1,180 @@
+package file_manager
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// FileSettingsOption is a type that defines a file settings option.
+//
+// Parameters:
+//   - fs: The file settings.
+type FileSettingsOption func(fs *FileSettings)
+
+// WithDir allows directories.
+//
+// Parameters:
+//   - allow_dir: Whether to allow directories.
+//
+// Returns:
+//   - FileSettingsOption: The file settings option.
+func WithDir(allow_dir bool) FileSettingsOption {
+	return func(fs *FileSettings) {
+		fs.allow_dir = allow_dir
+	}
+}
+
+// WithoutFile disallows files.
+//
+// Returns:
+//   - FileSettingsOption: The file settings option.
+func WithoutFile() FileSettingsOption {
+	return func(fs *FileSettings) {
+		fs.allow_file = false
+	}
+}
+
+// WithFileExts allows files with one of the given extensions. Extensions may
+// be given with or without their leading dot and may have several parts, such
+// as ".tar.gz". Empty extensions are ignored. Leave empty to allow all
+// extensions.
+//
+// Parameters:
+//   - exts: The allowed extensions.
+//
+// Returns:
+//   - FileSettingsOption: The file settings option.
+func WithFileExts(exts ...string) FileSettingsOption {
+	exts = slices.Clone(exts)
+
+	return func(fs *FileSettings) {
+		fs.allow_file = true
+		fs.allowed_exts = exts
+	}
+}
+
+// WithCaseInsensitive matches extensions regardless of case, so that ".go"
+// also allows "MAIN.GO".
+//
+// Returns:
+//   - FileSettingsOption: The file settings option.
+func WithCaseInsensitive() FileSettingsOption {
+	return func(fs *FileSettings) {
+		fs.fold = true
+	}
+}
+
+// FileSettings is the settings for the file manager.
+type FileSettings struct {
+	// allow_dir is true if directories are allowed.
+	allow_dir bool
+
+	// allow_file is true if files are allowed.
+	allow_file bool
+
+	// allowed_exts is the list of allowed extensions.
+	allowed_exts []string
+
+	// fold is true if extensions are matched regardless of case.
+	fold bool
+}
+
+// new_file_settings creates the file settings from the given options.
+//
+// Parameters:
+//   - opts: The file settings options.
+//
+// Returns:
+//   - FileSettings: The file settings. By default, directories and files with
+//     any extension are allowed.
+func new_file_settings(opts []FileSettingsOption) FileSettings {
+	settings := FileSettings{
+		allow_dir:  true,
+		allow_file: true,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	// Extensions are only normalized once all options are applied, as it
+	// depends on whether matching is case-insensitive.
+	settings.allowed_exts = fix_exts(settings.allowed_exts, settings.fold)
+
+	return settings
+}
+
+// match_ext checks whether a file name has one of the allowed extensions.
+//
+// Parameters:
+//   - name: The name of the file.
+//
+// Returns:
+//   - bool: True if all extensions are allowed or the name has one of them,
+//     false otherwise.
+func (fs FileSettings) match_ext(name string) bool {
+	if len(fs.allowed_exts) == 0 {
+		return true
+	}
+
+	name = path.Base(name)
+
+	if fs.fold {
+		name = strings.ToLower(name)
+	}
+
+	for _, ext := range fs.allowed_exts {
+		// A name that is only the extension, such as ".go", is a hidden file
+		// without one.
+		if len(name) > len(ext) && strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fix_exts normalizes an extension list: spaces are trimmed, a leading dot is
+// added if missing, extensions are lowercased if matching is case-insensitive,
+// and empty, invalid and duplicate extensions are removed.
+//
+// Parameters:
+//   - exts: The extension list.
+//   - fold: Whether extensions are matched regardless of case.
+//
+// Returns:
+//   - []string: The sorted extension list. Nil if no extension is valid.
+func fix_exts(exts []string, fold bool) []string {
+	if len(exts) == 0 {
+		return nil
+	}
+
+	new_exts := make([]string, 0, len(exts))
+
+	for _, ext := range exts {
+		ext = strings.TrimSpace(ext)
+		ext = strings.TrimPrefix(ext, ".")
+
+		if ext == "" || strings.ContainsAny(ext, `/\`) || strings.HasSuffix(ext, ".") {
+			continue
+		}
+
+		ext = "." + ext
+
+		if fold {
+			ext = strings.ToLower(ext)
+		}
+
+		pos, ok := slices.BinarySearch(new_exts, ext)
+		if !ok {
+			new_exts = slices.Insert(new_exts, pos, ext)
+		}
+	}
+
+	if len(new_exts) == 0 {
+		return nil
+	}
+
+	return new_exts[:len(new_exts):len(new_exts)]
+}
@@ -0,0 +1,256 @@
+package file_manager
+
+import (
+	"path"
+	"strings"
+)
+
+// glob is a compiled glob pattern. Patterns are matched segment by segment,
+// with the syntax of path.Match, and a "**" segment matches any number of
+// segments, including none, except at the end of the pattern where it matches
+// at least one.
+type glob struct {
+	// segs are the segments of the pattern.
+	segs []string
+
+	// anchored is true if the pattern matches the whole relative path, false
+	// if it only matches the base name, at any depth.
+	anchored bool
+}
+
+// compile_glob compiles a glob pattern. A pattern that contains a slash
+// matches the whole relative path; one that does not matches the base name at
+// any depth, as in .gitignore files.
+//
+// Parameters:
+//   - pattern: The pattern. A leading slash is ignored.
+//
+// Returns:
+//   - glob: The compiled pattern.
+//   - error: An error if the pattern is malformed.
+//
+// Errors:
+//   - path.ErrBadPattern: If a segment is malformed.
+func compile_glob(pattern string) (glob, error) {
+	anchored := strings.Contains(pattern, "/")
+
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	segs := strings.Split(pattern, "/")
+
+	for _, seg := range segs {
+		_, err := path.Match(seg, "")
+		if err != nil {
+			return glob{}, err
+		}
+	}
+
+	return glob{
+		segs:     segs,
+		anchored: anchored,
+	}, nil
+}
+
+// match checks whether a relative path matches the pattern.
+//
+// Parameters:
+//   - rel: The slash-separated path, relative to the base of the pattern.
+//
+// Returns:
+//   - bool: True if the path matches, false otherwise.
+func (g glob) match(rel string) bool {
+	if !g.anchored {
+		ok, _ := path.Match(g.segs[0], path.Base(rel))
+		return ok
+	}
+
+	return match_segments(g.segs, strings.Split(rel, "/"))
+}
+
+// match_segments matches path segments against pattern segments.
+//
+// Parameters:
+//   - segs: The pattern segments.
+//   - parts: The path segments.
+//
+// Returns:
+//   - bool: True if the segments match, false otherwise.
+func match_segments(segs, parts []string) bool {
+	for len(segs) > 0 {
+		if segs[0] == "**" {
+			// Consecutive "**" are the same as one.
+			for len(segs) > 0 && segs[0] == "**" {
+				segs = segs[1:]
+			}
+
+			// A trailing "**" matches everything inside, but not the
+			// directory itself.
+			if len(segs) == 0 {
+				return len(parts) > 0
+			}
+
+			for i := range parts {
+				if match_segments(segs, parts[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+
+		ok, _ := path.Match(segs[0], parts[0])
+		if !ok {
+			return false
+		}
+
+		segs = segs[1:]
+		parts = parts[1:]
+	}
+
+	return len(parts) == 0
+}
+
+// match_any checks whether a relative path matches any of the patterns.
+//
+// Parameters:
+//   - globs: The patterns.
+//   - rel: The relative path.
+//
+// Returns:
+//   - bool: True if the path matches one of the patterns, false otherwise.
+func match_any(globs []glob, rel string) bool {
+	for _, g := range globs {
+		if g.match(rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ignore_rule is a pattern of an ignore file, such as .gitignore.
+type ignore_rule struct {
+	// glob is the pattern.
+	glob glob
+
+	// base is the directory of the ignore file, relative to the root of the
+	// walk. Empty for the root itself.
+	base string
+
+	// negate is true if the pattern re-includes what previous patterns
+	// excluded.
+	negate bool
+
+	// dir_only is true if the pattern only matches directories.
+	dir_only bool
+}
+
+// parse_ignore parses the content of an ignore file with the syntax of
+// .gitignore. Malformed patterns are skipped, as git does.
+//
+// Parameters:
+//   - data: The content of the file.
+//   - base: The directory of the file, relative to the root of the walk.
+//
+// Returns:
+//   - []ignore_rule: The rules, in the order of the file.
+func parse_ignore(data string, base string) []ignore_rule {
+	var rules []ignore_rule
+
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		// Trailing spaces are ignored unless escaped.
+		trimmed := strings.TrimRight(line, " ")
+		if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+			trimmed += " "
+		}
+
+		line = trimmed
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignore_rule{
+			base: base,
+		}
+
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dir_only = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		g, err := compile_glob(line)
+		if err != nil {
+			continue
+		}
+
+		rule.glob = g
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// match checks whether the rule matches a path.
+//
+// Parameters:
+//   - rel: The path, relative to the root of the walk.
+//   - is_dir: Whether the path is a directory.
+//
+// Returns:
+//   - bool: True if the rule matches, false otherwise.
+func (r ignore_rule) match(rel string, is_dir bool) bool {
+	if r.dir_only && !is_dir {
+		return false
+	}
+
+	if r.base != "" {
+		var ok bool
+
+		rel, ok = strings.CutPrefix(rel, r.base+"/")
+		if !ok {
+			return false
+		}
+	}
+
+	return r.glob.match(rel)
+}
+
+// is_ignored checks whether a path is ignored by the rules. The last rule
+// that matches decides, so deeper ignore files and later lines override
+// earlier ones.
+//
+// Parameters:
+//   - rules: The rules, from the root to the deepest directory.
+//   - rel: The path, relative to the root of the walk.
+//   - is_dir: Whether the path is a directory.
+//
+// Returns:
+//   - bool: True if the path is ignored, false otherwise.
+func is_ignored(rules []ignore_rule, rel string, is_dir bool) bool {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match(rel, is_dir) {
+			return !rules[i].negate
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,460 @@
+package file_manager
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	gcos "github.com/PlayerR9/go-commons/os"
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// NamingStyle is how a unique path is derived from a path that is taken.
+type NamingStyle int
+
+const (
+	// StyleParens numbers the copies as "name (1).ext", "name (2).ext", and so
+	// on.
+	StyleParens NamingStyle = iota
+
+	// StyleDash numbers the copies as "name-2.ext", "name-3.ext", and so on.
+	StyleDash
+
+	// StyleTimestamp always adds the current time, as in
+	// "name-20060102-150405.ext", and numbers the copies made within the same
+	// second as "name-20060102-150405-2.ext".
+	StyleTimestamp
+
+	// StyleRotate keeps the path and rotates the previous versions away, as in
+	// log rotation: "name.ext" becomes "name.ext.1", "name.ext.1" becomes
+	// "name.ext.2", and so on up to the maximum number of versions, past which
+	// the oldest is removed.
+	//
+	// Rotations are serialized through the lock file "name.ext.lock", which is
+	// left in place. A file may still be rotated away while its writer holds
+	// it open, in which case the writes land in the rotated version.
+	StyleRotate
+)
+
+// UniqueOption is a type that defines an option of CreateUnique.
+//
+// Parameters:
+//   - s: The settings to modify.
+type UniqueOption func(s *unique_settings)
+
+// unique_settings is the settings of CreateUnique.
+type unique_settings struct {
+	// style is the naming style.
+	style NamingStyle
+
+	// perm is the permissions of the created file.
+	perm fs.FileMode
+
+	// max_attempts is the number of paths tried.
+	max_attempts int
+
+	// max_versions is the number of previous versions kept by StyleRotate.
+	max_versions int
+
+	// time_format is the layout of the timestamps.
+	time_format string
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// WithStyle sets the naming style. By default, StyleParens is used.
+//
+// Parameters:
+//   - style: The naming style.
+//
+// Returns:
+//   - UniqueOption: The option.
+func WithStyle(style NamingStyle) UniqueOption {
+	return func(s *unique_settings) {
+		s.style = style
+	}
+}
+
+// WithCreatePerm sets the permissions of the created file, before the umask.
+// By default, files are created with 0644.
+//
+// Parameters:
+//   - perm: The permissions.
+//
+// Returns:
+//   - UniqueOption: The option.
+func WithCreatePerm(perm fs.FileMode) UniqueOption {
+	return func(s *unique_settings) {
+		s.perm = perm.Perm()
+	}
+}
+
+// WithMaxAttempts sets how many paths are tried before giving up. By default,
+// 1000 paths are tried. StyleRotate only tries the path once.
+//
+// Parameters:
+//   - n: The number of paths.
+//
+// Returns:
+//   - UniqueOption: The option.
+func WithMaxAttempts(n int) UniqueOption {
+	return func(s *unique_settings) {
+		s.max_attempts = max(n, 1)
+	}
+}
+
+// WithMaxVersions sets how many previous versions StyleRotate keeps. By
+// default, 5 versions are kept.
+//
+// Parameters:
+//   - n: The number of versions.
+//
+// Returns:
+//   - UniqueOption: The option.
+func WithMaxVersions(n int) UniqueOption {
+	return func(s *unique_settings) {
+		s.max_versions = max(n, 1)
+	}
+}
+
+// WithTimeFormat sets the layout of the timestamps of StyleTimestamp. By
+// default, "20060102-150405" is used.
+//
+// Parameters:
+//   - layout: The layout, as in time.Format. It should not contain path
+//     separators.
+//
+// Returns:
+//   - UniqueOption: The option.
+func WithTimeFormat(layout string) UniqueOption {
+	return func(s *unique_settings) {
+		if layout != "" {
+			s.time_format = layout
+		}
+	}
+}
+
+// WithClock sets the function that returns the current time for
+// StyleTimestamp. By default, time.Now is used.
+//
+// Parameters:
+//   - now: The function.
+//
+// Returns:
+//   - UniqueOption: The option.
+func WithClock(now func() time.Time) UniqueOption {
+	return func(s *unique_settings) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// ErrNoUniquePath is an error that is returned when no free path was found
+// within the maximum number of attempts.
+type ErrNoUniquePath struct {
+	// Path is the requested path.
+	Path string
+
+	// Attempts is the number of paths tried.
+	Attempts int
+}
+
+// Error implements the error interface.
+//
+// Message: "no free path for <path> after <attempts> attempts"
+func (e ErrNoUniquePath) Error() string {
+	return "no free path for " + strconv.Quote(e.Path) + " after " + strconv.Itoa(e.Attempts) + " attempts"
+}
+
+// NewErrNoUniquePath creates a new ErrNoUniquePath error.
+//
+// Parameters:
+//   - path: The requested path.
+//   - attempts: The number of paths tried.
+//
+// Returns:
+//   - *ErrNoUniquePath: The new error. Never returns nil.
+func NewErrNoUniquePath(path string, attempts int) *ErrNoUniquePath {
+	return &ErrNoUniquePath{
+		Path:     path,
+		Attempts: attempts,
+	}
+}
+
+// new_unique_settings creates the settings of CreateUnique.
+//
+// Parameters:
+//   - opts: The options.
+//
+// Returns:
+//   - unique_settings: The settings.
+func new_unique_settings(opts []UniqueOption) unique_settings {
+	settings := unique_settings{
+		perm:         0644,
+		max_attempts: 1000,
+		max_versions: 5,
+		time_format:  "20060102-150405",
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return settings
+}
+
+// versioned_name returns the n-th candidate name of a path.
+//
+// Parameters:
+//   - path: The path.
+//   - style: The naming style.
+//   - n: The number of the candidate.
+//   - stamp: The formatted time of StyleTimestamp.
+//
+// Returns:
+//   - string: The candidate.
+func versioned_name(path string, style NamingStyle, n int, stamp string) string {
+	var suffix string
+
+	switch style {
+	case StyleParens:
+		if n > 0 {
+			suffix = " (" + strconv.Itoa(n) + ")"
+		}
+	case StyleDash:
+		if n > 0 {
+			suffix = "-" + strconv.Itoa(n+1)
+		}
+	case StyleTimestamp:
+		suffix = "-" + stamp
+
+		if n > 0 {
+			suffix += "-" + strconv.Itoa(n+1)
+		}
+	case StyleRotate:
+		if n > 0 {
+			return path + "." + strconv.Itoa(n)
+		}
+
+		return path
+	}
+
+	dir, base := filepath.Split(path)
+
+	// A hidden file such as ".env" has no extension.
+	ext := filepath.Ext(base)
+	if ext == "" || ext == base {
+		return dir + base + suffix
+	}
+
+	return dir + AddSuffixToFileName(base, suffix, ext)
+}
+
+// CreateUnique creates a file at a path that is not taken yet, deriving a new
+// path in the chosen naming style when it is. Each path is claimed with
+// O_EXCL, so that concurrent writers, even in other processes, never get the
+// same one.
+//
+// Parameters:
+//   - path: The requested path. Its directory must exist.
+//   - opts: The options.
+//
+// Returns:
+//   - *os.File: The created file, opened for writing. Its Name is the path
+//     that was claimed. Nil if an error occurred.
+//   - error: An error if no file could be created.
+//
+// Errors:
+//   - *errors.Err: If the path is empty or the style is not valid.
+//   - *ErrNoUniquePath: If every path tried is taken, or if the path was
+//     taken again right after StyleRotate freed it.
+//   - any other error returned by the file system.
+func CreateUnique(path string, opts ...UniqueOption) (*os.File, error) {
+	if path == "" {
+		return nil, gers.NewErrInvalidParameter("path must not be empty")
+	}
+
+	settings := new_unique_settings(opts)
+
+	if settings.style < StyleParens || settings.style > StyleRotate {
+		return nil, gers.NewErrInvalidParameter("style is not valid")
+	}
+
+	if settings.style == StyleRotate {
+		return create_rotated(path, settings)
+	}
+
+	stamp := settings.now().Format(settings.time_format)
+
+	for n := range settings.max_attempts {
+		name := versioned_name(path, settings.style, n, stamp)
+
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, settings.perm)
+		if err == nil {
+			return f, nil
+		} else if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+	}
+
+	return nil, NewErrNoUniquePath(path, settings.max_attempts)
+}
+
+// create_rotated rotates the previous versions of a path away and creates it.
+// The versions are rotated only once, so that a path that cannot be freed
+// does not rotate the whole history away.
+//
+// Parameters:
+//   - path: The path.
+//   - settings: The settings of CreateUnique.
+//
+// Returns:
+//   - *os.File: The created file. Nil if an error occurred.
+//   - error: An error if the file could not be created.
+//
+// Errors:
+//   - *ErrNoUniquePath: If the path was taken again after the rotation, such
+//     as by a writer that does not lock the rotation.
+//   - any other error returned by the file system.
+func create_rotated(path string, settings unique_settings) (*os.File, error) {
+	unlock, err := lock_rotation(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	err = rotate(path, settings.max_versions)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, settings.perm)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, NewErrNoUniquePath(path, 1)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// lock_rotation locks the rotation of a path against the other writers, even
+// in other processes.
+//
+// Parameters:
+//   - path: The path.
+//
+// Returns:
+//   - func(): The function that unlocks the rotation. Never returns nil.
+//   - error: An error if the rotation could not be locked.
+//
+// Where file locks are not supported, rotations are not serialized.
+func lock_rotation(path string) (func(), error) {
+	lock, err := gcos.Lock(path+".lock", gcos.LockExclusive)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return func() {}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = lock.Unlock()
+	}, nil
+}
+
+// rotate moves the previous versions of a path one step back, removing the
+// oldest, so that the path is free.
+//
+// Parameters:
+//   - path: The path.
+//   - max_versions: The number of previous versions to keep.
+//
+// Returns:
+//   - error: An error if a version could not be moved.
+func rotate(path string, max_versions int) error {
+	for i := max_versions; i > 0; i-- {
+		src := versioned_name(path, StyleRotate, i-1, "")
+		dst := versioned_name(path, StyleRotate, i, "")
+
+		// Renaming over the last version removes the oldest one.
+		err := os.Rename(src, dst)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteUnique writes data to a new file at a path that is not taken yet, as
+// CreateUnique does.
+//
+// Parameters:
+//   - path: The requested path. Its directory must exist.
+//   - data: The data to write.
+//   - opts: The options.
+//
+// Returns:
+//   - string: The path that was claimed.
+//   - error: An error if the file could not be created or written. The file
+//     is removed if it could not be written, unless it was rotated away
+//     meanwhile.
+//
+// Errors:
+//   - any error returned by CreateUnique or the file system.
+func WriteUnique(path string, data []byte, opts ...UniqueOption) (string, error) {
+	f, err := CreateUnique(path, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(data)
+
+	info, serr := f.Stat()
+
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		return f.Name(), nil
+	}
+
+	if serr == nil {
+		remove_own(f.Name(), info, new_unique_settings(opts).style)
+	}
+
+	return "", err
+}
+
+// remove_own removes a file that was created, unless its path now belongs to
+// another file.
+//
+// Parameters:
+//   - name: The path of the file.
+//   - info: The information of the file that was created.
+//   - style: The naming style it was created with.
+func remove_own(name string, info fs.FileInfo, style NamingStyle) {
+	if style == StyleRotate {
+		// The path cannot be rotated between the check and the removal.
+		unlock, err := lock_rotation(name)
+		if err != nil {
+			return
+		}
+
+		defer unlock()
+	}
+
+	curr, err := os.Lstat(name)
+	if err == nil && os.SameFile(info, curr) {
+		_ = os.Remove(name)
+	}
+}
@@ -0,0 +1,206 @@
+package file_manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestVersionedName tests the naming styles.
+func TestVersionedName(t *testing.T) {
+	tests := []struct {
+		style    NamingStyle
+		n        int
+		expected string
+	}{
+		{StyleParens, 0, "out/report.csv"},
+		{StyleParens, 2, "out/report (2).csv"},
+		{StyleDash, 1, "out/report-2.csv"},
+		{StyleTimestamp, 0, "out/report-20261018-120000.csv"},
+		{StyleTimestamp, 1, "out/report-20261018-120000-2.csv"},
+		{StyleRotate, 3, "out/report.csv.3"},
+	}
+
+	for _, test := range tests {
+		got := versioned_name("out/report.csv", test.style, test.n, "20261018-120000")
+		if got != test.expected {
+			t.Errorf("expected %q, got %q instead", test.expected, got)
+		}
+	}
+
+	got := versioned_name(".env", StyleDash, 1, "")
+	if got != ".env-2" {
+		t.Errorf("expected %q, got %q instead", ".env-2", got)
+	}
+}
+
+// TestCreateUniqueConcurrent tests that concurrent writers never claim the
+// same path.
+func TestCreateUniqueConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+
+	const writers = 20
+
+	var wg sync.WaitGroup
+
+	names := make([]string, writers)
+
+	for i := range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			name, err := WriteUnique(path, []byte("data"), WithStyle(StyleDash))
+			if err != nil {
+				t.Errorf("expected no error, got %v instead", err)
+				return
+			}
+
+			names[i] = name
+		}()
+	}
+
+	wg.Wait()
+
+	slices.Sort(names)
+
+	if len(slices.Compact(names)) != writers {
+		t.Errorf("expected %d distinct paths, got %v instead", writers, names)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if len(entries) != writers {
+		t.Errorf("expected %d, got %d instead", writers, len(entries))
+	}
+}
+
+// TestCreateUniqueTimestamp tests StyleTimestamp and the maximum number of
+// attempts.
+func TestCreateUniqueTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.sql")
+
+	clock := WithClock(func() time.Time {
+		return time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	})
+
+	for _, expected := range []string{"dump-20261018-093000.sql", "dump-20261018-093000-2.sql"} {
+		name, err := WriteUnique(path, nil, WithStyle(StyleTimestamp), clock, WithMaxAttempts(2))
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if name != filepath.Join(dir, expected) {
+			t.Errorf("expected %q, got %q instead", filepath.Join(dir, expected), name)
+		}
+	}
+
+	_, err := WriteUnique(path, nil, WithStyle(StyleTimestamp), clock, WithMaxAttempts(2))
+
+	var no_path *ErrNoUniquePath
+
+	if !errors.As(err, &no_path) {
+		t.Errorf("expected an ErrNoUniquePath, got %v instead", err)
+	}
+}
+
+// TestCreateUniqueRotate tests StyleRotate.
+func TestCreateUniqueRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	for _, data := range []string{"1", "2", "3", "4"} {
+		name, err := WriteUnique(path, []byte(data), WithStyle(StyleRotate), WithMaxVersions(2))
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if name != path {
+			t.Errorf("expected %q, got %q instead", path, name)
+		}
+	}
+
+	expected := map[string]string{
+		"app.log":   "4",
+		"app.log.1": "3",
+		"app.log.2": "2",
+
+		// The lock file is left in place.
+		"app.log.lock": "",
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if len(entries) != len(expected) {
+		t.Errorf("expected %d, got %d instead", len(expected), len(entries))
+	}
+
+	for name, data := range expected {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if string(got) != data {
+			t.Errorf("%s: expected %q, got %q instead", name, data, got)
+		}
+	}
+}
+
+// TestCreateUniqueRotateConcurrent tests that concurrent rotations lose no
+// version.
+func TestCreateUniqueRotateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	const writers = 10
+
+	var wg sync.WaitGroup
+
+	for i := range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := WriteUnique(path, []byte{byte('0' + i)}, WithStyle(StyleRotate), WithMaxVersions(writers))
+			if err != nil {
+				t.Errorf("expected no error, got %v instead", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var data []string
+
+	for n := range writers {
+		got, err := os.ReadFile(versioned_name(path, StyleRotate, n, ""))
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		data = append(data, string(got))
+	}
+
+	slices.Sort(data)
+
+	expected := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+
+	if !slices.Equal(data, expected) {
+		t.Errorf("expected %v, got %v instead", expected, data)
+	}
+}
@@ -0,0 +1,625 @@
+package file_manager
+
+import (
+	"errors"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// SymlinkPolicy is how a walk treats symbolic links.
+type SymlinkPolicy int
+
+const (
+	// SymlinkList reports symbolic links as entries without following them.
+	SymlinkList SymlinkPolicy = iota
+
+	// SymlinkSkip ignores symbolic links.
+	SymlinkSkip
+
+	// SymlinkFollow follows symbolic links, reporting what they point to and
+	// walking the directories they point to. A link to one of its own
+	// ancestors is not walked again.
+	SymlinkFollow
+)
+
+// WalkOption is a type that defines an option of a walk.
+//
+// Parameters:
+//   - s: The settings to modify.
+type WalkOption func(s *walk_settings)
+
+// walk_settings is the settings of a walk.
+type walk_settings struct {
+	// include are the patterns an entry must match to be reported.
+	include []string
+
+	// exclude are the patterns of the entries to skip.
+	exclude []string
+
+	// file_opts are the options that filter files by extension.
+	file_opts []FileSettingsOption
+
+	// ignore_files are the names of the ignore files.
+	ignore_files []string
+
+	// symlinks is the symbolic link policy.
+	symlinks SymlinkPolicy
+
+	// max_depth is the maximum depth of the entries. 0 for no limit.
+	max_depth int
+
+	// sorted is true if the entries are reported in lexical order.
+	sorted bool
+
+	// dirs is true if directories are reported too.
+	dirs bool
+
+	// workers is the number of directories read at once.
+	workers int
+}
+
+// WithInclude only reports the entries that match one of the given patterns.
+// A pattern that contains a slash matches the path relative to the root of the
+// walk, where "**" matches any number of directories; one that does not
+// matches the base name at any depth. Directories are walked even if they do
+// not match.
+//
+// Parameters:
+//   - patterns: The patterns.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithInclude(patterns ...string) WalkOption {
+	patterns = slices.Clone(patterns)
+
+	return func(s *walk_settings) {
+		s.include = append(s.include, patterns...)
+	}
+}
+
+// WithExclude skips the entries that match one of the given patterns, with
+// the same syntax as WithInclude. Excluded directories are not walked.
+//
+// Parameters:
+//   - patterns: The patterns.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithExclude(patterns ...string) WalkOption {
+	patterns = slices.Clone(patterns)
+
+	return func(s *walk_settings) {
+		s.exclude = append(s.exclude, patterns...)
+	}
+}
+
+// WithFileSettings filters the reported files with file settings options,
+// such as WithFileExts and WithCaseInsensitive.
+//
+// Parameters:
+//   - opts: The file settings options.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithFileSettings(opts ...FileSettingsOption) WalkOption {
+	opts = slices.Clone(opts)
+
+	return func(s *walk_settings) {
+		s.file_opts = append(s.file_opts, opts...)
+	}
+}
+
+// WithIgnoreFiles sets the names of the ignore files that are honoured in
+// every directory, with the syntax of .gitignore. When a directory has
+// several, the later ones take precedence. By default, ".gitignore" and
+// ".ignore" are honoured.
+//
+// Parameters:
+//   - names: The names. None to honour no ignore file.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithIgnoreFiles(names ...string) WalkOption {
+	names = slices.Clone(names)
+
+	return func(s *walk_settings) {
+		s.ignore_files = names
+	}
+}
+
+// WithSymlinks sets the symbolic link policy. By default, SymlinkList is used.
+//
+// Parameters:
+//   - policy: The policy.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithSymlinks(policy SymlinkPolicy) WalkOption {
+	return func(s *walk_settings) {
+		s.symlinks = policy
+	}
+}
+
+// WithMaxDepth limits the depth of the walk, where the entries of the root
+// have a depth of 1. By default, the depth is not limited.
+//
+// Parameters:
+//   - depth: The maximum depth. Non-positive values mean no limit.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithMaxDepth(depth int) WalkOption {
+	return func(s *walk_settings) {
+		s.max_depth = max(depth, 0)
+	}
+}
+
+// WithSorted reports the entries in lexical order of their path, so that the
+// output is deterministic. Entries are then only reported once the whole tree
+// is walked. By default, entries are reported as soon as they are found, in
+// no particular order.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithSorted() WalkOption {
+	return func(s *walk_settings) {
+		s.sorted = true
+	}
+}
+
+// WithDirs reports directories too. By default, only files and, depending on
+// the symbolic link policy, links are reported.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithDirs() WalkOption {
+	return func(s *walk_settings) {
+		s.dirs = true
+	}
+}
+
+// WithWorkers sets how many directories are read at once. By default,
+// runtime.GOMAXPROCS(0) directories are.
+//
+// Parameters:
+//   - n: The number of directories.
+//
+// Returns:
+//   - WalkOption: The option.
+func WithWorkers(n int) WalkOption {
+	return func(s *walk_settings) {
+		s.workers = max(n, 1)
+	}
+}
+
+// WalkEntry is an entry found by a walk.
+type WalkEntry struct {
+	// Path is the path of the entry in the file system.
+	Path string
+
+	// Depth is the depth of the entry, where the entries of the root have a
+	// depth of 1.
+	Depth int
+
+	// IsDir is true if the entry is a directory, or a link to one that was
+	// followed.
+	IsDir bool
+
+	// IsSymlink is true if the entry is a symbolic link.
+	IsSymlink bool
+}
+
+// Walker walks a tree of an io/fs.FS concurrently.
+type Walker struct {
+	// fsys is the file system.
+	fsys fs.FS
+
+	// root is the directory the walk starts from.
+	root string
+
+	// settings are the settings of the walk.
+	settings walk_settings
+
+	// file_settings filter the reported files.
+	file_settings FileSettings
+
+	// include are the compiled include patterns.
+	include []glob
+
+	// exclude are the compiled exclude patterns.
+	exclude []glob
+
+	// mu protects errs.
+	mu sync.Mutex
+
+	// errs are the errors of the last walk.
+	errs []error
+}
+
+// NewWalker creates a walker.
+//
+// Parameters:
+//   - fsys: The file system.
+//   - root: The directory to walk. Use "." for the root of the file system.
+//   - opts: The options of the walk.
+//
+// Returns:
+//   - *Walker: The walker. Nil if an error occurred.
+//   - error: An error if a parameter is not valid.
+//
+// Errors:
+//   - *errors.Err: If the file system is nil or root is empty.
+//   - path.ErrBadPattern: If an include or exclude pattern is malformed.
+func NewWalker(fsys fs.FS, root string, opts ...WalkOption) (*Walker, error) {
+	if fsys == nil {
+		return nil, gers.NewErrNilParameter("fsys")
+	} else if root == "" {
+		return nil, gers.NewErrInvalidParameter("root must not be empty")
+	}
+
+	settings := walk_settings{
+		ignore_files: []string{".gitignore", ".ignore"},
+		workers:      runtime.GOMAXPROCS(0),
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	w := &Walker{
+		fsys:          fsys,
+		root:          path.Clean(root),
+		settings:      settings,
+		file_settings: new_file_settings(settings.file_opts),
+	}
+
+	for _, pattern := range settings.include {
+		g, err := compile_glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		w.include = append(w.include, g)
+	}
+
+	for _, pattern := range settings.exclude {
+		g, err := compile_glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		w.exclude = append(w.exclude, g)
+	}
+
+	return w, nil
+}
+
+// Err returns the errors of the last walk, such as directories that could not
+// be read. Those do not stop the walk.
+//
+// Returns:
+//   - error: The errors, joined with errors.Join. Nil if there were none.
+func (w *Walker) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return errors.Join(w.errs...)
+}
+
+// report records an error of the walk.
+//
+// Parameters:
+//   - err: The error.
+func (w *Walker) report(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.errs = append(w.errs, err)
+}
+
+// Entries walks the tree. Each iteration walks it again.
+//
+// Returns:
+//   - iter.Seq[WalkEntry]: The entries. Never returns nil.
+func (w *Walker) Entries() iter.Seq[WalkEntry] {
+	return func(yield func(WalkEntry) bool) {
+		w.mu.Lock()
+		w.errs = nil
+		w.mu.Unlock()
+
+		if !w.settings.sorted {
+			w.walk(yield)
+			return
+		}
+
+		var entries []WalkEntry
+
+		w.walk(func(e WalkEntry) bool {
+			entries = append(entries, e)
+			return true
+		})
+
+		slices.SortFunc(entries, func(a, b WalkEntry) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+
+		for _, e := range entries {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// walk_job is a directory to read.
+type walk_job struct {
+	// dir is the path of the directory in the file system.
+	dir string
+
+	// depth is the depth of the directory.
+	depth int
+
+	// rules are the ignore rules that apply to the directory.
+	rules []ignore_rule
+
+	// ancestors are the directories followed so far, for the SymlinkFollow
+	// policy.
+	ancestors []fs.FileInfo
+}
+
+// walk_state is the state shared by the goroutines of a walk.
+type walk_state struct {
+	// wg waits for the directories being read.
+	wg sync.WaitGroup
+
+	// sem limits how many directories are read at once.
+	sem chan struct{}
+
+	// out receives the entries.
+	out chan WalkEntry
+
+	// done is closed when the consumer stops.
+	done chan struct{}
+}
+
+// walk walks the tree and reports the entries as they are found.
+//
+// Parameters:
+//   - yield: The function the entries are reported to.
+func (w *Walker) walk(yield func(WalkEntry) bool) {
+	st := &walk_state{
+		sem:  make(chan struct{}, w.settings.workers),
+		out:  make(chan WalkEntry, w.settings.workers),
+		done: make(chan struct{}),
+	}
+
+	job := walk_job{
+		dir: w.root,
+	}
+
+	if w.settings.symlinks == SymlinkFollow {
+		info, err := fs.Stat(w.fsys, w.root)
+		if err == nil {
+			job.ancestors = []fs.FileInfo{info}
+		}
+	}
+
+	st.wg.Add(1)
+	go w.read_dir(st, job)
+
+	go func() {
+		st.wg.Wait()
+		close(st.out)
+	}()
+
+	for e := range st.out {
+		if !yield(e) {
+			close(st.done)
+
+			// Drain so that the goroutines blocked on sending can stop.
+			for range st.out {
+			}
+
+			return
+		}
+	}
+}
+
+// read_dir reads a directory, reports its entries and walks its
+// sub-directories.
+//
+// Parameters:
+//   - st: The state of the walk.
+//   - job: The directory.
+func (w *Walker) read_dir(st *walk_state, job walk_job) {
+	defer st.wg.Done()
+
+	select {
+	case st.sem <- struct{}{}:
+	case <-st.done:
+		return
+	}
+
+	defer func() {
+		<-st.sem
+	}()
+
+	entries, err := fs.ReadDir(w.fsys, job.dir)
+	if err != nil {
+		w.report(err)
+
+		if len(entries) == 0 {
+			return
+		}
+	}
+
+	rules := job.rules
+
+	for _, name := range w.settings.ignore_files {
+		data, err := fs.ReadFile(w.fsys, path.Join(job.dir, name))
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				w.report(err)
+			}
+
+			continue
+		}
+
+		// The rules of the parent are shared with the other sub-directories,
+		// so they must not be appended to in place.
+		rules = append(slices.Clip(rules), parse_ignore(string(data), w.rel(job.dir))...)
+	}
+
+	depth := job.depth + 1
+
+	for _, d := range entries {
+		name := path.Join(job.dir, d.Name())
+		rel := w.rel(name)
+
+		e := WalkEntry{
+			Path:      name,
+			Depth:     depth,
+			IsDir:     d.IsDir(),
+			IsSymlink: d.Type()&fs.ModeSymlink != 0,
+		}
+
+		var target fs.FileInfo
+
+		if e.IsSymlink {
+			if w.settings.symlinks == SymlinkSkip {
+				continue
+			} else if w.settings.symlinks == SymlinkFollow {
+				// A broken link is reported as is.
+				info, err := fs.Stat(w.fsys, name)
+				if err == nil {
+					target = info
+					e.IsDir = info.IsDir()
+				}
+			}
+		}
+
+		if is_ignored(rules, rel, e.IsDir) || match_any(w.exclude, rel) {
+			continue
+		}
+
+		if e.IsDir {
+			if w.settings.dirs && w.is_included(rel) && !w.send(st, e) {
+				return
+			}
+
+			if w.settings.max_depth > 0 && depth >= w.settings.max_depth {
+				continue
+			}
+
+			sub := walk_job{
+				dir:       name,
+				depth:     depth,
+				rules:     rules,
+				ancestors: job.ancestors,
+			}
+
+			if w.settings.symlinks == SymlinkFollow {
+				if target == nil {
+					info, err := d.Info()
+					if err == nil {
+						target = info
+					}
+				}
+
+				if target != nil {
+					if is_ancestor(job.ancestors, target) {
+						continue
+					}
+
+					sub.ancestors = append(slices.Clip(job.ancestors), target)
+				}
+			}
+
+			st.wg.Add(1)
+			go w.read_dir(st, sub)
+
+			continue
+		}
+
+		if !w.file_settings.allow_file || !w.file_settings.match_ext(name) || !w.is_included(rel) {
+			continue
+		}
+
+		if !w.send(st, e) {
+			return
+		}
+	}
+}
+
+// send reports an entry.
+//
+// Parameters:
+//   - st: The state of the walk.
+//   - e: The entry.
+//
+// Returns:
+//   - bool: True if the walk goes on, false if the consumer stopped.
+func (w *Walker) send(st *walk_state, e WalkEntry) bool {
+	select {
+	case st.out <- e:
+		return true
+	case <-st.done:
+		return false
+	}
+}
+
+// rel returns the path of an entry relative to the root of the walk.
+//
+// Parameters:
+//   - name: The path of the entry in the file system.
+//
+// Returns:
+//   - string: The relative path. Empty for the root itself.
+func (w *Walker) rel(name string) string {
+	if name == w.root {
+		return ""
+	} else if w.root == "." {
+		return name
+	}
+
+	return strings.TrimPrefix(name, w.root+"/")
+}
+
+// is_included checks whether a path matches the include patterns.
+//
+// Parameters:
+//   - rel: The path, relative to the root of the walk.
+//
+// Returns:
+//   - bool: True if there are no include patterns or the path matches one of
+//     them, false otherwise.
+func (w *Walker) is_included(rel string) bool {
+	return len(w.include) == 0 || match_any(w.include, rel)
+}
+
+// is_ancestor checks whether a directory is one of the directories followed
+// so far.
+//
+// Parameters:
+//   - ancestors: The directories followed so far.
+//   - info: The directory.
+//
+// Returns:
+//   - bool: True if the directory is one of them, false otherwise.
+func is_ancestor(ancestors []fs.FileInfo, info fs.FileInfo) bool {
+	for _, a := range ancestors {
+		if os.SameFile(a, info) {
+			return true
+		}
+	}
+
+	return false
+}
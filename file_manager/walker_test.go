@@ -0,0 +1,265 @@
+package file_manager
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// collect walks a tree in sorted mode and returns the paths.
+func collect(t *testing.T, w *Walker) []string {
+	t.Helper()
+
+	var paths []string
+
+	for e := range w.Entries() {
+		paths = append(paths, e.Path)
+	}
+
+	err := w.Err()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	return paths
+}
+
+// TestMatchGlob tests the glob patterns.
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		rel      string
+		expected bool
+	}{
+		{"*.go", "a/b/c.go", true},
+		{"/*.go", "a/c.go", false},
+		{"/*.go", "c.go", true},
+		{"**/testdata/*.txt", "testdata/x.txt", true},
+		{"**/testdata/*.txt", "a/b/testdata/x.txt", true},
+		{"src/**", "src/a/b", true},
+		{"src/**", "lib/a", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+	}
+
+	for _, test := range tests {
+		g, err := compile_glob(test.pattern)
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if g.match(test.rel) != test.expected {
+			t.Errorf("%s on %s: expected %t, got %t instead", test.pattern, test.rel, test.expected, !test.expected)
+		}
+	}
+
+	_, err := compile_glob("a/[b")
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+}
+
+// TestWalkerIgnore tests the .gitignore semantics.
+func TestWalkerIgnore(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":          {Data: []byte("# build output\n*.log\n!keep.log\nbuild/\n/root_only.txt\n")},
+		"main.go":             {},
+		"debug.log":           {},
+		"keep.log":            {},
+		"root_only.txt":       {},
+		"build/out.bin":       {},
+		"pkg/build":           {},
+		"pkg/root_only.txt":   {},
+		"pkg/.ignore":         {Data: []byte("!trace.log\ngen_*.go\n")},
+		"pkg/trace.log":       {},
+		"pkg/gen_types.go":    {},
+		"pkg/types.go":        {},
+		"pkg/sub/deep.log":    {},
+		"pkg/sub/trace.log":   {},
+		"vendor/lib/lib.go":   {},
+		"vendor/lib/lib_test": {},
+	}
+
+	w, err := NewWalker(fsys, ".", WithSorted(), WithExclude("vendor"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected := []string{
+		".gitignore",
+		"keep.log",
+		"main.go",
+		"pkg/.ignore",
+		"pkg/build",
+		"pkg/root_only.txt",
+		"pkg/sub/trace.log",
+		"pkg/trace.log",
+		"pkg/types.go",
+	}
+
+	paths := collect(t, w)
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v instead", expected, paths)
+	}
+
+	// A trailing "**" ignores the content of the directory but not the
+	// directory itself, so that its entries can be re-included.
+	fsys = fstest.MapFS{
+		".gitignore":     {Data: []byte("build/**\n!build/.gitkeep\n")},
+		"build/.gitkeep": {},
+		"build/out.bin":  {},
+		"build/obj/a.o":  {},
+	}
+
+	w, err = NewWalker(fsys, ".", WithSorted())
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected = []string{
+		".gitignore",
+		"build/.gitkeep",
+	}
+
+	paths = collect(t, w)
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v instead", expected, paths)
+	}
+}
+
+// TestWalkerFilters tests the include patterns, the extension filters and the
+// maximum depth.
+func TestWalkerFilters(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.GO":          {},
+		"b.txt":         {},
+		"x/c.go":        {},
+		"x/y/d.go":      {},
+		"x/y/z/e.go":    {},
+		"docs/f.go":     {},
+		"docs/notes.md": {},
+	}
+
+	w, err := NewWalker(fsys, ".", WithSorted(),
+		WithFileSettings(WithFileExts("go"), WithCaseInsensitive()),
+		WithInclude("x/**"),
+		WithMaxDepth(3),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected := []string{"x/c.go", "x/y/d.go"}
+
+	paths := collect(t, w)
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v instead", expected, paths)
+	}
+
+	w, err = NewWalker(fsys, "x", WithSorted(), WithDirs(), WithMaxDepth(2))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected = []string{"x/c.go", "x/y", "x/y/d.go", "x/y/z"}
+
+	paths = collect(t, w)
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v instead", expected, paths)
+	}
+}
+
+// TestWalkerConcurrent tests that an unsorted walk reports every entry once
+// and that it can be stopped early.
+func TestWalkerConcurrent(t *testing.T) {
+	fsys := make(fstest.MapFS)
+
+	var expected []string
+
+	for _, dir := range []string{"a", "b", "c", "d"} {
+		for _, name := range []string{"1.txt", "2.txt", "sub/3.txt"} {
+			p := dir + "/" + name
+
+			fsys[p] = &fstest.MapFile{}
+			expected = append(expected, p)
+		}
+	}
+
+	w, err := NewWalker(fsys, ".", WithWorkers(3))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	paths := collect(t, w)
+	slices.Sort(paths)
+	slices.Sort(expected)
+
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v instead", expected, paths)
+	}
+
+	var count int
+
+	for range w.Entries() {
+		count++
+
+		if count == 2 {
+			break
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("expected %d, got %d instead", 2, count)
+	}
+}
+
+// TestWalkerSymlinks tests the symbolic link policies.
+func TestWalkerSymlinks(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.MkdirAll(filepath.Join(dir, "real", "inner"), 0755)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "real", "inner", "file.txt"), nil, 0644)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	links := map[string]string{
+		filepath.Join(dir, "link"):                "real",
+		filepath.Join(dir, "real", "inner", "up"): "..",
+	}
+
+	for name, target := range links {
+		err := os.Symlink(target, name)
+		if err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		policy   SymlinkPolicy
+		expected []string
+	}{
+		{SymlinkSkip, []string{"real/inner/file.txt"}},
+		{SymlinkList, []string{"link", "real/inner/file.txt", "real/inner/up"}},
+		{SymlinkFollow, []string{"link/inner/file.txt", "real/inner/file.txt"}},
+	}
+
+	for _, test := range tests {
+		w, err := NewWalker(os.DirFS(dir), ".", WithSorted(), WithSymlinks(test.policy))
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		paths := collect(t, w)
+		if !slices.Equal(paths, test.expected) {
+			t.Errorf("policy %d: expected %v, got %v instead", test.policy, test.expected, paths)
+		}
+	}
+}
@@ -0,0 +1,473 @@
+package file_manager
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// Op is a set of changes to a path.
+type Op uint8
+
+const (
+	// OpCreate is the creation of a path, or its move into the watched tree.
+	OpCreate Op = 1 << iota
+
+	// OpWrite is a change of the content of a file.
+	OpWrite
+
+	// OpRemove is the removal of a path.
+	OpRemove
+
+	// OpRename is the move of a path to another name or out of the watched
+	// tree.
+	OpRename
+
+	// OpChmod is a change of the attributes of a path, such as its
+	// permissions.
+	OpChmod
+
+	// OpOverflow means that events were lost, so that the watched tree should
+	// be scanned again. Its path is the root.
+	OpOverflow
+)
+
+// String implements the fmt.Stringer interface.
+//
+// Format: the names of the changes joined with "|", such as "create|write".
+func (op Op) String() string {
+	names := [...]string{"create", "write", "remove", "rename", "chmod", "overflow"}
+
+	var parts []string
+
+	for i, name := range names {
+		if op&(1<<i) != 0 {
+			parts = append(parts, name)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "none"
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// Event is the changes to a path during a debounce window.
+type Event struct {
+	// Path is the path, joined to the root of the watch.
+	Path string
+
+	// Op are all the changes seen during the window. A file created and then
+	// written is reported once, with OpCreate|OpWrite.
+	Op Op
+
+	// IsDir is true if the path is a directory.
+	IsDir bool
+}
+
+// WatchOption is a type that defines an option of a watch.
+//
+// Parameters:
+//   - s: The settings to modify.
+type WatchOption func(s *watch_settings)
+
+// watch_settings is the settings of a watch.
+type watch_settings struct {
+	// debounce is how long the watch waits for more events before reporting.
+	debounce time.Duration
+
+	// max_wait is how long the watch holds an event at most before reporting.
+	// 0 for ten times the debounce duration.
+	max_wait time.Duration
+
+	// file_opts are the options that filter the events.
+	file_opts []FileSettingsOption
+
+	// poll_interval is the time between two scans when polling.
+	poll_interval time.Duration
+
+	// force_polling is true if inotify is not used.
+	force_polling bool
+
+	// on_fallback is called when the watch falls back to polling. Nil if
+	// none.
+	on_fallback func(reason error)
+}
+
+// WithDebounce sets how long a watch waits after an event for more events
+// before reporting them together. By default, it waits 100 milliseconds.
+//
+// Parameters:
+//   - d: The duration. Non-positive values report events at once.
+//
+// Returns:
+//   - WatchOption: The option.
+func WithDebounce(d time.Duration) WatchOption {
+	return func(s *watch_settings) {
+		s.debounce = max(d, 0)
+	}
+}
+
+// WithMaxWait sets how long a watch holds an event at most before reporting
+// it, so that a steady stream of events, such as a file being written for a
+// long time, is still reported. By default, it is ten times the debounce
+// duration.
+//
+// Parameters:
+//   - d: The duration. Non-positive values restore the default.
+//
+// Returns:
+//   - WatchOption: The option.
+func WithMaxWait(d time.Duration) WatchOption {
+	return func(s *watch_settings) {
+		s.max_wait = max(d, 0)
+	}
+}
+
+// WithWatchFileSettings filters the events with file settings options, such
+// as WithFileExts and WithDir. Events for the files of the other extensions,
+// and for directories if they are not allowed, are dropped.
+//
+// Parameters:
+//   - opts: The file settings options.
+//
+// Returns:
+//   - WatchOption: The option.
+func WithWatchFileSettings(opts ...FileSettingsOption) WatchOption {
+	opts = slices.Clone(opts)
+
+	return func(s *watch_settings) {
+		s.file_opts = append(s.file_opts, opts...)
+	}
+}
+
+// WithPollInterval sets the time between two scans of the tree when polling.
+// By default, the tree is scanned every second.
+//
+// Parameters:
+//   - d: The interval.
+//
+// Returns:
+//   - WatchOption: The option.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(s *watch_settings) {
+		if d > 0 {
+			s.poll_interval = d
+		}
+	}
+}
+
+// WithPolling makes the watch poll the tree instead of using inotify, such as
+// for network file systems, where inotify misses remote changes.
+//
+// Returns:
+//   - WatchOption: The option.
+func WithPolling() WatchOption {
+	return func(s *watch_settings) {
+		s.force_polling = true
+	}
+}
+
+// WithFallback sets the function called when the watch falls back to
+// polling, so that the reason can be logged.
+//
+// Parameters:
+//   - fn: The function. It receives the reason inotify could not be used.
+//
+// Returns:
+//   - WatchOption: The option.
+func WithFallback(fn func(reason error)) WatchOption {
+	return func(s *watch_settings) {
+		s.on_fallback = fn
+	}
+}
+
+// watcher is the state of a watch.
+type watcher struct {
+	// root is the watched directory.
+	root string
+
+	// settings are the settings of the watch.
+	settings watch_settings
+
+	// file_settings filter the events.
+	file_settings FileSettings
+}
+
+// Watch watches a directory and its sub-directories, including the ones
+// created during the watch, until the context is done. Events are debounced:
+// they are reported once no new event arrived for the debounce duration, or
+// once the oldest of them waited for the maximum duration, with the events of
+// a same path coalesced into one.
+//
+// On Linux, inotify is used. When it is unavailable or the watch limit of the
+// user (fs.inotify.max_user_watches) is exhausted, the watch falls back to
+// polling; changes made while it switches may be missed. Elsewhere, the tree
+// is always polled.
+//
+// Parameters:
+//   - ctx: The context.
+//   - root: The directory to watch.
+//   - fn: The function the events are reported to, sorted by path. It runs on
+//     the calling goroutine, so events arriving meanwhile are reported in the
+//     next batch.
+//   - opts: The options of the watch.
+//
+// Returns:
+//   - error: An error if the directory could not be watched. Nil once the
+//     context is done.
+//
+// Errors:
+//   - *errors.Err: If the context or the function is nil, or root is empty.
+//   - any error returned by the file system or inotify.
+func Watch(ctx context.Context, root string, fn func(events []Event), opts ...WatchOption) error {
+	if ctx == nil {
+		return gers.NewErrNilParameter("ctx")
+	} else if fn == nil {
+		return gers.NewErrNilParameter("fn")
+	} else if root == "" {
+		return gers.NewErrInvalidParameter("root must not be empty")
+	}
+
+	settings := watch_settings{
+		debounce:      100 * time.Millisecond,
+		poll_interval: time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	if settings.max_wait == 0 {
+		settings.max_wait = 10 * settings.debounce
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	} else if !info.IsDir() {
+		return gers.NewErrInvalidParameter("root must be a directory")
+	}
+
+	w := &watcher{
+		root:          filepath.Clean(root),
+		settings:      settings,
+		file_settings: new_file_settings(settings.file_opts),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	raw := make(chan Event, 256)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- w.run(ctx, raw)
+	}()
+
+	pending := make(map[string]Event)
+
+	// deadline is when the oldest pending event must be reported.
+	var deadline time.Time
+
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errc:
+			return err
+		case e := <-raw:
+			if !w.allowed(e) {
+				continue
+			}
+
+			prev, ok := pending[e.Path]
+			if ok {
+				e.Op |= prev.Op
+				e.IsDir = e.IsDir || prev.IsDir
+			}
+
+			if len(pending) == 0 {
+				deadline = time.Now().Add(settings.max_wait)
+			}
+
+			pending[e.Path] = e
+
+			timer.Reset(min(settings.debounce, time.Until(deadline)))
+		case <-timer.C:
+			events := slices.SortedFunc(maps.Values(pending), func(a, b Event) int {
+				return strings.Compare(a.Path, b.Path)
+			})
+
+			clear(pending)
+
+			fn(events)
+		}
+	}
+}
+
+// allowed checks whether an event passes the file settings.
+//
+// Parameters:
+//   - e: The event.
+//
+// Returns:
+//   - bool: True if the event is reported, false otherwise.
+func (w *watcher) allowed(e Event) bool {
+	if e.Op&OpOverflow != 0 {
+		return true
+	} else if e.IsDir {
+		return w.file_settings.allow_dir
+	}
+
+	return w.file_settings.allow_file && w.file_settings.match_ext(e.Path)
+}
+
+// run sends the raw events of the tree until the context is done.
+//
+// Parameters:
+//   - ctx: The context.
+//   - out: The channel the events are sent to.
+//
+// Returns:
+//   - error: An error if the tree could not be watched.
+func (w *watcher) run(ctx context.Context, out chan<- Event) error {
+	if !w.settings.force_polling {
+		err := w.run_native(ctx, out)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return err
+		}
+
+		if w.settings.on_fallback != nil {
+			w.settings.on_fallback(err)
+		}
+	}
+
+	return w.run_poll(ctx, out)
+}
+
+// poll_entry is the state of a path when it was last scanned.
+type poll_entry struct {
+	// mod_time is the modification time.
+	mod_time time.Time
+
+	// size is the size.
+	size int64
+
+	// mode is the mode.
+	mode fs.FileMode
+}
+
+// scan scans the tree.
+//
+// Returns:
+//   - map[string]poll_entry: The state of every path but the root.
+func (w *watcher) scan() map[string]poll_entry {
+	snapshot := make(map[string]poll_entry)
+
+	_ = filepath.WalkDir(w.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == w.root {
+			// Unreadable directories are skipped; they are scanned again on
+			// the next poll.
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		snapshot[name] = poll_entry{
+			mod_time: info.ModTime(),
+			size:     info.Size(),
+			mode:     info.Mode(),
+		}
+
+		return nil
+	})
+
+	return snapshot
+}
+
+// run_poll sends the raw events of the tree by scanning it at a fixed
+// interval, until the context is done.
+//
+// Parameters:
+//   - ctx: The context.
+//   - out: The channel the events are sent to.
+//
+// Returns:
+//   - error: Always nil.
+func (w *watcher) run_poll(ctx context.Context, out chan<- Event) error {
+	prev := w.scan()
+
+	ticker := time.NewTicker(w.settings.poll_interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		curr := w.scan()
+
+		var events []Event
+
+		for name, c := range curr {
+			e := Event{
+				Path:  name,
+				IsDir: c.mode.IsDir(),
+			}
+
+			p, ok := prev[name]
+
+			switch {
+			case !ok:
+				e.Op = OpCreate
+			case c.mode.Type() != p.mode.Type():
+				e.Op = OpRemove | OpCreate
+			case !e.IsDir && (!c.mod_time.Equal(p.mod_time) || c.size != p.size):
+				e.Op = OpWrite
+			case c.mode.Perm() != p.mode.Perm():
+				e.Op = OpChmod
+			default:
+				continue
+			}
+
+			events = append(events, e)
+		}
+
+		for name, p := range prev {
+			_, ok := curr[name]
+			if !ok {
+				events = append(events, Event{Path: name, Op: OpRemove, IsDir: p.mode.IsDir()})
+			}
+		}
+
+		for _, e := range events {
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		prev = curr
+	}
+}
@@ -0,0 +1,253 @@
+package file_manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	// inotify_mask are the inotify events watched on every directory.
+	inotify_mask = syscall.IN_CREATE | syscall.IN_MOVED_TO | syscall.IN_MODIFY |
+		syscall.IN_CLOSE_WRITE | syscall.IN_DELETE | syscall.IN_MOVED_FROM |
+		syscall.IN_ATTRIB | syscall.IN_ONLYDIR | syscall.IN_DONT_FOLLOW
+)
+
+// inotify is an inotify instance watching a tree.
+type inotify struct {
+	// w is the watch.
+	w *watcher
+
+	// f is the inotify file. Its descriptor must not be taken with Fd, which
+	// would make reads block outside of the runtime poller.
+	f *os.File
+
+	// fd is the inotify file descriptor.
+	fd int
+
+	// paths are the watched directories, by watch descriptor.
+	paths map[int32]string
+}
+
+// run_native sends the raw events of the tree through inotify, until the
+// context is done.
+//
+// Parameters:
+//   - ctx: The context.
+//   - out: The channel the events are sent to.
+//
+// Returns:
+//   - error: An error if the tree could not be watched.
+//
+// Errors:
+//   - errors.ErrUnsupported: If inotify is unavailable or the watch limit is
+//     exhausted, wrapping the reason.
+//   - any error returned by inotify.
+func (w *watcher) run_native(ctx context.Context, out chan<- Event) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return errors.Join(errors.ErrUnsupported, os.NewSyscallError("inotify_init1", err))
+	}
+
+	in := &inotify{
+		w:     w,
+		f:     os.NewFile(uintptr(fd), "inotify"),
+		fd:    fd,
+		paths: make(map[int32]string),
+	}
+
+	defer in.f.Close()
+
+	err = in.add_tree(w.root, nil)
+	if err != nil {
+		return err
+	}
+
+	// Closing the file unblocks the pending read.
+	stop := context.AfterFunc(ctx, func() {
+		in.f.Close()
+	})
+
+	defer stop()
+
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := in.f.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		var events []Event
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[off:]))
+			mask := binary.NativeEndian.Uint32(buf[off+4:])
+			size := int(binary.NativeEndian.Uint32(buf[off+12:]))
+
+			start := off + syscall.SizeofInotifyEvent
+			name := string(bytes.TrimRight(buf[start:start+size], "\x00"))
+
+			off = start + size
+
+			events, err = in.handle(events, wd, mask, name)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, e := range events {
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// handle translates an inotify event.
+//
+// Parameters:
+//   - events: The events translated so far.
+//   - wd: The watch descriptor.
+//   - mask: The mask of the event.
+//   - name: The name of the entry, relative to the directory.
+//
+// Returns:
+//   - []Event: The events, with the new ones appended.
+//   - error: An error if a new directory could not be watched.
+func (in *inotify) handle(events []Event, wd int32, mask uint32, name string) ([]Event, error) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		return append(events, Event{Path: in.w.root, Op: OpOverflow, IsDir: true}), nil
+	}
+
+	dir, ok := in.paths[wd]
+	if !ok {
+		return events, nil
+	}
+
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(in.paths, wd)
+		return events, nil
+	} else if name == "" {
+		return events, nil
+	}
+
+	e := Event{
+		Path:  filepath.Join(dir, name),
+		IsDir: mask&syscall.IN_ISDIR != 0,
+	}
+
+	switch {
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		e.Op = OpCreate
+	case mask&(syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE) != 0:
+		e.Op = OpWrite
+	case mask&syscall.IN_DELETE != 0:
+		e.Op = OpRemove
+	case mask&syscall.IN_MOVED_FROM != 0:
+		e.Op = OpRename
+	case mask&syscall.IN_ATTRIB != 0:
+		e.Op = OpChmod
+	default:
+		return events, nil
+	}
+
+	events = append(events, e)
+
+	if !e.IsDir {
+		return events, nil
+	}
+
+	switch e.Op {
+	case OpCreate:
+		// Entries created before the watch was added are reported as
+		// created too.
+		var created []Event
+
+		err := in.add_tree(e.Path, &created)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, created...)
+	case OpRename:
+		in.remove_tree(e.Path)
+	}
+
+	return events, nil
+}
+
+// add_tree watches a directory and its sub-directories.
+//
+// Parameters:
+//   - root: The directory.
+//   - found: If not nil, the entries found under the directory are appended
+//     to it as created.
+//
+// Returns:
+//   - error: An error if a directory could not be watched.
+//
+// Errors:
+//   - errors.ErrUnsupported: If the watch limit is exhausted, wrapping the
+//     reason.
+//   - any error returned by inotify.
+func (in *inotify) add_tree(root string, found *[]Event) error {
+	return filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories removed or made unreadable meanwhile are skipped.
+			if name == root {
+				return nil
+			}
+
+			return fs.SkipDir
+		}
+
+		if found != nil && name != root {
+			*found = append(*found, Event{Path: name, Op: OpCreate, IsDir: d.IsDir()})
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		wd, err := syscall.InotifyAddWatch(in.fd, name, inotify_mask)
+		if err == nil {
+			in.paths[int32(wd)] = name
+			return nil
+		}
+
+		if errors.Is(err, syscall.ENOSPC) {
+			return errors.Join(errors.ErrUnsupported, os.NewSyscallError("inotify_add_watch", err))
+		} else if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.ENOTDIR) {
+			return fs.SkipDir
+		}
+
+		return &os.PathError{Op: "inotify_add_watch", Path: name, Err: err}
+	})
+}
+
+// remove_tree stops watching a directory and its sub-directories, such as
+// when it is moved out of the watched tree.
+//
+// Parameters:
+//   - root: The directory.
+func (in *inotify) remove_tree(root string) {
+	for wd, name := range in.paths {
+		if name == root || strings.HasPrefix(name, root+string(filepath.Separator)) {
+			_, _ = syscall.InotifyRmWatch(in.fd, uint32(wd))
+			delete(in.paths, wd)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package file_manager
+
+import (
+	"context"
+	"errors"
+)
+
+// run_native always fails as inotify is only available on Linux, so that the
+// tree is polled instead.
+//
+// Parameters:
+//   - ctx: The context.
+//   - out: The channel the events are sent to.
+//
+// Returns:
+//   - error: Always errors.ErrUnsupported.
+func (w *watcher) run_native(ctx context.Context, out chan<- Event) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,229 @@
+package file_manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// start_watch starts a watch in the background and returns the channel its
+// batches are sent to.
+func start_watch(t *testing.T, root string, opts ...WatchOption) <-chan []Event {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	batches := make(chan []Event, 16)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- Watch(ctx, root, func(events []Event) {
+			batches <- events
+		}, opts...)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+
+		err := <-done
+		if err != nil {
+			t.Errorf("expected no error, got %v instead", err)
+		}
+	})
+
+	// Gives the watch the time to start before the tree changes.
+	time.Sleep(100 * time.Millisecond)
+
+	return batches
+}
+
+// wait_for waits for the events of a path until the given changes were all
+// seen.
+func wait_for(t *testing.T, batches <-chan []Event, name string, op Op) Event {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	var seen Event
+
+	for {
+		select {
+		case events := <-batches:
+			for _, e := range events {
+				if e.Path == name {
+					seen.Path = e.Path
+					seen.Op |= e.Op
+					seen.IsDir = e.IsDir
+				}
+			}
+
+			if seen.Op&op == op {
+				return seen
+			}
+		case <-timeout:
+			t.Fatalf("expected %v on %s, got %v instead", op, name, seen.Op)
+		}
+	}
+}
+
+// write_file writes a file or fails the test.
+func write_file(t *testing.T, name, data string) {
+	t.Helper()
+
+	err := os.WriteFile(name, []byte(data), 0644)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+}
+
+// TestWatchRecursive tests that new sub-directories are watched and that the
+// events of a path are coalesced.
+func TestWatchRecursive(t *testing.T) {
+	root := t.TempDir()
+
+	fallback := make(chan error, 1)
+
+	batches := start_watch(t, root, WithDebounce(50*time.Millisecond), WithFallback(func(err error) {
+		fallback <- err
+	}))
+
+	sub := filepath.Join(root, "a", "b")
+
+	err := os.MkdirAll(sub, 0755)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	wait_for(t, batches, sub, OpCreate)
+
+	name := filepath.Join(sub, "main.go")
+	write_file(t, name, "package main")
+
+	e := wait_for(t, batches, name, OpCreate|OpWrite)
+	if e.IsDir {
+		t.Errorf("expected a file, got a directory instead")
+	}
+
+	err = os.Remove(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	wait_for(t, batches, name, OpRemove)
+
+	select {
+	case reason := <-fallback:
+		t.Logf("fell back to polling: %v", reason)
+	default:
+	}
+}
+
+// TestWatchFilter tests that the events are filtered by extension.
+func TestWatchFilter(t *testing.T) {
+	root := t.TempDir()
+
+	batches := start_watch(t, root,
+		WithDebounce(50*time.Millisecond),
+		WithWatchFileSettings(WithDir(false), WithFileExts(".go")),
+	)
+
+	write_file(t, filepath.Join(root, "notes.txt"), "ignored")
+	write_file(t, filepath.Join(root, "main.go"), "package main")
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case events := <-batches:
+			for _, e := range events {
+				if filepath.Ext(e.Path) != ".go" {
+					t.Fatalf("expected only .go files, got %s instead", e.Path)
+				}
+
+				return
+			}
+		case <-timeout:
+			t.Fatalf("expected an event for main.go")
+		}
+	}
+}
+
+// TestWatchMaxWait tests that a steady stream of events is reported before it
+// stops.
+func TestWatchMaxWait(t *testing.T) {
+	root := t.TempDir()
+
+	batches := start_watch(t, root,
+		WithDebounce(time.Second),
+		WithMaxWait(100*time.Millisecond),
+	)
+
+	name := filepath.Join(root, "app.log")
+
+	stop := time.After(5 * time.Second)
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-batches:
+			return
+		case <-ticker.C:
+			write_file(t, name, "line")
+		case <-stop:
+			t.Fatalf("expected a batch while writing")
+		}
+	}
+}
+
+// TestWatchPolling tests the polling watch.
+func TestWatchPolling(t *testing.T) {
+	root := t.TempDir()
+
+	name := filepath.Join(root, "data.csv")
+	write_file(t, name, "a")
+
+	batches := start_watch(t, root,
+		WithPolling(),
+		WithPollInterval(20*time.Millisecond),
+		WithDebounce(10*time.Millisecond),
+	)
+
+	write_file(t, name, "a,b")
+	wait_for(t, batches, name, OpWrite)
+
+	created := filepath.Join(root, "sub", "new.csv")
+
+	err := os.MkdirAll(filepath.Dir(created), 0755)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	write_file(t, created, "x")
+	wait_for(t, batches, created, OpCreate)
+
+	err = os.Chmod(name, 0600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	wait_for(t, batches, name, OpChmod)
+
+	err = os.Remove(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	wait_for(t, batches, name, OpRemove)
+}
+
+// TestOpString tests the names of the changes.
+func TestOpString(t *testing.T) {
+	got := (OpCreate | OpWrite).String()
+	if got != "create|write" {
+		t.Errorf("expected %q, got %q instead", "create|write", got)
+	}
+}
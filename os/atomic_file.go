@@ -0,0 +1,377 @@
+package os
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// AtomicOption is a type that defines an option of an atomic write.
+//
+// Parameters:
+//   - s: The settings to modify.
+type AtomicOption func(s *atomic_settings)
+
+// atomic_settings is the settings of an atomic write.
+type atomic_settings struct {
+	// perm is the permissions of a file that does not exist yet.
+	perm fs.FileMode
+
+	// backup is true if the previous version is kept.
+	backup bool
+}
+
+// WithPerm sets the permissions of the file if it does not exist yet. The
+// permissions of an existing file are always kept. By default, new files are
+// created with 0644.
+//
+// Parameters:
+//   - perm: The permissions.
+//
+// Returns:
+//   - AtomicOption: The option.
+func WithPerm(perm fs.FileMode) AtomicOption {
+	return func(s *atomic_settings) {
+		s.perm = perm.Perm()
+	}
+}
+
+// WithBackup keeps the previous version of the file, if any, next to it with
+// the ".bak" suffix. An existing backup is replaced.
+//
+// Returns:
+//   - AtomicOption: The option.
+func WithBackup() AtomicOption {
+	return func(s *atomic_settings) {
+		s.backup = true
+	}
+}
+
+// AtomicFile is a file that is written to a temporary file in the same
+// directory and only replaces its target when it is closed, so that a crash
+// mid-write never leaves a partially written target.
+type AtomicFile struct {
+	// f is the temporary file.
+	f *os.File
+
+	// path is the path of the target.
+	path string
+
+	// perm is the permissions of the target once committed.
+	perm fs.FileMode
+
+	// exists is true if the target already existed.
+	exists bool
+
+	// backup is true if the previous version is kept.
+	backup bool
+
+	// done is true once the file is committed or aborted.
+	done bool
+}
+
+// CreateAtomic starts an atomic write of a file.
+//
+// Parameters:
+//   - path: The path of the file. If it is a symbolic link, the file it points
+//     to is replaced instead of the link.
+//   - opts: The options of the write.
+//
+// Returns:
+//   - *AtomicFile: The file to write to. It must be either closed, which
+//     commits the write, or aborted.
+//   - error: An error if the temporary file could not be created.
+//
+// Errors:
+//   - any error returned by the file system.
+func CreateAtomic(path string, opts ...AtomicOption) (*AtomicFile, error) {
+	settings := atomic_settings{
+		perm: 0644,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		path = resolved
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	af := &AtomicFile{
+		path:   path,
+		perm:   settings.perm,
+		backup: settings.backup,
+	}
+
+	info, err := os.Stat(path)
+	if err == nil {
+		if info.IsDir() {
+			return nil, &fs.PathError{Op: "create", Path: path, Err: syscall.EISDIR}
+		}
+
+		af.perm = info.Mode().Perm()
+		af.exists = true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	dir, name := filepath.Split(path)
+
+	f, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	af.f = f
+
+	return af, nil
+}
+
+// Name returns the path of the target.
+//
+// Returns:
+//   - string: The path.
+func (af AtomicFile) Name() string {
+	return af.path
+}
+
+// Write implements the io.Writer interface.
+func (af *AtomicFile) Write(p []byte) (int, error) {
+	if af == nil {
+		return 0, gers.NewErrNilParameter("AtomicFile")
+	} else if af.done {
+		return 0, os.ErrClosed
+	}
+
+	return af.f.Write(p)
+}
+
+// Close commits the write: the temporary file is synced to disk, given the
+// permissions of the target and renamed over it, and the directory is synced
+// so that the rename itself is durable. On failure, the write is aborted and
+// the target is left untouched.
+//
+// Returns:
+//   - error: An error if the write could not be committed.
+//
+// Errors:
+//   - os.ErrClosed: If the file was already closed or aborted.
+//   - any error returned by the file system.
+func (af *AtomicFile) Close() error {
+	if af == nil {
+		return gers.NewErrNilParameter("AtomicFile")
+	} else if af.done {
+		return os.ErrClosed
+	}
+
+	err := af.commit()
+	if err != nil {
+		_ = af.Abort()
+		return err
+	}
+
+	af.done = true
+
+	return sync_dir(filepath.Dir(af.path))
+}
+
+// commit syncs the temporary file and renames it over the target.
+//
+// Returns:
+//   - error: An error if the file could not be committed.
+func (af *AtomicFile) commit() error {
+	err := af.f.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = af.f.Chmod(af.perm)
+	if err != nil {
+		return err
+	}
+
+	err = af.f.Close()
+	if err != nil {
+		return err
+	}
+
+	if af.backup && af.exists {
+		err := make_backup(af.path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(af.f.Name(), af.path)
+}
+
+// Abort discards the write and removes the temporary file. Does nothing if the
+// file was already closed or aborted, so it can be deferred right after
+// CreateAtomic.
+//
+// Returns:
+//   - error: An error if the temporary file could not be removed.
+func (af *AtomicFile) Abort() error {
+	if af == nil || af.done {
+		return nil
+	}
+
+	af.done = true
+
+	_ = af.f.Close()
+
+	err := os.Remove(af.f.Name())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// make_backup replaces the backup of a file with its current version.
+//
+// Parameters:
+//   - path: The path of the file.
+//
+// Returns:
+//   - error: An error if the backup could not be made.
+func make_backup(path string) error {
+	bak := path + ".bak"
+
+	err := os.Remove(bak)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// A hard link is both cheap and atomic; copying is the fallback for file
+	// systems without them.
+	err = os.Link(path, bak)
+	if err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(bak, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+
+	cerr := dst.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// sync_dir syncs a directory so that the entries renamed in it are durable.
+//
+// Parameters:
+//   - dir: The directory.
+//
+// Returns:
+//   - error: An error if the directory could not be synced. File systems and
+//     platforms that cannot sync directories are not reported.
+func sync_dir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	defer d.Close()
+
+	err = d.Sync()
+	if err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) && !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+
+	return nil
+}
+
+// WriteFileAtomic writes data to a file atomically and durably, like
+// os.WriteFile but without ever leaving a partially written file.
+//
+// Parameters:
+//   - path: The path of the file.
+//   - data: The data to write.
+//   - opts: The options of the write.
+//
+// Returns:
+//   - error: An error if the data could not be written.
+//
+// Errors:
+//   - any error returned by the file system.
+func WriteFileAtomic(path string, data []byte, opts ...AtomicOption) error {
+	af, err := CreateAtomic(path, opts...)
+	if err != nil {
+		return err
+	}
+
+	defer af.Abort()
+
+	_, err = af.Write(data)
+	if err != nil {
+		return err
+	}
+
+	return af.Close()
+}
+
+// CopyAtomic writes the content of a reader to a file atomically and durably.
+//
+// Parameters:
+//   - path: The path of the file.
+//   - r: The reader.
+//   - opts: The options of the write.
+//
+// Returns:
+//   - int64: The number of bytes written.
+//   - error: An error if the content could not be written, in which case the
+//     file is left untouched.
+//
+// Errors:
+//   - *errors.Err: If the reader is nil.
+//   - any error returned by the reader or the file system.
+func CopyAtomic(path string, r io.Reader, opts ...AtomicOption) (int64, error) {
+	if r == nil {
+		return 0, gers.NewErrNilParameter("r")
+	}
+
+	af, err := CreateAtomic(path, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	defer af.Abort()
+
+	n, err := io.Copy(af, r)
+	if err != nil {
+		return n, err
+	}
+
+	return n, af.Close()
+}
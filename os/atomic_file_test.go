@@ -0,0 +1,142 @@
+package os
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWriteFileAtomic tests that writes replace the file and keep its
+// permissions.
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+
+	err := WriteFileAtomic(path, []byte("v1"), WithPerm(0600))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	err = os.Chmod(path, 0640)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	err = WriteFileAtomic(path, []byte("v2"), WithBackup())
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "v2" {
+		t.Errorf("expected %q, got %q instead", "v2", data)
+	}
+
+	bak, _ := os.ReadFile(path + ".bak")
+	if string(bak) != "v1" {
+		t.Errorf("expected %q, got %q instead", "v1", bak)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected %v, got %v instead", os.FileMode(0640), info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected no temporary file to be left, got %d entries instead", len(entries))
+	}
+}
+
+// TestAtomicFileAbort tests that aborted and failed writes leave the file
+// untouched.
+func TestAtomicFileAbort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+
+	err := os.WriteFile(path, []byte("original"), 0644)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	af, err := CreateAtomic(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	_, _ = af.Write([]byte("partial"))
+
+	err = af.Abort()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if err := af.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected %v, got %v instead", os.ErrClosed, err)
+	}
+
+	expected := errors.New("read failure")
+
+	_, err = CopyAtomic(path, &failing_reader{err: expected})
+	if err != expected {
+		t.Errorf("expected %v, got %v instead", expected, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "original" {
+		t.Errorf("expected %q, got %q instead", "original", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary file to be left, got %d entries instead", len(entries))
+	}
+}
+
+// TestAtomicFileSymlink tests that the target of a symbolic link is replaced
+// instead of the link.
+func TestAtomicFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+
+	_ = os.WriteFile(target, []byte("old"), 0644)
+
+	err := os.Symlink(target, link)
+	if err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+
+	_, err = CopyAtomic(link, strings.NewReader("new"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	info, _ := os.Lstat(link)
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected the link to be kept")
+	}
+
+	data, _ := os.ReadFile(target)
+	if string(data) != "new" {
+		t.Errorf("expected %q, got %q instead", "new", data)
+	}
+}
+
+// failing_reader is a reader that writes some data and then fails.
+type failing_reader struct {
+	err  error
+	done bool
+}
+
+func (r *failing_reader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, r.err
+	}
+
+	r.done = true
+
+	return copy(p, "partial"), nil
+}
@@ -0,0 +1,657 @@
+package os
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gcstr "github.com/PlayerR9/go-commons/strings"
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// Stream is an output stream of a command.
+type Stream int
+
+const (
+	// StreamStdout is the standard output.
+	StreamStdout Stream = iota
+
+	// StreamStderr is the standard error.
+	StreamStderr
+)
+
+// String implements the fmt.Stringer interface.
+func (s Stream) String() string {
+	switch s {
+	case StreamStdout:
+		return "stdout"
+	case StreamStderr:
+		return "stderr"
+	default:
+		return "Stream(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// OutputLine is a line written by a command.
+type OutputLine struct {
+	// Stream is the stream the line was written to.
+	Stream Stream
+
+	// Text is the line, without its line ending.
+	Text string
+}
+
+// ErrExit is an error that is returned when a command exits with a non-zero
+// exit code or is killed by a signal.
+type ErrExit struct {
+	// Command is the command line.
+	Command string
+
+	// Code is the exit code. -1 if the command was killed by a signal.
+	Code int
+}
+
+// Error implements the error interface.
+//
+// Message: "command <command> exited with code <code>" or
+// "command <command> was killed"
+func (e ErrExit) Error() string {
+	if e.Code < 0 {
+		return "command " + strconv.Quote(e.Command) + " was killed"
+	}
+
+	return "command " + strconv.Quote(e.Command) + " exited with code " + strconv.Itoa(e.Code)
+}
+
+// NewErrExit creates a new ErrExit error.
+//
+// Parameters:
+//   - command: The command line.
+//   - code: The exit code. -1 if the command was killed by a signal.
+//
+// Returns:
+//   - *ErrExit: The new error. Never returns nil.
+func NewErrExit(command string, code int) *ErrExit {
+	return &ErrExit{
+		Command: command,
+		Code:    code,
+	}
+}
+
+// CommandOption is a type that defines an option of a command.
+//
+// Parameters:
+//   - s: The settings to modify.
+type CommandOption func(s *command_settings)
+
+// command_settings is the settings of a command.
+type command_settings struct {
+	// dir is the working directory. Empty for the current one.
+	dir string
+
+	// env are the environment overrides.
+	env map[string]string
+
+	// clean_env is true if the environment is not inherited.
+	clean_env bool
+
+	// timeout is the maximum duration of the command. 0 for no limit.
+	timeout time.Duration
+
+	// stdin is the standard input. Nil for none.
+	stdin io.Reader
+
+	// stdout receives the lines of the standard output. Nil to discard them.
+	stdout *gcstr.LineBuffer
+
+	// stderr receives the lines of the standard error. Nil to discard them.
+	stderr *gcstr.LineBuffer
+
+	// max_lines is the number of lines kept for Lines.
+	max_lines int
+}
+
+// WithDir sets the working directory of the command. By default, the current
+// working directory is used.
+//
+// Parameters:
+//   - dir: The working directory.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithDir(dir string) CommandOption {
+	return func(s *command_settings) {
+		s.dir = dir
+	}
+}
+
+// WithEnv sets an environment variable of the command, overriding the
+// inherited value, if any.
+//
+// Parameters:
+//   - key: The name of the variable.
+//   - value: The value of the variable.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithEnv(key, value string) CommandOption {
+	return func(s *command_settings) {
+		if s.env == nil {
+			s.env = make(map[string]string)
+		}
+
+		s.env[key] = value
+	}
+}
+
+// WithCleanEnv makes the command start with an empty environment, except for
+// the variables set with WithEnv. By default, the environment of the current
+// process is inherited.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithCleanEnv() CommandOption {
+	return func(s *command_settings) {
+		s.clean_env = true
+	}
+}
+
+// WithTimeout kills the command if it runs for longer than the given duration.
+// By default, only the context limits the command.
+//
+// Parameters:
+//   - timeout: The maximum duration. Non-positive values mean no limit.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithTimeout(timeout time.Duration) CommandOption {
+	return func(s *command_settings) {
+		s.timeout = max(timeout, 0)
+	}
+}
+
+// WithStdin sets the standard input of the command. By default, the command
+// reads from the null device.
+//
+// Parameters:
+//   - r: The standard input.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithStdin(r io.Reader) CommandOption {
+	return func(s *command_settings) {
+		s.stdin = r
+	}
+}
+
+// WithMaxLines sets how many of the last lines are kept for Process.Lines.
+// By default, 10000 lines are kept.
+//
+// Parameters:
+//   - n: The number of lines. Non-positive values keep none, such as when the
+//     output is only captured through the other options.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithMaxLines(n int) CommandOption {
+	return func(s *command_settings) {
+		s.max_lines = max(n, 0)
+	}
+}
+
+// WithStdout captures the lines of the standard output of the command.
+//
+// Parameters:
+//   - lb: The buffer that receives the lines.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithStdout(lb *gcstr.LineBuffer) CommandOption {
+	return func(s *command_settings) {
+		s.stdout = lb
+	}
+}
+
+// WithStderr captures the lines of the standard error of the command.
+//
+// Parameters:
+//   - lb: The buffer that receives the lines.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithStderr(lb *gcstr.LineBuffer) CommandOption {
+	return func(s *command_settings) {
+		s.stderr = lb
+	}
+}
+
+// WithCombinedOutput captures the lines of both the standard output and the
+// standard error of the command, interleaved in the order they were read.
+//
+// Parameters:
+//   - lb: The buffer that receives the lines.
+//
+// Returns:
+//   - CommandOption: The option.
+func WithCombinedOutput(lb *gcstr.LineBuffer) CommandOption {
+	return func(s *command_settings) {
+		s.stdout = lb
+		s.stderr = lb
+	}
+}
+
+// Process is a running command.
+type Process struct {
+	// cmd is the underlying command.
+	cmd *exec.Cmd
+
+	// line is the command line, for error messages.
+	line string
+
+	// ctx is the context the command runs in.
+	ctx context.Context
+
+	// cancel releases the resources of the context.
+	cancel context.CancelFunc
+
+	// mu protects lines and closed.
+	mu sync.Mutex
+
+	// cond signals new lines and the end of the output.
+	cond *sync.Cond
+
+	// lines are the last lines read.
+	lines []OutputLine
+
+	// first is the number of lines dropped before the first of lines.
+	first int
+
+	// max_lines is the number of lines kept.
+	max_lines int
+
+	// closed is true once both streams are exhausted.
+	closed bool
+
+	// readers tracks the goroutines reading the streams.
+	readers sync.WaitGroup
+
+	// exited is closed once exec.Cmd.Wait returned.
+	exited chan struct{}
+
+	// exit_err is the error returned by exec.Cmd.Wait.
+	exit_err error
+
+	// killed is true if the command was killed because its context was done.
+	killed atomic.Bool
+
+	// wait_once makes Wait only wait once.
+	wait_once sync.Once
+
+	// err is the result of Wait.
+	err error
+}
+
+// command_line returns the command line of a command, for error messages.
+//
+// Parameters:
+//   - name: The name of the program.
+//   - args: The arguments.
+//
+// Returns:
+//   - string: The command line.
+func command_line(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
+
+// environ returns the environment of a command.
+//
+// Parameters:
+//   - settings: The settings of the command.
+//
+// Returns:
+//   - []string: The environment, or nil to inherit it as is.
+func environ(settings command_settings) []string {
+	if len(settings.env) == 0 && !settings.clean_env {
+		return nil
+	}
+
+	env := []string{}
+
+	if !settings.clean_env {
+		for _, kv := range os.Environ() {
+			key, _, _ := strings.Cut(kv, "=")
+
+			_, ok := settings.env[key]
+			if !ok {
+				env = append(env, kv)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(settings.env))
+
+	for key := range settings.env {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		env = append(env, key+"="+settings.env[key])
+	}
+
+	return env
+}
+
+// Start starts a command. Its output must be consumed, either through Lines
+// or the options that capture it, and Wait must be called to release its
+// resources.
+//
+// Parameters:
+//   - ctx: The context of the command. When it is done, the command and every
+//     process it started in its process group are killed.
+//   - name: The name or path of the program.
+//   - args: The arguments of the program.
+//   - opts: The options of the command.
+//
+// Returns:
+//   - *Process: The running command.
+//   - error: An error if the command could not be started.
+//
+// Errors:
+//   - *errors.Err: If the context is nil.
+//   - any error returned by exec.Cmd.Start.
+func Start(ctx context.Context, name string, args []string, opts ...CommandOption) (*Process, error) {
+	if ctx == nil {
+		return nil, gers.NewErrNilParameter("ctx")
+	}
+
+	settings := command_settings{
+		max_lines: 10000,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	var cancel context.CancelFunc
+
+	if settings.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, settings.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = settings.dir
+	cmd.Env = environ(settings)
+	cmd.Stdin = settings.stdin
+	cmd.WaitDelay = time.Second
+
+	set_process_group(cmd)
+
+	p := &Process{
+		cmd:       cmd,
+		line:      command_line(name, args),
+		ctx:       ctx,
+		cancel:    cancel,
+		max_lines: settings.max_lines,
+		exited:    make(chan struct{}),
+	}
+
+	kill := cmd.Cancel
+
+	cmd.Cancel = func() error {
+		var err error
+
+		if kill != nil {
+			err = kill()
+		} else {
+			err = cmd.Process.Kill()
+		}
+
+		// A command that already exited was not killed by the context.
+		if err == nil {
+			p.killed.Store(true)
+		}
+
+		return err
+	}
+
+	// The streams are copied through exec.Cmd, rather than its pipes, so that
+	// WaitDelay bounds the wait for descendants that keep them open.
+	stdout, stdout_w := io.Pipe()
+	stderr, stderr_w := io.Pipe()
+
+	cmd.Stdout = stdout_w
+	cmd.Stderr = stderr_w
+
+	err := cmd.Start()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	p.cond = sync.NewCond(&p.mu)
+
+	p.readers.Add(2)
+
+	go p.read(stdout, StreamStdout, settings.stdout)
+	go p.read(stderr, StreamStderr, settings.stderr)
+
+	go func() {
+		p.exit_err = cmd.Wait()
+
+		// Every line was copied once exec.Cmd.Wait returned.
+		_ = stdout_w.Close()
+		_ = stderr_w.Close()
+
+		close(p.exited)
+	}()
+
+	go func() {
+		p.readers.Wait()
+
+		p.mu.Lock()
+		p.closed = true
+		p.mu.Unlock()
+
+		p.cond.Broadcast()
+	}()
+
+	return p, nil
+}
+
+// read reads the lines of a stream.
+//
+// Parameters:
+//   - r: The stream.
+//   - stream: The kind of the stream.
+//   - lb: The buffer that receives the lines. Nil to discard them.
+func (p *Process) read(r io.Reader, stream Stream, lb *gcstr.LineBuffer) {
+	defer p.readers.Done()
+
+	br := bufio.NewReader(r)
+
+	for {
+		line, err := br.ReadString('\n')
+
+		if line != "" {
+			line = strings.TrimSuffix(line, "\n")
+			line = strings.TrimSuffix(line, "\r")
+
+			p.mu.Lock()
+
+			p.lines = append(p.lines, OutputLine{Stream: stream, Text: line})
+
+			if len(p.lines) > p.max_lines {
+				drop := len(p.lines) - p.max_lines
+
+				p.lines = p.lines[drop:]
+				p.first += drop
+			}
+
+			if lb != nil {
+				lb.AddString(line)
+			}
+
+			p.mu.Unlock()
+
+			p.cond.Broadcast()
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Pid returns the process ID of the command.
+//
+// Returns:
+//   - int: The process ID.
+func (p *Process) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+// Lines returns an iterator over the lines of both streams, interleaved in
+// the order they were read. It yields lines as soon as they are written and
+// stops once both streams are closed. Every call iterates from the first line
+// still kept; lines dropped past the maximum, even while iterating, are
+// skipped.
+//
+// Returns:
+//   - iter.Seq[OutputLine]: The iterator.
+func (p *Process) Lines() iter.Seq[OutputLine] {
+	return func(yield func(OutputLine) bool) {
+		for i := 0; ; i++ {
+			p.mu.Lock()
+
+			for i >= p.first+len(p.lines) && !p.closed {
+				p.cond.Wait()
+			}
+
+			i = max(i, p.first)
+
+			if i >= p.first+len(p.lines) {
+				p.mu.Unlock()
+				return
+			}
+
+			line := p.lines[i-p.first]
+
+			p.mu.Unlock()
+
+			if !yield(line) {
+				return
+			}
+		}
+	}
+}
+
+// Wait waits for the command to exit and for its output to be read.
+//
+// Returns:
+//   - error: An error if the command failed.
+//
+// Errors:
+//   - context.Canceled, context.DeadlineExceeded: If the context was done
+//     before the command exited, or its timeout expired.
+//   - *ErrExit: If the command exited with a non-zero exit code or was killed
+//     by a signal.
+//   - exec.ErrWaitDelay: If processes started by the command kept its output
+//     open for more than a second after it exited.
+//   - any other error returned by exec.Cmd.Wait.
+//
+// Calling Wait more than once returns the same error.
+func (p *Process) Wait() error {
+	p.wait_once.Do(func() {
+		p.err = p.wait()
+	})
+
+	return p.err
+}
+
+// wait waits for the command to exit and for its output to be read.
+//
+// Returns:
+//   - error: An error if the command failed.
+func (p *Process) wait() error {
+	defer p.cancel()
+
+	<-p.exited
+	p.readers.Wait()
+
+	err := p.exit_err
+	if err == nil {
+		return nil
+	}
+
+	if p.killed.Load() {
+		return p.ctx.Err()
+	}
+
+	var exit_err *exec.ExitError
+
+	if errors.As(err, &exit_err) {
+		return NewErrExit(p.line, exit_err.ExitCode())
+	}
+
+	return err
+}
+
+// Run runs a command to completion.
+//
+// Parameters:
+//   - ctx: The context of the command.
+//   - name: The name or path of the program.
+//   - args: The arguments of the program.
+//   - opts: The options of the command.
+//
+// Returns:
+//   - error: An error if the command could not be started or failed.
+//
+// Errors:
+//   - see Start and Process.Wait.
+func Run(ctx context.Context, name string, args []string, opts ...CommandOption) error {
+	p, err := Start(ctx, name, args, opts...)
+	if err != nil {
+		return err
+	}
+
+	return p.Wait()
+}
+
+// Output runs a command to completion and returns its standard output.
+//
+// Parameters:
+//   - ctx: The context of the command.
+//   - name: The name or path of the program.
+//   - args: The arguments of the program.
+//   - opts: The options of the command.
+//
+// Returns:
+//   - string: The lines of the standard output, joined by newlines.
+//   - error: An error if the command could not be started or failed.
+//
+// Errors:
+//   - see Start and Process.Wait.
+func Output(ctx context.Context, name string, args []string, opts ...CommandOption) (string, error) {
+	var lb gcstr.LineBuffer
+
+	opts = append(slices.Clip(opts), WithStdout(&lb))
+
+	err := Run(ctx, name, args, opts...)
+
+	return lb.String(), err
+}
@@ -0,0 +1,152 @@
+package os
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+// TestRun tests capturing the output of a command.
+func TestRun(t *testing.T) {
+	var stdout, stderr, combined gcstr.LineBuffer
+
+	script := "echo out1; echo err1 >&2; echo \"$GREETING\"; exit 3"
+
+	err := Run(context.Background(), "sh", []string{"-c", script},
+		WithStdout(&stdout), WithStderr(&stderr), WithEnv("GREETING", "hello"))
+
+	var exit_err *ErrExit
+
+	if !errors.As(err, &exit_err) || exit_err.Code != 3 {
+		t.Fatalf("expected exit code %d, got %v instead", 3, err)
+	}
+
+	if stdout.String() != "out1\nhello" {
+		t.Errorf("expected %q, got %q instead", "out1\nhello", stdout.String())
+	}
+
+	if stderr.String() != "err1" {
+		t.Errorf("expected %q, got %q instead", "err1", stderr.String())
+	}
+
+	err = Run(context.Background(), "sh", []string{"-c", "echo a; sleep 0.05; echo b >&2"}, WithCombinedOutput(&combined))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if combined.String() != "a\nb" {
+		t.Errorf("expected %q, got %q instead", "a\nb", combined.String())
+	}
+
+	out, err := Output(context.Background(), "sh", []string{"-c", "echo \"${HOME:-unset}\""}, WithCleanEnv())
+	if err != nil || out != "unset" {
+		t.Errorf("expected %q, got %q instead (%v)", "unset", out, err)
+	}
+}
+
+// TestLines tests iterating over live output.
+func TestLines(t *testing.T) {
+	p, err := Start(context.Background(), "sh", []string{"-c", "echo one; echo two >&2; echo three"}, WithStdin(strings.NewReader("")))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	var stdout []string
+
+	var stderr int
+
+	for line := range p.Lines() {
+		if line.Stream == StreamStderr {
+			stderr++
+		} else {
+			stdout = append(stdout, line.Text)
+		}
+	}
+
+	err = p.Wait()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if strings.Join(stdout, " ") != "one three" || stderr != 1 {
+		t.Errorf("expected %q and 1 error line, got %q and %d instead", "one three", stdout, stderr)
+	}
+}
+
+// TestTimeout tests that timeouts kill the whole process group.
+func TestTimeout(t *testing.T) {
+	start := time.Now()
+
+	err := Run(context.Background(), "sh", []string{"-c", "sleep 10 & sleep 10; wait"}, WithTimeout(100*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v instead", context.DeadlineExceeded, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the command to be killed, took %v instead", elapsed)
+	}
+}
+
+// TestMaxLines tests that only the last lines are kept.
+func TestMaxLines(t *testing.T) {
+	p, err := Start(context.Background(), "sh", []string{"-c", "seq 1 100"}, WithMaxLines(3))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	err = p.Wait()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	var lines []string
+
+	for line := range p.Lines() {
+		lines = append(lines, line.Text)
+	}
+
+	if strings.Join(lines, " ") != "98 99 100" {
+		t.Errorf("expected %q, got %q instead", "98 99 100", lines)
+	}
+}
+
+// TestWaitDelay tests that Wait does not hang on processes that keep the
+// output open.
+func TestWaitDelay(t *testing.T) {
+	start := time.Now()
+
+	err := Run(context.Background(), "sh", []string{"-c", "sleep 5 & echo started"})
+	if !errors.Is(err, exec.ErrWaitDelay) {
+		t.Errorf("expected %v, got %v instead", exec.ErrWaitDelay, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("expected Wait to give up on the output, took %v instead", elapsed)
+	}
+}
+
+// TestExitBeforeCancel tests that a command that exited on its own is not
+// reported as cancelled.
+func TestExitBeforeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p, err := Start(ctx, "sh", []string{"-c", "exit 3"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	<-p.exited
+	cancel()
+
+	err = p.Wait()
+
+	var exit_err *ErrExit
+	if !errors.As(err, &exit_err) || exit_err.Code != 3 {
+		t.Errorf("expected exit code 3, got %v instead", err)
+	}
+}
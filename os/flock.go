@@ -0,0 +1,456 @@
+package os
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+var (
+	// AlreadyLocked is an error that is returned when a lock is tried but the
+	// file is locked by another process. Functions must return this error as
+	// is and not wrap it as callers are expected to check for this error
+	// using ==.
+	AlreadyLocked error
+)
+
+func init() {
+	AlreadyLocked = errors.New("file is already locked")
+}
+
+// LockMode is the mode of a file lock.
+type LockMode int
+
+const (
+	// LockShared is a lock that can be held by several processes at once, as
+	// long as none holds an exclusive lock. It suits readers.
+	LockShared LockMode = iota
+
+	// LockExclusive is a lock that can only be held by one process at once.
+	// It suits writers.
+	LockExclusive
+)
+
+// String implements the fmt.Stringer interface.
+func (m LockMode) String() string {
+	switch m {
+	case LockShared:
+		return "shared"
+	case LockExclusive:
+		return "exclusive"
+	default:
+		return "LockMode(" + strconv.Itoa(int(m)) + ")"
+	}
+}
+
+// FileLock is an advisory lock on a file, held through flock. Advisory locks
+// only exclude processes that lock the same file; they do not prevent reading
+// or writing it. The lock is released when the process exits, even if it
+// crashes.
+type FileLock struct {
+	// f is the locked file. Nil once unlocked.
+	f *os.File
+
+	// path is the path of the locked file.
+	path string
+
+	// mode is the mode of the lock.
+	mode LockMode
+}
+
+// open_lock creates the file to lock if it does not exist and opens it.
+//
+// Parameters:
+//   - path: The path of the file.
+//   - mode: The mode of the lock.
+//
+// Returns:
+//   - *os.File: The file.
+//   - error: An error if the file could not be opened.
+func open_lock(path string, mode LockMode) (*os.File, error) {
+	if mode != LockShared && mode != LockExclusive {
+		return nil, gers.NewErrInvalidParameter("mode is not valid")
+	}
+
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+}
+
+// Lock locks a file, waiting until the lock is available.
+//
+// Parameters:
+//   - path: The path of the file. It is created if it does not exist.
+//   - mode: The mode of the lock.
+//
+// Returns:
+//   - *FileLock: The lock. It must be unlocked.
+//   - error: An error if the file could not be locked.
+//
+// Errors:
+//   - *errors.Err: If the mode is not valid.
+//   - errors.ErrUnsupported: If file locks are not supported on this platform.
+//   - any error returned by the file system.
+func Lock(path string, mode LockMode) (*FileLock, error) {
+	f, err := open_lock(path, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = flock(f, mode, true)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &FileLock{f: f, path: path, mode: mode}, nil
+}
+
+// TryLock locks a file if the lock is available, without waiting.
+//
+// Parameters:
+//   - path: The path of the file. It is created if it does not exist.
+//   - mode: The mode of the lock.
+//
+// Returns:
+//   - *FileLock: The lock. It must be unlocked.
+//   - error: An error if the file could not be locked.
+//
+// Errors:
+//   - AlreadyLocked: If the file is locked by another process.
+//   - *errors.Err: If the mode is not valid.
+//   - errors.ErrUnsupported: If file locks are not supported on this platform.
+//   - any error returned by the file system.
+func TryLock(path string, mode LockMode) (*FileLock, error) {
+	f, err := open_lock(path, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = flock(f, mode, false)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &FileLock{f: f, path: path, mode: mode}, nil
+}
+
+// LockContext locks a file, waiting until the lock is available or the
+// context is done. As flock cannot be interrupted, the lock is polled with an
+// increasing interval of up to 100 milliseconds.
+//
+// Parameters:
+//   - ctx: The context.
+//   - path: The path of the file. It is created if it does not exist.
+//   - mode: The mode of the lock.
+//
+// Returns:
+//   - *FileLock: The lock. It must be unlocked.
+//   - error: An error if the file could not be locked.
+//
+// Errors:
+//   - *errors.Err: If the context is nil or the mode is not valid.
+//   - errors.ErrUnsupported: If file locks are not supported on this platform.
+//   - any error returned by the context or the file system.
+func LockContext(ctx context.Context, path string, mode LockMode) (*FileLock, error) {
+	if ctx == nil {
+		return nil, gers.NewErrNilParameter("ctx")
+	}
+
+	f, err := open_lock(path, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	delay := time.Millisecond
+
+	for {
+		err := flock(f, mode, false)
+		if err == nil {
+			return &FileLock{f: f, path: path, mode: mode}, nil
+		} else if err != AlreadyLocked {
+			f.Close()
+			return nil, err
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			f.Close()
+
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, 100*time.Millisecond)
+	}
+}
+
+// Path returns the path of the locked file, even once it is unlocked.
+//
+// Returns:
+//   - string: The path.
+func (l FileLock) Path() string {
+	return l.path
+}
+
+// Mode returns the mode of the lock.
+//
+// Returns:
+//   - LockMode: The mode.
+func (l FileLock) Mode() LockMode {
+	return l.mode
+}
+
+// Unlock releases the lock. The file is left in place, as removing it would
+// let another process lock a file that is no longer reachable by its path.
+//
+// Returns:
+//   - error: An error if the lock could not be released.
+//
+// Errors:
+//   - os.ErrClosed: If the lock was already released.
+//   - any error returned by the operating system.
+func (l *FileLock) Unlock() error {
+	if l == nil {
+		return gers.NewErrNilParameter("FileLock")
+	} else if l.f == nil {
+		return os.ErrClosed
+	}
+
+	err := funlock(l.f)
+
+	cerr := l.f.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	l.f = nil
+
+	return err
+}
+
+// ErrInstanceRunning is an error that is returned when another instance of
+// the program holds the pidfile.
+type ErrInstanceRunning struct {
+	// Path is the path of the pidfile.
+	Path string
+
+	// Pid is the ID of the process that holds it. 0 if it is unknown.
+	Pid int
+}
+
+// Error implements the error interface.
+//
+// Message: "another instance is running (pid <pid>, pidfile <path>)"
+func (e ErrInstanceRunning) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString("another instance is running (")
+
+	if e.Pid > 0 {
+		builder.WriteString("pid ")
+		builder.WriteString(strconv.Itoa(e.Pid))
+		builder.WriteString(", ")
+	}
+
+	builder.WriteString("pidfile ")
+	builder.WriteString(strconv.Quote(e.Path))
+	builder.WriteString(")")
+
+	return builder.String()
+}
+
+// NewErrInstanceRunning creates a new ErrInstanceRunning error.
+//
+// Parameters:
+//   - path: The path of the pidfile.
+//   - pid: The ID of the process that holds it. 0 if it is unknown.
+//
+// Returns:
+//   - *ErrInstanceRunning: The new error. Never returns nil.
+func NewErrInstanceRunning(path string, pid int) *ErrInstanceRunning {
+	return &ErrInstanceRunning{
+		Path: path,
+		Pid:  pid,
+	}
+}
+
+// Instance is a guard that ensures only one instance of a program runs at
+// once, through a pidfile that is exclusively locked for as long as the
+// instance runs.
+type Instance struct {
+	// lock is the lock on the pidfile.
+	lock *FileLock
+
+	// stale is the ID of the process that left the pidfile behind. 0 if none.
+	stale int
+}
+
+// AcquireInstance claims a pidfile for the current process.
+//
+// Parameters:
+//   - path: The path of the pidfile.
+//
+// Returns:
+//   - *Instance: The guard. It must be released.
+//   - error: An error if the pidfile could not be claimed.
+//
+// Errors:
+//   - *ErrInstanceRunning: If another instance holds the pidfile.
+//   - errors.ErrUnsupported: If file locks are not supported on this platform.
+//   - any error returned by the file system.
+//
+// A pidfile left behind by a process that died without releasing it is
+// stale: as the lock of a process is released when it dies, such a pidfile is
+// unlocked and is claimed as if it did not exist. StalePid reports the
+// process that left it.
+func AcquireInstance(path string) (*Instance, error) {
+	for {
+		lock, err := TryLock(path, LockExclusive)
+		if err == AlreadyLocked {
+			return nil, NewErrInstanceRunning(path, read_pid(path))
+		} else if err != nil {
+			return nil, err
+		}
+
+		// A releasing instance removes the pidfile once it is locked; a lock
+		// taken on the removed file must be retried on the new one.
+		if !same_file(lock.f, path) {
+			_ = lock.Unlock()
+			continue
+		}
+
+		inst := &Instance{
+			lock: lock,
+		}
+
+		pid := read_pid(path)
+		if pid > 0 && pid != os.Getpid() {
+			inst.stale = pid
+		}
+
+		err = write_pid(lock.f)
+		if err != nil {
+			_ = lock.Unlock()
+			return nil, err
+		}
+
+		return inst, nil
+	}
+}
+
+// StalePid returns the ID of the process that left a stale pidfile behind.
+//
+// Returns:
+//   - int: The ID. 0 if the pidfile was not stale.
+func (i Instance) StalePid() int {
+	return i.stale
+}
+
+// Path returns the path of the pidfile, even once it is released.
+//
+// Returns:
+//   - string: The path.
+func (i Instance) Path() string {
+	return i.lock.Path()
+}
+
+// Release removes the pidfile and releases it so that another instance can
+// run.
+//
+// Returns:
+//   - error: An error if the pidfile could not be released.
+//
+// Errors:
+//   - os.ErrClosed: If the pidfile was already released.
+//   - any error returned by the file system.
+func (i *Instance) Release() error {
+	if i == nil {
+		return gers.NewErrNilParameter("Instance")
+	} else if i.lock.f == nil {
+		return os.ErrClosed
+	}
+
+	// The pidfile is removed while it is still locked so that no other
+	// instance can claim it in between.
+	err := os.Remove(i.lock.Path())
+
+	uerr := i.lock.Unlock()
+	if err == nil {
+		err = uerr
+	}
+
+	return err
+}
+
+// same_file checks whether an open file is still the one at a path.
+//
+// Parameters:
+//   - f: The open file.
+//   - path: The path.
+//
+// Returns:
+//   - bool: True if the path leads to the open file, false otherwise.
+func same_file(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(fi, pi)
+}
+
+// read_pid reads the process ID of a pidfile.
+//
+// Parameters:
+//   - path: The path of the pidfile.
+//
+// Returns:
+//   - int: The process ID. 0 if it could not be read.
+func read_pid(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid < 0 {
+		return 0
+	}
+
+	return pid
+}
+
+// write_pid replaces the content of a pidfile with the ID of the current
+// process.
+//
+// Parameters:
+//   - f: The pidfile.
+//
+// Returns:
+//   - error: An error if the ID could not be written.
+func write_pid(f *os.File) error {
+	err := f.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	if err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
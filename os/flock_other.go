@@ -0,0 +1,33 @@
+//go:build !unix
+
+package os
+
+import (
+	"errors"
+	"os"
+)
+
+// flock always fails as file locks are yet to be supported on this platform.
+//
+// Parameters:
+//   - f: The file.
+//   - mode: The mode of the lock.
+//   - block: True to wait until the lock is available, false to fail at once.
+//
+// Returns:
+//   - error: Always errors.ErrUnsupported.
+func flock(f *os.File, mode LockMode, block bool) error {
+	return errors.ErrUnsupported
+}
+
+// funlock always fails as file locks are yet to be supported on this
+// platform.
+//
+// Parameters:
+//   - f: The file.
+//
+// Returns:
+//   - error: Always errors.ErrUnsupported.
+func funlock(f *os.File) error {
+	return errors.ErrUnsupported
+}
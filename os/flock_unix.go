@@ -0,0 +1,63 @@
+//go:build unix
+
+package os
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// flock locks a file.
+//
+// Parameters:
+//   - f: The file.
+//   - mode: The mode of the lock.
+//   - block: True to wait until the lock is available, false to fail at once.
+//
+// Returns:
+//   - error: An error if the file could not be locked.
+//
+// Errors:
+//   - AlreadyLocked: If block is false and the file is locked by another
+//     process.
+//   - any error returned by the operating system.
+func flock(f *os.File, mode LockMode, block bool) error {
+	how := syscall.LOCK_EX
+	if mode == LockShared {
+		how = syscall.LOCK_SH
+	}
+
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return AlreadyLocked
+		} else if !errors.Is(err, syscall.EINTR) {
+			return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+		}
+	}
+}
+
+// funlock unlocks a file.
+//
+// Parameters:
+//   - f: The file.
+//
+// Returns:
+//   - error: An error if the file could not be unlocked.
+func funlock(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	if err != nil {
+		return &os.PathError{Op: "funlock", Path: f.Name(), Err: err}
+	}
+
+	return nil
+}
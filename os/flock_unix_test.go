@@ -0,0 +1,162 @@
+//go:build unix
+
+package os
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTryLock tests the compatibility of shared and exclusive locks. Locks
+// are held per open file, so they exclude each other within one process too.
+func TestTryLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.lock")
+
+	first, err := TryLock(path, LockShared)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	second, err := TryLock(path, LockShared)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	_, err = TryLock(path, LockExclusive)
+	if err != AlreadyLocked {
+		t.Errorf("expected %v, got %v instead", AlreadyLocked, err)
+	}
+
+	_ = first.Unlock()
+	_ = second.Unlock()
+
+	lock, err := TryLock(path, LockExclusive)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	_, err = TryLock(path, LockShared)
+	if err != AlreadyLocked {
+		t.Errorf("expected %v, got %v instead", AlreadyLocked, err)
+	}
+
+	err = lock.Unlock()
+	if err != nil {
+		t.Errorf("expected no error, got %v instead", err)
+	}
+
+	err = lock.Unlock()
+	if err != os.ErrClosed {
+		t.Errorf("expected %v, got %v instead", os.ErrClosed, err)
+	}
+	if lock.Path() != path {
+		t.Errorf("expected %q, got %q instead", path, lock.Path())
+	}
+}
+
+// TestLockContext tests that waiting for a lock stops when the context is
+// done and succeeds once the lock is released.
+func TestLockContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.lock")
+
+	held, err := Lock(path, LockExclusive)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	_, err = LockContext(ctx, path, LockExclusive)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v instead", context.DeadlineExceeded, err)
+	}
+
+	time.AfterFunc(30*time.Millisecond, func() {
+		_ = held.Unlock()
+	})
+
+	lock, err := LockContext(context.Background(), path, LockExclusive)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	_ = lock.Unlock()
+}
+
+// TestAcquireInstance tests that a second instance is refused and that a
+// stale pidfile is claimed.
+func TestAcquireInstance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool.pid")
+
+	err := os.WriteFile(path, []byte("999999999\n"), 0644)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	inst, err := AcquireInstance(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if inst.StalePid() != 999999999 {
+		t.Errorf("expected %d, got %d instead", 999999999, inst.StalePid())
+	}
+
+	if pid := read_pid(path); pid != os.Getpid() {
+		t.Errorf("expected %d, got %d instead", os.Getpid(), pid)
+	}
+
+	_, err = AcquireInstance(path)
+
+	var running *ErrInstanceRunning
+
+	if !errors.As(err, &running) {
+		t.Fatalf("expected an ErrInstanceRunning, got %v instead", err)
+	}
+
+	if running.Pid != os.Getpid() {
+		t.Errorf("expected %d, got %d instead", os.Getpid(), running.Pid)
+	}
+
+	err = inst.Release()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	_, err = os.Stat(path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %v, got %v instead", fs.ErrNotExist, err)
+	}
+
+	if inst.Path() != path {
+		t.Errorf("expected %q, got %q instead", path, inst.Path())
+	}
+
+	inst, err = AcquireInstance(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if inst.StalePid() != 0 {
+		t.Errorf("expected %d, got %d instead", 0, inst.StalePid())
+	}
+
+	_ = inst.Release()
+}
+
+// TestLockModeString tests the names of the lock modes.
+func TestLockModeString(t *testing.T) {
+	if LockExclusive.String() != "exclusive" {
+		t.Errorf("expected %q, got %q instead", "exclusive", LockExclusive.String())
+	}
+
+	if LockMode(-1).String() != "LockMode(-1)" {
+		t.Errorf("expected %q, got %q instead", "LockMode(-1)", LockMode(-1).String())
+	}
+}
@@ -0,0 +1,423 @@
+package os
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+// LifecycleOption is a type that defines an option of a Lifecycle.
+//
+// Parameters:
+//   - s: The settings to modify.
+type LifecycleOption func(s *lifecycle_settings)
+
+// lifecycle_settings is the settings of a Lifecycle.
+type lifecycle_settings struct {
+	// timeout is the deadline of the shutdown hooks.
+	timeout time.Duration
+
+	// reload is called on SIGHUP. Nil if SIGHUP is not trapped.
+	reload func()
+
+	// exit is called to force the exit.
+	exit func(code int)
+}
+
+// WithShutdownTimeout sets how long the shutdown hooks may run in total. By
+// default, they may run for 10 seconds.
+//
+// Parameters:
+//   - timeout: The deadline. Non-positive values mean no deadline.
+//
+// Returns:
+//   - LifecycleOption: The option.
+func WithShutdownTimeout(timeout time.Duration) LifecycleOption {
+	return func(s *lifecycle_settings) {
+		s.timeout = max(timeout, 0)
+	}
+}
+
+// WithReload traps SIGHUP and calls the given function every time it is
+// received. By default, SIGHUP is not trapped.
+//
+// Parameters:
+//   - reload: The function to call. It runs on the signal-handling goroutine,
+//     so signals received meanwhile are handled once it returns.
+//
+// Returns:
+//   - LifecycleOption: The option.
+func WithReload(reload func()) LifecycleOption {
+	return func(s *lifecycle_settings) {
+		s.reload = reload
+	}
+}
+
+// WithExit sets the function called to force the exit when a second signal is
+// received. By default, os.Exit is called.
+//
+// Parameters:
+//   - exit: The function. It receives 128 plus the number of the signal.
+//
+// Returns:
+//   - LifecycleOption: The option.
+func WithExit(exit func(code int)) LifecycleOption {
+	return func(s *lifecycle_settings) {
+		if exit == nil {
+			exit = os.Exit
+		}
+
+		s.exit = exit
+	}
+}
+
+// ErrShutdownTimeout is an error that is returned when the shutdown hooks did
+// not complete before the deadline.
+type ErrShutdownTimeout struct {
+	// Pending are the names of the hooks that did not complete, in the order
+	// they were due to run.
+	Pending []string
+}
+
+// Error implements the error interface.
+//
+// Message: "shutdown timed out with <n> pending hooks"
+func (e ErrShutdownTimeout) Error() string {
+	return "shutdown timed out with " + strconv.Itoa(len(e.Pending)) + " pending hooks"
+}
+
+// NewErrShutdownTimeout creates a new ErrShutdownTimeout error.
+//
+// Parameters:
+//   - pending: The names of the hooks that did not complete.
+//
+// Returns:
+//   - *ErrShutdownTimeout: The new error. Never returns nil.
+func NewErrShutdownTimeout(pending []string) *ErrShutdownTimeout {
+	return &ErrShutdownTimeout{
+		Pending: pending,
+	}
+}
+
+// ErrHook is an error that is returned when a shutdown hook fails.
+type ErrHook struct {
+	// Name is the name of the hook.
+	Name string
+
+	// Reason is the error returned by the hook.
+	Reason error
+}
+
+// Error implements the error interface.
+//
+// Message: "shutdown hook <name> failed: <reason>"
+func (e ErrHook) Error() string {
+	return "shutdown hook " + strconv.Quote(e.Name) + " failed: " + e.Reason.Error()
+}
+
+// Unwrap returns the error returned by the hook.
+//
+// Returns:
+//   - error: The error.
+func (e ErrHook) Unwrap() error {
+	return e.Reason
+}
+
+// NewErrHook creates a new ErrHook error.
+//
+// Parameters:
+//   - name: The name of the hook.
+//   - reason: The error returned by the hook.
+//
+// Returns:
+//   - *ErrHook: The new error. Never returns nil.
+func NewErrHook(name string, reason error) *ErrHook {
+	return &ErrHook{
+		Name:   name,
+		Reason: reason,
+	}
+}
+
+// shutdown_hook is a function run on shutdown.
+type shutdown_hook struct {
+	// name is the name of the hook.
+	name string
+
+	// fn is the function.
+	fn func(ctx context.Context) error
+}
+
+// Lifecycle traps termination signals and shuts the program down gracefully.
+// The first SIGINT or SIGTERM cancels the root context; a second one, or one
+// received while Shutdown runs, forces the exit.
+type Lifecycle struct {
+	// ctx is the root context.
+	ctx context.Context
+
+	// cancel cancels the root context.
+	cancel context.CancelFunc
+
+	// settings are the settings of the lifecycle.
+	settings lifecycle_settings
+
+	// signals receives the trapped signals.
+	signals chan os.Signal
+
+	// stop stops the signal-handling goroutine.
+	stop chan struct{}
+
+	// mu protects hooks, stopping and sig.
+	mu sync.Mutex
+
+	// hooks are the shutdown hooks, in registration order.
+	hooks []shutdown_hook
+
+	// stopping is true once the shutdown started, by a signal or Shutdown.
+	stopping bool
+
+	// sig is the signal that started the shutdown. Nil if none.
+	sig os.Signal
+
+	// shutdown_once makes Shutdown only run the hooks once.
+	shutdown_once sync.Once
+
+	// err is the result of Shutdown.
+	err error
+}
+
+// NewLifecycle starts trapping SIGINT and SIGTERM, and SIGHUP if a reload
+// function is set.
+//
+// Parameters:
+//   - parent: The parent of the root context. If nil, context.Background() is
+//     used.
+//   - opts: The options of the lifecycle.
+//
+// Returns:
+//   - *Lifecycle: The new lifecycle. Never returns nil.
+//
+// Signals are trapped until Shutdown completes.
+func NewLifecycle(parent context.Context, opts ...LifecycleOption) *Lifecycle {
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	settings := lifecycle_settings{
+		timeout: 10 * time.Second,
+		exit:    os.Exit,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+
+	l := &Lifecycle{
+		ctx:      ctx,
+		cancel:   cancel,
+		settings: settings,
+		signals:  make(chan os.Signal, 2),
+		stop:     make(chan struct{}),
+	}
+
+	sigs := []os.Signal{os.Interrupt, syscall.SIGTERM}
+	if settings.reload != nil {
+		sigs = append(sigs, syscall.SIGHUP)
+	}
+
+	signal.Notify(l.signals, sigs...)
+
+	go l.handle_signals()
+
+	return l
+}
+
+// handle_signals handles the trapped signals until the lifecycle stops.
+func (l *Lifecycle) handle_signals() {
+	for {
+		select {
+		case <-l.stop:
+			return
+		case sig := <-l.signals:
+			if sig == syscall.SIGHUP {
+				l.settings.reload()
+				continue
+			}
+
+			l.mu.Lock()
+
+			first := !l.stopping
+			if first {
+				l.stopping = true
+				l.sig = sig
+			}
+
+			l.mu.Unlock()
+
+			if first {
+				l.cancel()
+				continue
+			}
+
+			code := 1
+
+			s, ok := sig.(syscall.Signal)
+			if ok {
+				code = 128 + int(s)
+			}
+
+			l.settings.exit(code)
+		}
+	}
+}
+
+// Context returns the root context, which is cancelled on the first SIGINT or
+// SIGTERM, on Shutdown, or when its parent is done.
+//
+// Returns:
+//   - context.Context: The root context.
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Signal returns the signal that started the shutdown.
+//
+// Returns:
+//   - os.Signal: The signal. Nil if the shutdown was not started by a signal.
+func (l *Lifecycle) Signal() os.Signal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.sig
+}
+
+// OnShutdown registers a hook to run on shutdown. Hooks run one at a time, in
+// the reverse order of their registration, so that resources are released in
+// the reverse order of their acquisition. Hooks cannot be registered once the
+// shutdown started.
+//
+// Parameters:
+//   - name: The name of the hook, used in errors.
+//   - fn: The hook. It receives a context that is done at the deadline.
+//
+// Returns:
+//   - error: An error if the hook could not be registered.
+//
+// Errors:
+//   - *errors.Err: If the hook is nil or the shutdown already started.
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) error {
+	if fn == nil {
+		return gers.NewErrNilParameter("fn")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stopping {
+		return gerr.New(gers.OperationFail, "shutdown already started")
+	}
+
+	l.hooks = append(l.hooks, shutdown_hook{
+		name: name,
+		fn:   fn,
+	})
+
+	return nil
+}
+
+// Wait blocks until the root context is done and then shuts down.
+//
+// Returns:
+//   - error: The error returned by Shutdown.
+func (l *Lifecycle) Wait() error {
+	<-l.ctx.Done()
+
+	return l.Shutdown()
+}
+
+// Shutdown cancels the root context and runs the shutdown hooks, then stops
+// trapping signals. Calling Shutdown more than once returns the same error.
+//
+// Returns:
+//   - error: An error if some hooks failed or the deadline expired.
+//
+// Errors:
+//   - *ErrHook: For each hook that failed, joined with errors.Join.
+//   - *ErrShutdownTimeout: If the hooks did not complete before the deadline.
+//     Hooks that did not run yet are skipped.
+func (l *Lifecycle) Shutdown() error {
+	l.shutdown_once.Do(func() {
+		// A signal received from now on forces the exit.
+		l.mu.Lock()
+		l.stopping = true
+		l.mu.Unlock()
+
+		l.cancel()
+		l.err = l.run_hooks()
+
+		signal.Stop(l.signals)
+		close(l.stop)
+	})
+
+	return l.err
+}
+
+// run_hooks runs the shutdown hooks in reverse order.
+//
+// Returns:
+//   - error: An error if some hooks failed or the deadline expired.
+func (l *Lifecycle) run_hooks() error {
+	l.mu.Lock()
+	hooks := l.hooks
+	l.hooks = nil
+	l.mu.Unlock()
+
+	ctx := context.Background()
+
+	if l.settings.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, l.settings.timeout)
+		defer cancel()
+	}
+
+	var errs []error
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+
+		done := make(chan error, 1)
+
+		go func() {
+			done <- hook.fn(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, NewErrHook(hook.name, err))
+			}
+		case <-ctx.Done():
+			pending := make([]string, 0, i+1)
+
+			for j := i; j >= 0; j-- {
+				pending = append(pending, hooks[j].name)
+			}
+
+			errs = append(errs, NewErrShutdownTimeout(pending))
+
+			return errors.Join(errs...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,197 @@
+//go:build unix
+
+package os
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// send_signal sends a signal to the current process.
+func send_signal(t *testing.T, sig syscall.Signal) {
+	t.Helper()
+
+	err := syscall.Kill(os.Getpid(), sig)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+}
+
+// TestLifecycleSignal tests that SIGTERM cancels the root context and that the
+// hooks run in reverse order.
+func TestLifecycleSignal(t *testing.T) {
+	l := NewLifecycle(context.Background())
+
+	var order []string
+
+	for _, name := range []string{"db", "cache", "server"} {
+		_ = l.OnShutdown(name, func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	send_signal(t, syscall.SIGTERM)
+
+	select {
+	case <-l.Context().Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the context to be cancelled")
+	}
+
+	err := l.Wait()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if l.Signal() != syscall.SIGTERM {
+		t.Errorf("expected %v, got %v instead", syscall.SIGTERM, l.Signal())
+	}
+
+	expected := []string{"server", "cache", "db"}
+	if !slices.Equal(order, expected) {
+		t.Errorf("expected %v, got %v instead", expected, order)
+	}
+	err = l.OnShutdown("late", func(ctx context.Context) error {
+		return nil
+	})
+	if err == nil {
+		t.Errorf("expected an error for a hook registered after the shutdown")
+	}
+}
+
+// TestLifecycleForceExit tests that a second signal forces the exit.
+func TestLifecycleForceExit(t *testing.T) {
+	codes := make(chan int, 1)
+
+	l := NewLifecycle(context.Background(), WithExit(func(code int) {
+		codes <- code
+	}))
+
+	defer l.Shutdown()
+
+	send_signal(t, syscall.SIGINT)
+	<-l.Context().Done()
+
+	send_signal(t, syscall.SIGINT)
+
+	select {
+	case code := <-codes:
+		if code != 130 {
+			t.Errorf("expected %d, got %d instead", 130, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the exit to be forced")
+	}
+}
+
+// TestLifecycleShutdownForceExit tests that a signal received while Shutdown
+// runs forces the exit.
+func TestLifecycleShutdownForceExit(t *testing.T) {
+	codes := make(chan int, 1)
+
+	l := NewLifecycle(context.Background(), WithShutdownTimeout(time.Second), WithExit(func(code int) {
+		codes <- code
+	}))
+
+	started := make(chan struct{})
+
+	_ = l.OnShutdown("stuck", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		_ = l.Shutdown()
+	}()
+
+	<-started
+
+	send_signal(t, syscall.SIGTERM)
+
+	select {
+	case code := <-codes:
+		if code != 143 {
+			t.Errorf("expected %d, got %d instead", 143, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the exit to be forced")
+	}
+
+	if l.Signal() != nil {
+		t.Errorf("expected no signal, got %v instead", l.Signal())
+	}
+
+	<-done
+}
+
+// TestLifecycleReload tests that SIGHUP calls the reload function without
+// shutting down.
+func TestLifecycleReload(t *testing.T) {
+	reloads := make(chan struct{}, 1)
+
+	l := NewLifecycle(context.Background(), WithReload(func() {
+		reloads <- struct{}{}
+	}))
+
+	defer l.Shutdown()
+
+	send_signal(t, syscall.SIGHUP)
+
+	select {
+	case <-reloads:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a reload")
+	}
+
+	if l.Context().Err() != nil {
+		t.Errorf("expected no error, got %v instead", l.Context().Err())
+	}
+}
+
+// TestLifecycleTimeout tests that hooks that do not complete before the
+// deadline are reported.
+func TestLifecycleTimeout(t *testing.T) {
+	l := NewLifecycle(context.Background(), WithShutdownTimeout(50*time.Millisecond))
+
+	failure := errors.New("failure")
+
+	_ = l.OnShutdown("first", func(ctx context.Context) error {
+		return nil
+	})
+
+	_ = l.OnShutdown("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	_ = l.OnShutdown("failing", func(ctx context.Context) error {
+		return failure
+	})
+
+	err := l.Shutdown()
+
+	if !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %v instead", failure, err)
+	}
+
+	var timeout *ErrShutdownTimeout
+
+	if !errors.As(err, &timeout) {
+		t.Fatalf("expected a timeout, got %v instead", err)
+	}
+
+	expected := []string{"stuck", "first"}
+	if !slices.Equal(timeout.Pending, expected) {
+		t.Errorf("expected %v, got %v instead", expected, timeout.Pending)
+	}
+}
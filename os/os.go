@@ -1,44 +1,18 @@
 package os
 
 import (
-	"fmt"
 	"os"
-	"os/exec"
-	"runtime"
-)
 
-var (
-	// clear is a map of the clear functions for each platform.
-	clear map[string]func()
+	gctrm "github.com/PlayerR9/go-commons/os/terminal"
 )
 
-func init() {
-	clear = make(map[string]func())
-
-	clear["linux"] = func() {
-		cmd := exec.Command("clear")
-		cmd.Stdout = os.Stdout
-		cmd.Run()
-	}
-
-	clear["windows"] = func() {
-		cmd := exec.Command("cmd", "/c", "cls")
-		cmd.Stdout = os.Stdout
-		cmd.Run()
-	}
-}
-
-// ClearScreen clears the screen.
+// ClearScreen clears the screen by writing the "clear" capability of the
+// terminal named by $TERM to the standard output. If $TERM is unset or
+// unknown, the ANSI sequence is written.
 //
 // Returns:
-//   - error: An error if the platform is not supported.
+//   - error: An error if the terminal cannot clear the screen, such as a dumb
+//     terminal, or the capability could not be written.
 func ClearScreen() error {
-	f, ok := clear[runtime.GOOS]
-	if !ok {
-		return fmt.Errorf("platform %q is yet to be supported by this clear screen function", runtime.GOOS)
-	}
-
-	f()
-
-	return nil
+	return gctrm.NewWithTerminfo(os.Stdout, gctrm.FromEnv()).ClearScreen()
 }
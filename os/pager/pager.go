@@ -0,0 +1,371 @@
+// Package pager provides a less-like pager for output that does not fit on
+// one screen.
+package pager
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	gctrm "github.com/PlayerR9/go-commons/os/terminal"
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+// Option is a type that defines an option of the pager.
+//
+// Parameters:
+//   - s: The settings to modify.
+type Option func(s *settings)
+
+// settings is the settings of the pager.
+type settings struct {
+	// in is the input the keys are read from. Nil for the default.
+	in io.Reader
+
+	// info is the description of the terminal. Nil for $TERM.
+	info *gctrm.Terminfo
+
+	// interactive forces the pager on or off, if not nil.
+	interactive *bool
+
+	// cols is the number of columns of the screen. 0 to query the terminal.
+	cols int
+
+	// rows is the number of rows of the screen. 0 to query the terminal.
+	rows int
+
+	// chop is true if long lines are scrolled horizontally instead of being
+	// wrapped.
+	chop bool
+
+	// use_env is true if $PAGER is honoured.
+	use_env bool
+}
+
+// WithInput sets the input the keys are read from. By default, the standard
+// input is used if it is a terminal and /dev/tty otherwise, so that piped
+// programs can still be paged.
+//
+// Parameters:
+//   - in: The input.
+//
+// Returns:
+//   - Option: The option.
+func WithInput(in io.Reader) Option {
+	return func(s *settings) {
+		s.in = in
+	}
+}
+
+// WithTerminfo sets the description of the terminal. By default, the
+// description of the terminal named by $TERM is used.
+//
+// Parameters:
+//   - info: The description. If nil, generic ANSI escape sequences are used.
+//
+// Returns:
+//   - Option: The option.
+func WithTerminfo(info *gctrm.Terminfo) Option {
+	return func(s *settings) {
+		if info == nil {
+			info = gctrm.ANSI()
+		}
+
+		s.info = info
+	}
+}
+
+// WithInteractive forces the pager on or off. By default, the pager is only
+// used when the output is a terminal.
+//
+// Parameters:
+//   - interactive: True to force the pager on, false to pass the output
+//     through.
+//
+// Returns:
+//   - Option: The option.
+func WithInteractive(interactive bool) Option {
+	return func(s *settings) {
+		s.interactive = &interactive
+	}
+}
+
+// WithSize sets the size of the screen. By default, the size of the terminal
+// is used.
+//
+// Parameters:
+//   - cols: The number of columns.
+//   - rows: The number of rows, including the status line.
+//
+// Returns:
+//   - Option: The option.
+func WithSize(cols, rows int) Option {
+	return func(s *settings) {
+		s.cols = max(cols, 1)
+		s.rows = max(rows, 2)
+	}
+}
+
+// WithHorizontalScroll starts the pager in horizontal-scroll mode, where long
+// lines are cut at the edge of the screen and the Left and Right keys scroll
+// them, which suits wide tables. By default, long lines are wrapped. The S key
+// toggles the mode.
+//
+// Returns:
+//   - Option: The option.
+func WithHorizontalScroll() Option {
+	return func(s *settings) {
+		s.chop = true
+	}
+}
+
+// WithPagerEnv makes the pager run the program named by $PAGER, if it is set,
+// instead of the built-in pager. The built-in pager is used if the program
+// fails to start.
+//
+// Returns:
+//   - Option: The option.
+func WithPagerEnv() Option {
+	return func(s *settings) {
+		s.use_env = true
+	}
+}
+
+// PageString pages a text.
+//
+// Parameters:
+//   - w: The output.
+//   - text: The text. A trailing newline is ignored.
+//   - opts: The options of the pager.
+//
+// Returns:
+//   - error: An error if the text could not be written or the keys could not
+//     be read.
+//
+// This is meant for the String method of types such as runes.RuneTable or
+// strings.LineBuffer.
+func PageString(w io.Writer, text string, opts ...Option) error {
+	text = strings.TrimSuffix(text, "\n")
+
+	return Page(w, strings.Split(text, "\n"), opts...)
+}
+
+// Page pages lines. The lines are written as is, without paging, if the
+// output is not a terminal or if they fit on one screen.
+//
+// Parameters:
+//   - w: The output.
+//   - lines: The lines, without line endings.
+//   - opts: The options of the pager.
+//
+// Returns:
+//   - error: An error if the lines could not be written or the keys could not
+//     be read.
+//
+// Keys:
+//   - q, Ctrl+C: Quit.
+//   - j, Down, Enter: Scroll one line down. k, Up: one line up.
+//   - Space, f, PageDown: Scroll one page down. b, PageUp: one page up.
+//   - d, u: Scroll half a page down or up.
+//   - g, Home: Go to the top. G, End: go to the bottom.
+//   - /: Search forward. n, N: Go to the next or previous match.
+//   - Left, Right: Scroll horizontally in horizontal-scroll mode.
+//   - S: Toggle horizontal-scroll mode.
+func Page(w io.Writer, lines []string, opts ...Option) error {
+	var s settings
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if s.info == nil {
+		s.info = gctrm.FromEnv()
+	}
+
+	term := gctrm.NewWithTerminfo(w, s.info)
+
+	interactive := term.IsTTY()
+	if s.interactive != nil {
+		interactive = *s.interactive
+	}
+
+	expanded := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		expanded = append(expanded, expand_tabs(line))
+	}
+
+	if !interactive {
+		return pass_through(w, expanded)
+	}
+
+	if s.cols == 0 || s.rows == 0 {
+		cols, rows, err := term.Size()
+		if err != nil || cols < 1 || rows < 2 {
+			return pass_through(w, expanded)
+		}
+
+		s.cols, s.rows = cols, rows
+	}
+
+	if fits(expanded, s.cols, s.rows-1) {
+		return pass_through(w, expanded)
+	}
+
+	if s.use_env && run_env_pager(w, expanded) {
+		return nil
+	}
+
+	in := s.in
+
+	if in == nil {
+		f, ok := open_tty()
+		if !ok {
+			return pass_through(w, expanded)
+		}
+
+		defer func() {
+			if f != os.Stdin {
+				f.Close()
+			}
+		}()
+
+		in = f
+	}
+
+	pg := new_pager(expanded, s.cols, s.rows, s.chop)
+
+	f, ok := in.(interface{ Fd() uintptr })
+	if !ok || !gctrm.IsTerminal(f.Fd()) {
+		return pg.run(term, gctrm.NewKeyReader(in))
+	}
+
+	return gctrm.WithMode(f.Fd(), gctrm.ModeRaw, func() error {
+		return pg.run(term, gctrm.NewKeyReader(in))
+	})
+}
+
+// open_tty opens the terminal the keys are read from.
+//
+// Returns:
+//   - *os.File: The standard input if it is a terminal, /dev/tty otherwise.
+//   - bool: True if a terminal could be opened, false otherwise.
+func open_tty() (*os.File, bool) {
+	if gctrm.IsTerminal(os.Stdin.Fd()) {
+		return os.Stdin, true
+	}
+
+	f, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, false
+	}
+
+	return f, true
+}
+
+// pass_through writes lines without paging.
+//
+// Parameters:
+//   - w: The output.
+//   - lines: The lines.
+//
+// Returns:
+//   - error: An error if the lines could not be written.
+func pass_through(w io.Writer, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return gcstr.Write(w, strings.Join(lines, "\n")+"\n")
+}
+
+// fits checks whether lines fit on one screen.
+//
+// Parameters:
+//   - lines: The lines.
+//   - cols: The number of columns.
+//   - rows: The number of rows available for the lines.
+//
+// Returns:
+//   - bool: True if the lines fit, false otherwise.
+func fits(lines []string, cols, rows int) bool {
+	if len(lines) > rows {
+		return false
+	}
+
+	for _, line := range lines {
+		if len([]rune(line)) > cols {
+			return false
+		}
+	}
+
+	return true
+}
+
+// expand_tabs replaces the tabs of a line with spaces, with tab stops every 8
+// columns.
+//
+// Parameters:
+//   - line: The line.
+//
+// Returns:
+//   - string: The line without tabs.
+func expand_tabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var builder strings.Builder
+
+	var col int
+
+	for _, r := range line {
+		if r != '\t' {
+			builder.WriteRune(r)
+			col++
+
+			continue
+		}
+
+		n := 8 - col%8
+		builder.WriteString(strings.Repeat(" ", n))
+		col += n
+	}
+
+	return builder.String()
+}
+
+// run_env_pager runs the program named by $PAGER.
+//
+// Parameters:
+//   - w: The output. It must be a file for the program to write to it.
+//   - lines: The lines.
+//
+// Returns:
+//   - bool: True if the program ran, false if it is unset or could not start.
+func run_env_pager(w io.Writer, lines []string) bool {
+	pager := strings.TrimSpace(os.Getenv("PAGER"))
+	if pager == "" {
+		return false
+	}
+
+	out, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Start()
+	if err != nil {
+		return false
+	}
+
+	_ = cmd.Wait()
+
+	return true
+}
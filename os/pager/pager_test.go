@@ -0,0 +1,165 @@
+package pager
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	gctrm "github.com/PlayerR9/go-commons/os/terminal"
+)
+
+// numbered returns n lines named "line 1" to "line n".
+func numbered(n int) []string {
+	lines := make([]string, 0, n)
+
+	for i := 1; i <= n; i++ {
+		lines = append(lines, "line "+strconv.Itoa(i))
+	}
+
+	return lines
+}
+
+// feed feeds keys to a pager.
+func feed(pg *pager, input string) {
+	for key, err := range gctrm.NewKeyReader(strings.NewReader(input)).Keys() {
+		if err != nil {
+			break
+		}
+
+		pg.handle_key(key)
+	}
+}
+
+// top_text returns the text of the first row shown.
+func top_text(pg *pager) string {
+	return pg.render(pg.visible()[0], nil, "", "")
+}
+
+// TestPassThrough tests that the lines are written as is when they are not
+// paged.
+func TestPassThrough(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := PageString(&buf, "a\tb\nc\n", WithInteractive(false))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if buf.String() != "a       b\nc\n" {
+		t.Errorf("expected %q, got %q instead", "a       b\nc\n", buf.String())
+	}
+
+	buf.Reset()
+
+	err = Page(&buf, numbered(3), WithInteractive(true), WithSize(80, 10), WithTerminfo(nil))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if buf.String() != "line 1\nline 2\nline 3\n" {
+		t.Errorf("expected the lines to fit, got %q instead", buf.String())
+	}
+}
+
+// TestScroll tests the scrolling keys.
+func TestScroll(t *testing.T) {
+	pg := new_pager(numbered(100), 20, 6, false)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"jj", "line 3"},
+		{"k", "line 2"},
+		{" ", "line 7"},
+		{"b", "line 2"},
+		{"d", "line 4"},
+		{"G", "line 96"},
+		{"j", "line 96"},
+		{"g", "line 1"},
+		{"\x1b[6~", "line 6"},
+	}
+
+	for _, test := range tests {
+		feed(pg, test.input)
+
+		if got := top_text(pg); got != test.expected {
+			t.Errorf("%q: expected %q, got %q instead", test.input, test.expected, got)
+		}
+	}
+}
+
+// TestSearch tests searching and highlighting.
+func TestSearch(t *testing.T) {
+	pg := new_pager(numbered(100), 20, 6, false)
+
+	feed(pg, "/LINE 5\r")
+	if pg.message != "Pattern not found" {
+		t.Errorf("expected the search to be case-sensitive, got %q instead", pg.message)
+	}
+
+	feed(pg, "/line 5\r")
+	if got := top_text(pg); got != "line 5" {
+		t.Errorf("expected %q, got %q instead", "line 5", got)
+	}
+
+	feed(pg, "n")
+	if got := top_text(pg); got != "line 50" {
+		t.Errorf("expected %q, got %q instead", "line 50", got)
+	}
+
+	feed(pg, "N")
+	if got := top_text(pg); got != "line 5" {
+		t.Errorf("expected %q, got %q instead", "line 5", got)
+	}
+
+	got := pg.render(pg.visible()[0], pg.matcher(), "[", "]")
+	if got != "[line 5]" {
+		t.Errorf("expected %q, got %q instead", "[line 5]", got)
+	}
+}
+
+// TestHorizontalScroll tests wrapping and horizontal-scroll mode.
+func TestHorizontalScroll(t *testing.T) {
+	wide := strings.Repeat("0123456789", 5)
+
+	pg := new_pager([]string{wide, "short"}, 20, 4, false)
+	if len(pg.rows) != 4 {
+		t.Errorf("expected %d rows, got %d instead", 4, len(pg.rows))
+	}
+
+	feed(pg, "S\x1b[C")
+	if len(pg.rows) != 2 {
+		t.Errorf("expected %d rows, got %d instead", 2, len(pg.rows))
+	}
+
+	if got := top_text(pg); got != wide[10:30] {
+		t.Errorf("expected %q, got %q instead", wide[10:30], got)
+	}
+
+	feed(pg, "\x1b[C\x1b[C\x1b[C")
+	if got := top_text(pg); got != wide[30:] {
+		t.Errorf("expected %q, got %q instead", wide[30:], got)
+	}
+}
+
+// TestRun tests the pager end to end over a byte stream.
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Page(&buf, numbered(50), WithInteractive(true), WithSize(20, 6), WithTerminfo(nil), WithInput(strings.NewReader("jq")))
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "\x1b[?1049h") || !strings.HasSuffix(out, "\x1b[?1049l") {
+		t.Errorf("expected the alternate screen to be used, got %q instead", out)
+	}
+
+	if !strings.Contains(out, "line 6\x1b[K\r\n\x1b[7mlines 2-6/50 12%") {
+		t.Errorf("expected the second screen, got %q instead", out)
+	}
+}
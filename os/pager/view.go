@@ -0,0 +1,542 @@
+package pager
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	gctrm "github.com/PlayerR9/go-commons/os/terminal"
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+// display_row is a row of the screen.
+type display_row struct {
+	// line is the index of the line shown in the row.
+	line int
+
+	// start is the index of the first rune of the line shown in the row.
+	start int
+
+	// end is the index after the last rune of the line shown in the row.
+	end int
+}
+
+// pager is the state of the built-in pager.
+type pager struct {
+	// lines are the lines being paged.
+	lines [][]rune
+
+	// cols is the number of columns of the screen.
+	cols int
+
+	// height is the number of rows available for the lines.
+	height int
+
+	// chop is true in horizontal-scroll mode.
+	chop bool
+
+	// rows are the rows the lines are laid out on.
+	rows []display_row
+
+	// top is the index of the first row shown.
+	top int
+
+	// left is the index of the first column shown in horizontal-scroll mode.
+	left int
+
+	// width is the length of the longest line.
+	width int
+
+	// pattern is the last searched pattern.
+	pattern string
+
+	// searching is true while the pattern is being typed.
+	searching bool
+
+	// query is the pattern being typed.
+	query []rune
+
+	// found is the index of the line of the last match. -1 if there is none.
+	found int
+
+	// message is a message shown in the status line until the next key.
+	message string
+}
+
+// new_pager creates a new pager.
+//
+// Parameters:
+//   - lines: The lines being paged.
+//   - cols: The number of columns of the screen.
+//   - rows: The number of rows of the screen, including the status line.
+//   - chop: True to start in horizontal-scroll mode.
+//
+// Returns:
+//   - *pager: The new pager. Never returns nil.
+func new_pager(lines []string, cols, rows int, chop bool) *pager {
+	pg := &pager{
+		lines:  make([][]rune, 0, len(lines)),
+		cols:   cols,
+		height: rows - 1,
+		chop:   chop,
+		found:  -1,
+	}
+
+	for _, line := range lines {
+		runes := []rune(line)
+
+		pg.lines = append(pg.lines, runes)
+		pg.width = max(pg.width, len(runes))
+	}
+
+	pg.layout()
+
+	return pg
+}
+
+// layout lays the lines out on rows, keeping the first line shown at the top.
+func (pg *pager) layout() {
+	var line int
+
+	if pg.top < len(pg.rows) {
+		line = pg.rows[pg.top].line
+	}
+
+	pg.rows = pg.rows[:0]
+
+	for i, runes := range pg.lines {
+		if pg.chop || len(runes) <= pg.cols {
+			pg.rows = append(pg.rows, display_row{line: i, end: len(runes)})
+			continue
+		}
+
+		for start := 0; start < len(runes); start += pg.cols {
+			pg.rows = append(pg.rows, display_row{
+				line:  i,
+				start: start,
+				end:   min(start+pg.cols, len(runes)),
+			})
+		}
+	}
+
+	pg.left = 0
+	pg.go_to_line(line)
+}
+
+// max_top returns the greatest index of the first row shown.
+//
+// Returns:
+//   - int: The index.
+func (pg pager) max_top() int {
+	return max(len(pg.rows)-pg.height, 0)
+}
+
+// scroll scrolls vertically.
+//
+// Parameters:
+//   - delta: The number of rows to scroll by. Positive values scroll down.
+func (pg *pager) scroll(delta int) {
+	pg.top = min(max(pg.top+delta, 0), pg.max_top())
+}
+
+// scroll_left scrolls horizontally. Does nothing unless in horizontal-scroll
+// mode.
+//
+// Parameters:
+//   - delta: The number of columns to scroll by. Positive values scroll right.
+func (pg *pager) scroll_left(delta int) {
+	if !pg.chop {
+		return
+	}
+
+	pg.left = min(max(pg.left+delta, 0), max(pg.width-pg.cols, 0))
+}
+
+// go_to_line scrolls so that the first row of a line is at the top.
+//
+// Parameters:
+//   - line: The index of the line.
+func (pg *pager) go_to_line(line int) {
+	for i, row := range pg.rows {
+		if row.line >= line {
+			pg.top = min(i, pg.max_top())
+			return
+		}
+	}
+
+	pg.top = pg.max_top()
+}
+
+// matcher returns the function that finds the pattern in a line. The search
+// ignores case unless the pattern has an uppercase letter.
+//
+// Returns:
+//   - func(line []rune) [][2]int: The function that returns the rune ranges
+//     of the matches in a line. Nil if there is no pattern.
+func (pg pager) matcher() func(line []rune) [][2]int {
+	if pg.pattern == "" {
+		return nil
+	}
+
+	pattern := []rune(pg.pattern)
+
+	fold := strings.IndexFunc(pg.pattern, unicode.IsUpper) < 0
+	if fold {
+		pattern = []rune(strings.ToLower(pg.pattern))
+	}
+
+	return func(line []rune) [][2]int {
+		var ranges [][2]int
+
+		for i := 0; i+len(pattern) <= len(line); i++ {
+			ok := true
+
+			for j, r := range pattern {
+				c := line[i+j]
+				if fold {
+					c = unicode.ToLower(c)
+				}
+
+				if c != r {
+					ok = false
+					break
+				}
+			}
+
+			if ok {
+				ranges = append(ranges, [2]int{i, i + len(pattern)})
+				i += len(pattern) - 1
+			}
+		}
+
+		return ranges
+	}
+}
+
+// search goes to the next line, in the given direction, that matches the
+// pattern.
+//
+// Parameters:
+//   - forward: True to search down, false to search up.
+//   - skip: True to search from the line after the last match, false to
+//     search from the line shown at the top.
+func (pg *pager) search(forward, skip bool) {
+	match := pg.matcher()
+	if match == nil {
+		pg.message = "No previous search pattern"
+		return
+	}
+
+	var from int
+
+	if skip && pg.found >= 0 {
+		from = pg.found
+	} else if pg.top < len(pg.rows) {
+		from = pg.rows[pg.top].line
+	}
+
+	step := 1
+	if !forward {
+		step = -1
+	}
+
+	if skip {
+		from += step
+	}
+
+	for i := from; i >= 0 && i < len(pg.lines); i += step {
+		if len(match(pg.lines[i])) > 0 {
+			pg.found = i
+			pg.go_to_line(i)
+
+			return
+		}
+	}
+
+	pg.message = "Pattern not found"
+}
+
+// handle_key handles a key press.
+//
+// Parameters:
+//   - key: The key press.
+//
+// Returns:
+//   - bool: True if the pager must quit, false otherwise.
+func (pg *pager) handle_key(key gctrm.KeyEvent) bool {
+	pg.message = ""
+
+	if pg.searching {
+		pg.handle_search_key(key)
+		return false
+	}
+
+	r := key.Rune
+	if key.Code != gctrm.KeyRune || key.Mod&(gctrm.ModAlt|gctrm.ModCtrl) != 0 {
+		r = 0
+	}
+
+	ctrl := key.Code == gctrm.KeyRune && key.Mod == gctrm.ModCtrl
+	half := max(pg.height/2, 1)
+
+	switch {
+	case r == 'q' || r == 'Q' || (ctrl && key.Rune == 'c'):
+		return true
+	case r == 'j' || r == 'e' || key.Code == gctrm.KeyDown || key.Code == gctrm.KeyEnter || (ctrl && key.Rune == 'n'):
+		pg.scroll(1)
+	case r == 'k' || r == 'y' || key.Code == gctrm.KeyUp || (ctrl && key.Rune == 'p'):
+		pg.scroll(-1)
+	case r == ' ' || r == 'f' || key.Code == gctrm.KeyPageDown || (ctrl && key.Rune == 'f'):
+		pg.scroll(pg.height)
+	case r == 'b' || key.Code == gctrm.KeyPageUp || (ctrl && key.Rune == 'b'):
+		pg.scroll(-pg.height)
+	case r == 'd' || (ctrl && key.Rune == 'd'):
+		pg.scroll(half)
+	case r == 'u' || (ctrl && key.Rune == 'u'):
+		pg.scroll(-half)
+	case r == 'g' || r == '<' || key.Code == gctrm.KeyHome:
+		pg.top = 0
+	case r == 'G' || r == '>' || key.Code == gctrm.KeyEnd:
+		pg.top = pg.max_top()
+	case key.Code == gctrm.KeyRight:
+		pg.scroll_left(max(pg.cols/2, 1))
+	case key.Code == gctrm.KeyLeft:
+		pg.scroll_left(-max(pg.cols/2, 1))
+	case r == 'S':
+		pg.chop = !pg.chop
+		pg.layout()
+	case r == '/':
+		pg.searching = true
+		pg.query = pg.query[:0]
+	case r == 'n':
+		pg.search(true, true)
+	case r == 'N':
+		pg.search(false, true)
+	}
+
+	return false
+}
+
+// handle_search_key handles a key press while the pattern is being typed.
+//
+// Parameters:
+//   - key: The key press.
+func (pg *pager) handle_search_key(key gctrm.KeyEvent) {
+	switch {
+	case key.Code == gctrm.KeyEnter:
+		pg.searching = false
+
+		if len(pg.query) > 0 {
+			pg.pattern = string(pg.query)
+		}
+
+		pg.search(true, false)
+	case key.Code == gctrm.KeyEscape || (key.Code == gctrm.KeyRune && key.Mod == gctrm.ModCtrl && key.Rune == 'c'):
+		pg.searching = false
+	case key.Code == gctrm.KeyBackspace:
+		if len(pg.query) == 0 {
+			pg.searching = false
+		} else {
+			pg.query = pg.query[:len(pg.query)-1]
+		}
+	case key.Code == gctrm.KeyRune && key.Mod == 0:
+		pg.query = append(pg.query, key.Rune)
+	case key.Code == gctrm.KeyPaste:
+		pg.query = append(pg.query, []rune(key.Text)...)
+	}
+}
+
+// visible returns the part of the lines shown on the screen.
+//
+// Returns:
+//   - []display_row: The rows shown, with the ranges of runes that are shown.
+func (pg pager) visible() []display_row {
+	end := min(pg.top+pg.height, len(pg.rows))
+
+	rows := make([]display_row, 0, end-pg.top)
+
+	for _, row := range pg.rows[pg.top:end] {
+		if pg.chop {
+			row.start = min(pg.left, row.end)
+			row.end = min(pg.left+pg.cols, row.end)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// status returns the text of the status line.
+//
+// Returns:
+//   - string: The status line.
+func (pg pager) status() string {
+	if pg.searching {
+		return "/" + string(pg.query)
+	} else if pg.message != "" {
+		return pg.message
+	}
+
+	if len(pg.rows) == 0 {
+		return "(empty)"
+	}
+
+	first := pg.rows[pg.top].line + 1
+	last := pg.rows[min(pg.top+pg.height, len(pg.rows))-1].line + 1
+
+	var builder strings.Builder
+
+	builder.WriteString("lines ")
+	builder.WriteString(strconv.Itoa(first))
+	builder.WriteByte('-')
+	builder.WriteString(strconv.Itoa(last))
+	builder.WriteByte('/')
+	builder.WriteString(strconv.Itoa(len(pg.lines)))
+
+	if pg.top >= pg.max_top() {
+		builder.WriteString(" (END)")
+	} else {
+		builder.WriteString(" ")
+		builder.WriteString(strconv.Itoa(100 * last / len(pg.lines)))
+		builder.WriteString("%")
+	}
+
+	if pg.chop && pg.left > 0 {
+		builder.WriteString(" col ")
+		builder.WriteString(strconv.Itoa(pg.left + 1))
+	}
+
+	return builder.String()
+}
+
+// render renders a row, highlighting the matches of the pattern.
+//
+// Parameters:
+//   - row: The row.
+//   - match: The function that finds the matches. Nil for no highlight.
+//   - on: The sequence that starts a highlight.
+//   - off: The sequence that ends a highlight.
+//
+// Returns:
+//   - string: The rendered row.
+func (pg pager) render(row display_row, match func(line []rune) [][2]int, on, off string) string {
+	line := pg.lines[row.line]
+
+	if match == nil || on == "" {
+		return string(line[row.start:row.end])
+	}
+
+	var builder strings.Builder
+
+	pos := row.start
+
+	for _, m := range match(line) {
+		from := max(m[0], row.start)
+		to := min(m[1], row.end)
+
+		if from >= to {
+			continue
+		}
+
+		builder.WriteString(string(line[pos:from]))
+		builder.WriteString(on)
+		builder.WriteString(string(line[from:to]))
+		builder.WriteString(off)
+
+		pos = to
+	}
+
+	builder.WriteString(string(line[pos:row.end]))
+
+	return builder.String()
+}
+
+// draw draws the screen.
+//
+// Parameters:
+//   - term: The terminal.
+//
+// Returns:
+//   - error: An error if the screen could not be written.
+func (pg pager) draw(term *gctrm.Terminal) error {
+	info := term.Terminfo()
+
+	on, _ := info.String("smso")
+	off, _ := info.String("rmso")
+
+	el, _ := info.String("el")
+
+	match := pg.matcher()
+
+	var builder strings.Builder
+
+	rows := pg.visible()
+
+	for i := 0; i < pg.height; i++ {
+		if i < len(rows) {
+			builder.WriteString(pg.render(rows[i], match, on, off))
+		} else {
+			builder.WriteString("~")
+		}
+
+		builder.WriteString(el)
+		builder.WriteString("\r\n")
+	}
+
+	builder.WriteString(on)
+	builder.WriteString(pg.status())
+	builder.WriteString(off)
+
+	err := term.MoveTo(0, 0)
+	if err != nil {
+		return err
+	}
+
+	err = gcstr.Write(term.Writer(), builder.String())
+	if err != nil {
+		return err
+	}
+
+	_ = term.ClearToEndOfLine()
+
+	return nil
+}
+
+// run runs the pager until the user quits or the input is exhausted.
+//
+// Parameters:
+//   - term: The terminal.
+//   - keys: The key reader.
+//
+// Returns:
+//   - error: An error if the screen could not be written or the keys could not
+//     be read.
+func (pg *pager) run(term *gctrm.Terminal, keys *gctrm.KeyReader) error {
+	_ = term.EnterAltScreen()
+	_ = term.HideCursor()
+
+	defer func() {
+		_ = term.ShowCursor()
+		_ = term.ExitAltScreen()
+	}()
+
+	for {
+		err := pg.draw(term)
+		if err != nil {
+			return err
+		}
+
+		key, err := keys.ReadKey()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if pg.handle_key(key) {
+			return nil
+		}
+	}
+}
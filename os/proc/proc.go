@@ -0,0 +1,568 @@
+// Package proc reads the resource usage of a process from the /proc file
+// system of Linux, so that long-running jobs can report their own usage.
+package proc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+const (
+	// clock_ticks is the number of clock ticks per second in which CPU times
+	// are expressed. Linux fixes it to 100 on every architecture it exposes
+	// to user space.
+	clock_ticks = 100
+)
+
+// Stat is the content of /proc/<pid>/stat.
+type Stat struct {
+	// Pid is the ID of the process.
+	Pid int
+
+	// Comm is the name of the executable, truncated to 15 bytes.
+	Comm string
+
+	// State is the state of the process, such as 'R' for running or 'S' for
+	// sleeping.
+	State byte
+
+	// PPid is the ID of the parent process.
+	PPid int
+
+	// MinorFaults is the number of page faults that did not load a page from
+	// disk.
+	MinorFaults uint64
+
+	// MajorFaults is the number of page faults that loaded a page from disk.
+	MajorFaults uint64
+
+	// UserTime is the CPU time spent in user mode.
+	UserTime time.Duration
+
+	// SystemTime is the CPU time spent in kernel mode.
+	SystemTime time.Duration
+
+	// Threads is the number of threads.
+	Threads int
+
+	// StartTime is the time the process started, since the system booted.
+	StartTime time.Duration
+
+	// VirtualSize is the size of the virtual memory, in bytes.
+	VirtualSize uint64
+
+	// RSS is the resident set size, in pages.
+	RSS uint64
+}
+
+// CPUTime returns the total CPU time of the process.
+//
+// Returns:
+//   - time.Duration: The CPU time spent in both user and kernel mode.
+func (s Stat) CPUTime() time.Duration {
+	return s.UserTime + s.SystemTime
+}
+
+// ParseStat parses the content of /proc/<pid>/stat.
+//
+// Parameters:
+//   - r: The content.
+//
+// Returns:
+//   - *Stat: The parsed content. Nil if an error occurred.
+//   - error: An error if the content could not be read or is malformed.
+//
+// Errors:
+//   - *errors.Err: If the reader is nil or the content is malformed.
+//   - any error returned by the reader.
+func ParseStat(r io.Reader) (*Stat, error) {
+	if r == nil {
+		return nil, gers.NewErrNilParameter("r")
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The name is between parentheses and may itself hold spaces and
+	// parentheses, so it ends at the last closing parenthesis.
+	open := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+
+	if open < 0 || end < open {
+		return nil, gerr.New(gers.BadParameter, "stat has no command name")
+	}
+
+	// fields[0] is the third field of the file, the state.
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 22 || len(fields[0]) != 1 {
+		return nil, gerr.New(gers.BadParameter, "stat has too few fields")
+	}
+
+	p := field_parser{
+		fields: fields,
+		offset: 3,
+	}
+
+	stat := &Stat{
+		Comm:        string(data[open+1 : end]),
+		State:       fields[0][0],
+		PPid:        int(p.int(4)),
+		MinorFaults: p.uint(10),
+		MajorFaults: p.uint(12),
+		UserTime:    ticks(p.uint(14)),
+		SystemTime:  ticks(p.uint(15)),
+		Threads:     int(p.int(20)),
+		StartTime:   ticks(p.uint(22)),
+		VirtualSize: p.uint(23),
+		RSS:         p.uint(24),
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data[:open])))
+	if err != nil {
+		return nil, gerr.New(gers.BadParameter, "stat has an invalid pid")
+	} else if p.err != nil {
+		return nil, p.err
+	}
+
+	stat.Pid = pid
+
+	return stat, nil
+}
+
+// field_parser parses numbered fields, remembering the first error.
+type field_parser struct {
+	// fields are the fields.
+	fields []string
+
+	// offset is the number of the first field.
+	offset int
+
+	// err is the first error. Nil if none.
+	err error
+}
+
+// uint parses an unsigned field.
+//
+// Parameters:
+//   - n: The number of the field, as documented in proc(5).
+//
+// Returns:
+//   - uint64: The value. 0 if it is malformed.
+func (p *field_parser) uint(n int) uint64 {
+	v, err := strconv.ParseUint(p.fields[n-p.offset], 10, 64)
+	if err != nil && p.err == nil {
+		p.err = gerr.New(gers.BadParameter, "field "+strconv.Itoa(n)+" is not a number")
+	}
+
+	return v
+}
+
+// int parses a signed field.
+//
+// Parameters:
+//   - n: The number of the field, as documented in proc(5).
+//
+// Returns:
+//   - int64: The value. 0 if it is malformed.
+func (p *field_parser) int(n int) int64 {
+	v, err := strconv.ParseInt(p.fields[n-p.offset], 10, 64)
+	if err != nil && p.err == nil {
+		p.err = gerr.New(gers.BadParameter, "field "+strconv.Itoa(n)+" is not a number")
+	}
+
+	return v
+}
+
+// ticks converts clock ticks to a duration.
+//
+// Parameters:
+//   - n: The number of clock ticks.
+//
+// Returns:
+//   - time.Duration: The duration.
+func ticks(n uint64) time.Duration {
+	return time.Duration(n) * (time.Second / clock_ticks)
+}
+
+// Status is the content of /proc/<pid>/status that describes resource usage.
+// Sizes are in bytes.
+type Status struct {
+	// Name is the name of the executable.
+	Name string
+
+	// VmPeak is the peak size of the virtual memory.
+	VmPeak uint64
+
+	// VmSize is the size of the virtual memory.
+	VmSize uint64
+
+	// VmHWM is the peak resident set size.
+	VmHWM uint64
+
+	// VmRSS is the resident set size.
+	VmRSS uint64
+
+	// VmSwap is the size of the memory swapped out.
+	VmSwap uint64
+
+	// Threads is the number of threads.
+	Threads int
+
+	// FDSize is the number of file descriptor slots currently allocated.
+	FDSize int
+
+	// VoluntarySwitches is the number of voluntary context switches.
+	VoluntarySwitches uint64
+
+	// InvoluntarySwitches is the number of involuntary context switches.
+	InvoluntarySwitches uint64
+}
+
+// ParseStatus parses the content of /proc/<pid>/status. Unknown keys are
+// ignored, and keys that are absent, such as the memory sizes of kernel
+// threads, are left to 0.
+//
+// Parameters:
+//   - r: The content.
+//
+// Returns:
+//   - *Status: The parsed content. Nil if an error occurred.
+//   - error: An error if the content could not be read or is malformed.
+//
+// Errors:
+//   - *errors.Err: If the reader is nil or a known key has a malformed value.
+//   - any error returned by the reader.
+func ParseStatus(r io.Reader) (*Status, error) {
+	if r == nil {
+		return nil, gers.NewErrNilParameter("r")
+	}
+
+	status := new(Status)
+
+	sizes := map[string]*uint64{
+		"VmPeak": &status.VmPeak,
+		"VmSize": &status.VmSize,
+		"VmHWM":  &status.VmHWM,
+		"VmRSS":  &status.VmRSS,
+		"VmSwap": &status.VmSwap,
+	}
+
+	counts := map[string]*uint64{
+		"voluntary_ctxt_switches":    &status.VoluntarySwitches,
+		"nonvoluntary_ctxt_switches": &status.InvoluntarySwitches,
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		var err error
+
+		switch key {
+		case "Name":
+			status.Name = value
+		case "Threads":
+			status.Threads, err = strconv.Atoi(value)
+		case "FDSize":
+			status.FDSize, err = strconv.Atoi(value)
+		default:
+			if ptr, ok := sizes[key]; ok {
+				*ptr, err = parse_kb(value)
+			} else if ptr, ok := counts[key]; ok {
+				*ptr, err = strconv.ParseUint(value, 10, 64)
+			}
+		}
+
+		if err != nil {
+			return nil, gerr.New(gers.BadParameter, "status key "+strconv.Quote(key)+" has an invalid value")
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// parse_kb parses a size in kilobytes, such as "1388 kB".
+//
+// Parameters:
+//   - value: The size.
+//
+// Returns:
+//   - uint64: The size, in bytes.
+//   - error: An error if the size is malformed.
+func parse_kb(value string) (uint64, error) {
+	n, ok := strings.CutSuffix(value, " kB")
+	if !ok {
+		return 0, strconv.ErrSyntax
+	}
+
+	v, err := strconv.ParseUint(strings.TrimSpace(n), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return v * 1024, nil
+}
+
+// IO is the content of /proc/<pid>/io. Counts are in bytes, except for the
+// system call counts.
+type IO struct {
+	// ReadChars is the number of bytes read through system calls, including
+	// from the page cache and from pipes.
+	ReadChars uint64
+
+	// WriteChars is the number of bytes written through system calls.
+	WriteChars uint64
+
+	// ReadSyscalls is the number of read system calls.
+	ReadSyscalls uint64
+
+	// WriteSyscalls is the number of write system calls.
+	WriteSyscalls uint64
+
+	// ReadBytes is the number of bytes fetched from storage.
+	ReadBytes uint64
+
+	// WriteBytes is the number of bytes sent to storage.
+	WriteBytes uint64
+
+	// CancelledWriteBytes is the number of bytes written and then truncated
+	// before reaching storage.
+	CancelledWriteBytes uint64
+}
+
+// ParseIO parses the content of /proc/<pid>/io.
+//
+// Parameters:
+//   - r: The content.
+//
+// Returns:
+//   - *IO: The parsed content. Nil if an error occurred.
+//   - error: An error if the content could not be read or is malformed.
+//
+// Errors:
+//   - *errors.Err: If the reader is nil or a key has a malformed value.
+//   - any error returned by the reader.
+func ParseIO(r io.Reader) (*IO, error) {
+	if r == nil {
+		return nil, gers.NewErrNilParameter("r")
+	}
+
+	counters := new(IO)
+
+	fields := map[string]*uint64{
+		"rchar":                 &counters.ReadChars,
+		"wchar":                 &counters.WriteChars,
+		"syscr":                 &counters.ReadSyscalls,
+		"syscw":                 &counters.WriteSyscalls,
+		"read_bytes":            &counters.ReadBytes,
+		"write_bytes":           &counters.WriteBytes,
+		"cancelled_write_bytes": &counters.CancelledWriteBytes,
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		ptr, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, gerr.New(gers.BadParameter, "io key "+strconv.Quote(key)+" has an invalid value")
+		}
+
+		*ptr = v
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return counters, nil
+}
+
+// Process is a process whose resource usage is read from its directory in
+// /proc.
+type Process struct {
+	// dir is the directory of the process.
+	dir string
+
+	// self is true if the directory is the one of the current process, which
+	// opens a descriptor of its own when listing its descriptors.
+	self bool
+}
+
+// Self returns the current process.
+//
+// Returns:
+//   - *Process: The process. Never returns nil.
+func Self() *Process {
+	return &Process{
+		dir:  "/proc/self",
+		self: true,
+	}
+}
+
+// Pid returns a process by its ID.
+//
+// Parameters:
+//   - pid: The ID of the process.
+//
+// Returns:
+//   - *Process: The process. Never returns nil.
+func Pid(pid int) *Process {
+	return &Process{
+		dir:  filepath.Join("/proc", strconv.Itoa(pid)),
+		self: pid == os.Getpid(),
+	}
+}
+
+// FromDir returns the process described by a directory laid out like
+// /proc/<pid>, such as a copy of it.
+//
+// Parameters:
+//   - dir: The directory.
+//
+// Returns:
+//   - *Process: The process. Never returns nil.
+func FromDir(dir string) *Process {
+	return &Process{
+		dir: dir,
+	}
+}
+
+// Dir returns the directory the process is read from.
+//
+// Returns:
+//   - string: The directory.
+func (p Process) Dir() string {
+	return p.dir
+}
+
+// Stat reads the stat file of the process.
+//
+// Returns:
+//   - *Stat: The content. Nil if an error occurred.
+//   - error: An error if the file could not be read or is malformed.
+//
+// Errors:
+//   - *errors.Err: If the file is malformed.
+//   - any error returned by the file system.
+func (p Process) Stat() (*Stat, error) {
+	f, err := os.Open(filepath.Join(p.dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ParseStat(f)
+}
+
+// Status reads the status file of the process.
+//
+// Returns:
+//   - *Status: The content. Nil if an error occurred.
+//   - error: An error if the file could not be read or is malformed.
+//
+// Errors:
+//   - *errors.Err: If the file is malformed.
+//   - any error returned by the file system.
+func (p Process) Status() (*Status, error) {
+	f, err := os.Open(filepath.Join(p.dir, "status"))
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ParseStatus(f)
+}
+
+// IO reads the io file of the process. Reading it requires the permission to
+// trace the process, which some sandboxes deny even for the current process.
+//
+// Returns:
+//   - *IO: The content. Nil if an error occurred.
+//   - error: An error if the file could not be read or is malformed.
+//
+// Errors:
+//   - *errors.Err: If the file is malformed.
+//   - any error returned by the file system.
+func (p Process) IO() (*IO, error) {
+	f, err := os.Open(filepath.Join(p.dir, "io"))
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ParseIO(f)
+}
+
+// OpenFiles counts the open file descriptors of the process. For the current
+// process, the descriptor used to list them is not counted.
+//
+// Returns:
+//   - int: The number of descriptors.
+//   - error: An error if the descriptors could not be listed.
+//
+// Errors:
+//   - any error returned by the file system.
+func (p Process) OpenFiles() (int, error) {
+	d, err := os.Open(filepath.Join(p.dir, "fd"))
+	if err != nil {
+		return 0, err
+	}
+
+	defer d.Close()
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
+
+	count := len(names)
+
+	if p.self {
+		own := strconv.Itoa(int(d.Fd()))
+
+		for _, name := range names {
+			if name == own {
+				count--
+				break
+			}
+		}
+	}
+
+	return count, nil
+}
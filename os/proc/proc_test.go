@@ -0,0 +1,156 @@
+package proc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFixture tests the parsers against the fixture in testdata/job.
+func TestFixture(t *testing.T) {
+	p := FromDir("testdata/job")
+
+	stat, err := p.Stat()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected_stat := Stat{
+		Pid:         4242,
+		Comm:        "batch (job) 2",
+		State:       'S',
+		PPid:        1,
+		MinorFaults: 15230,
+		MajorFaults: 12,
+		UserTime:    12340 * time.Millisecond,
+		SystemTime:  5670 * time.Millisecond,
+		Threads:     8,
+		StartTime:   2017620 * time.Millisecond,
+		VirtualSize: 734003200,
+		RSS:         12800,
+	}
+
+	if *stat != expected_stat {
+		t.Errorf("expected %+v, got %+v instead", expected_stat, *stat)
+	}
+
+	status, err := p.Status()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected_status := Status{
+		Name:                "batch (job) 2",
+		VmPeak:              716800 * 1024,
+		VmSize:              716796 * 1024,
+		VmHWM:               61440 * 1024,
+		VmRSS:               51200 * 1024,
+		Threads:             8,
+		FDSize:              64,
+		VoluntarySwitches:   3021,
+		InvoluntarySwitches: 77,
+	}
+
+	if *status != expected_status {
+		t.Errorf("expected %+v, got %+v instead", expected_status, *status)
+	}
+
+	counters, err := p.IO()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	expected_io := IO{
+		ReadChars:     1048576,
+		WriteChars:    524288,
+		ReadSyscalls:  300,
+		WriteSyscalls: 120,
+		ReadBytes:     4096,
+		WriteBytes:    8192,
+	}
+
+	if *counters != expected_io {
+		t.Errorf("expected %+v, got %+v instead", expected_io, *counters)
+	}
+
+	fds, err := p.OpenFiles()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if fds != 5 {
+		t.Errorf("expected %d, got %d instead", 5, fds)
+	}
+}
+
+// TestParseMalformed tests that malformed content is rejected.
+func TestParseMalformed(t *testing.T) {
+	_, err := ParseStat(strings.NewReader("12 (x) S 1 2"))
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+
+	_, err = ParseStat(strings.NewReader("12 no name"))
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+
+	_, err = ParseStatus(strings.NewReader("VmRSS:\t12 MB\n"))
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+
+	_, err = ParseIO(strings.NewReader("rchar: many\n"))
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+}
+
+// TestMonitor tests that the sampler reports samples until the context is
+// done.
+func TestMonitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var samples []*Sample
+
+	err := FromDir("testdata/job").Monitor(ctx, time.Millisecond, func(s *Sample) {
+		samples = append(samples, s)
+
+		if len(samples) == 3 {
+			cancel()
+		}
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if len(samples) != 3 {
+		t.Fatalf("expected %d, got %d instead", 3, len(samples))
+	}
+
+	s := samples[0]
+
+	if s.RSS != 51200*1024 || s.Threads != 8 || s.OpenFiles != 5 || s.IO == nil {
+		t.Errorf("expected the fixture values, got %+v instead", *s)
+	}
+
+	// The fixture does not change, so no CPU time is spent between samples.
+	if samples[2].CPUUsage != 0 {
+		t.Errorf("expected %v, got %v instead", 0.0, samples[2].CPUUsage)
+	}
+}
+
+// TestSelf tests that the current process can be sampled.
+func TestSelf(t *testing.T) {
+	s, err := Self().Sample()
+	if err != nil {
+		t.Skipf("/proc is not available: %v", err)
+	}
+
+	if s.RSS == 0 || s.Threads == 0 || s.OpenFiles < 3 {
+		t.Errorf("expected a live process, got %+v instead", *s)
+	}
+}
@@ -0,0 +1,155 @@
+package proc
+
+import (
+	"context"
+	"os"
+	"time"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// Sample is a snapshot of the resource usage of a process.
+type Sample struct {
+	// Time is when the sample was taken.
+	Time time.Time
+
+	// RSS is the resident set size, in bytes.
+	RSS uint64
+
+	// PeakRSS is the peak resident set size, in bytes.
+	PeakRSS uint64
+
+	// UserTime is the CPU time spent in user mode.
+	UserTime time.Duration
+
+	// SystemTime is the CPU time spent in kernel mode.
+	SystemTime time.Duration
+
+	// CPUUsage is the CPU time spent since the previous sample divided by the
+	// time elapsed, so that 1 means one fully busy core. 0 for the first
+	// sample.
+	CPUUsage float64
+
+	// Threads is the number of threads.
+	Threads int
+
+	// OpenFiles is the number of open file descriptors.
+	OpenFiles int
+
+	// IO are the I/O counters. Nil if they could not be read.
+	IO *IO
+}
+
+// CPUTime returns the total CPU time of the process.
+//
+// Returns:
+//   - time.Duration: The CPU time spent in both user and kernel mode.
+func (s Sample) CPUTime() time.Duration {
+	return s.UserTime + s.SystemTime
+}
+
+// Sample takes a snapshot of the resource usage of the process.
+//
+// Returns:
+//   - *Sample: The snapshot. Nil if an error occurred.
+//   - error: An error if the stat, status or fd entries could not be read.
+//     The io entry is optional as it is not always readable.
+//
+// Errors:
+//   - *errors.Err: If an entry is malformed.
+//   - any error returned by the file system.
+func (p Process) Sample() (*Sample, error) {
+	stat, err := p.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := p.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	fds, err := p.OpenFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &Sample{
+		Time:       time.Now(),
+		RSS:        status.VmRSS,
+		PeakRSS:    status.VmHWM,
+		UserTime:   stat.UserTime,
+		SystemTime: stat.SystemTime,
+		Threads:    stat.Threads,
+		OpenFiles:  fds,
+	}
+
+	// Kernel threads have no memory sizes in status; stat has the RSS in
+	// pages.
+	if sample.RSS == 0 {
+		sample.RSS = stat.RSS * uint64(os.Getpagesize())
+	}
+
+	counters, err := p.IO()
+	if err == nil {
+		sample.IO = counters
+	}
+
+	return sample, nil
+}
+
+// Monitor samples the resource usage of the process at a fixed interval,
+// starting right away, until the context is done.
+//
+// Parameters:
+//   - ctx: The context.
+//   - interval: The time between two samples.
+//   - fn: The function each sample is reported to. It runs on the calling
+//     goroutine, so a slow function delays the next sample.
+//
+// Returns:
+//   - error: An error if a sample could not be taken. Nil once the context is
+//     done.
+//
+// Errors:
+//   - *errors.Err: If the context or the function is nil, or the interval is
+//     not positive.
+//   - any error returned by Sample.
+func (p Process) Monitor(ctx context.Context, interval time.Duration, fn func(s *Sample)) error {
+	if ctx == nil {
+		return gers.NewErrNilParameter("ctx")
+	} else if fn == nil {
+		return gers.NewErrNilParameter("fn")
+	} else if interval <= 0 {
+		return gers.NewErrInvalidParameter("interval must be positive")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev *Sample
+
+	for {
+		sample, err := p.Sample()
+		if err != nil {
+			return err
+		}
+
+		if prev != nil {
+			elapsed := sample.Time.Sub(prev.Time)
+			if elapsed > 0 {
+				sample.CPUUsage = float64(sample.CPUTime()-prev.CPUTime()) / float64(elapsed)
+			}
+		}
+
+		fn(sample)
+
+		prev = sample
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
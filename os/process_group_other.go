@@ -0,0 +1,14 @@
+//go:build !unix
+
+package os
+
+import (
+	"os/exec"
+)
+
+// set_process_group does nothing as process groups are yet to be supported
+// on this platform; cancelling a command only kills the command itself.
+//
+// Parameters:
+//   - cmd: The command, not yet started.
+func set_process_group(cmd *exec.Cmd) {}
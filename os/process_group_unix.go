@@ -0,0 +1,23 @@
+//go:build unix
+
+package os
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// set_process_group makes a command run in its own process group, so that
+// cancelling it also kills the processes it started.
+//
+// Parameters:
+//   - cmd: The command, not yet started.
+func set_process_group(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
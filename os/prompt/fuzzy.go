@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"cmp"
+	"slices"
+	"unicode"
+)
+
+// FuzzyScore matches a pattern against a string. The pattern matches if its
+// characters appear in the string in the same order, ignoring case.
+//
+// Parameters:
+//   - pattern: The pattern.
+//   - s: The string.
+//
+// Returns:
+//   - int: The score of the match. Consecutive characters and characters at
+//     the start of words score higher; gaps score lower.
+//   - bool: True if the pattern matches, false otherwise.
+//
+// The empty pattern matches every string with a score of 0.
+func FuzzyScore(pattern, s string) (int, bool) {
+	p := []rune(pattern)
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	var score, i int
+
+	prev_match := -2
+	prev := ' '
+
+	for j, r := range []rune(s) {
+		if i < len(p) && unicode.ToLower(r) == unicode.ToLower(p[i]) {
+			score += 1
+
+			if prev_match == j-1 {
+				score += 5
+			}
+
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) || (unicode.IsLower(prev) && unicode.IsUpper(r)) {
+				score += 3
+			}
+
+			if prev_match >= 0 {
+				score -= min(j-prev_match-1, 3)
+			}
+
+			prev_match = j
+			i++
+		}
+
+		prev = r
+	}
+
+	if i < len(p) {
+		return 0, false
+	}
+
+	return score, true
+}
+
+// FuzzyFilter returns the options that match a pattern, best matches first.
+//
+// Parameters:
+//   - pattern: The pattern.
+//   - options: The options.
+//
+// Returns:
+//   - []int: The indices of the matching options. Options with the same score
+//     keep their order.
+func FuzzyFilter(pattern string, options []string) []int {
+	type match struct {
+		idx   int
+		score int
+	}
+
+	var matches []match
+
+	for i, opt := range options {
+		score, ok := FuzzyScore(pattern, opt)
+		if ok {
+			matches = append(matches, match{idx: i, score: score})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	indices := make([]int, 0, len(matches))
+
+	for _, m := range matches {
+		indices = append(indices, m.idx)
+	}
+
+	return indices
+}
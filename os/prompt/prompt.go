@@ -0,0 +1,603 @@
+// Package prompt provides interactive terminal prompts that fall back to
+// plain line-based input when the input is not a terminal.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	gctrm "github.com/PlayerR9/go-commons/os/terminal"
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+var (
+	// Interrupted is an error that is returned when the user interrupts a
+	// prompt with Ctrl+C. Functions must return this error as is and not
+	// wrap it as callers are expected to check for this error using ==.
+	Interrupted error
+)
+
+func init() {
+	Interrupted = errors.New("prompt interrupted")
+}
+
+const (
+	// secret_mask is shown in place of the answer of a password prompt.
+	secret_mask string = "********"
+)
+
+// Option is a type that defines an option of a Prompter.
+//
+// Parameters:
+//   - s: The settings to modify.
+type Option func(s *settings)
+
+// settings is the settings of a Prompter.
+type settings struct {
+	// info is the description of the output terminal.
+	info *gctrm.Terminfo
+
+	// interactive forces the mode of the prompts, if not nil.
+	interactive *bool
+
+	// page_size is the number of options shown at once by the selects.
+	page_size int
+}
+
+// WithTerminfo sets the description of the output terminal. By default, the
+// description of the terminal named by $TERM is used.
+//
+// Parameters:
+//   - info: The description. If nil, generic ANSI escape sequences are used.
+//
+// Returns:
+//   - Option: The option.
+func WithTerminfo(info *gctrm.Terminfo) Option {
+	return func(s *settings) {
+		if info == nil {
+			info = gctrm.ANSI()
+		}
+
+		s.info = info
+	}
+}
+
+// WithInteractive forces the prompts to be interactive or line-based. By
+// default, prompts are interactive only when the input is a terminal.
+//
+// Parameters:
+//   - interactive: True for interactive prompts, false for line-based ones.
+//
+// Returns:
+//   - Option: The option.
+func WithInteractive(interactive bool) Option {
+	return func(s *settings) {
+		s.interactive = &interactive
+	}
+}
+
+// WithPageSize sets the number of options shown at once by the selects. By
+// default, 10 options are shown.
+//
+// Parameters:
+//   - size: The number of options. Values less than 1 are treated as 1.
+//
+// Returns:
+//   - Option: The option.
+func WithPageSize(size int) Option {
+	return func(s *settings) {
+		s.page_size = max(size, 1)
+	}
+}
+
+// Prompter asks questions to the user.
+type Prompter struct {
+	// in is the input.
+	in io.Reader
+
+	// out is the output.
+	out io.Writer
+
+	// term writes the escape sequences of interactive prompts.
+	term *gctrm.Terminal
+
+	// fd is the file descriptor of the input, if it is a terminal.
+	fd uintptr
+
+	// is_tty is true if the input is a terminal.
+	is_tty bool
+
+	// interactive is true if the prompts are interactive.
+	interactive bool
+
+	// keys reads the input of interactive prompts.
+	keys *gctrm.KeyReader
+
+	// lines reads the input of line-based prompts.
+	lines *bufio.Reader
+
+	// page_size is the number of options shown at once by the selects.
+	page_size int
+}
+
+// New creates a new prompter.
+//
+// Parameters:
+//   - in: The input to read answers from.
+//   - out: The output to write questions to.
+//   - opts: The options of the prompter.
+//
+// Returns:
+//   - *Prompter: The new prompter. Never returns nil.
+//
+// Interactive prompts put the input in raw mode while they run and restore it
+// before returning.
+func New(in io.Reader, out io.Writer, opts ...Option) *Prompter {
+	s := settings{
+		page_size: 10,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if s.info == nil {
+		s.info = gctrm.FromEnv()
+	}
+
+	p := &Prompter{
+		in:        in,
+		out:       out,
+		term:      gctrm.NewWithTerminfo(out, s.info),
+		page_size: s.page_size,
+	}
+
+	f, ok := in.(interface{ Fd() uintptr })
+	if ok && gctrm.IsTerminal(f.Fd()) {
+		p.fd = f.Fd()
+		p.is_tty = true
+	}
+
+	if s.interactive != nil {
+		p.interactive = *s.interactive
+	} else {
+		p.interactive = p.is_tty
+	}
+
+	if p.interactive {
+		p.keys = gctrm.NewKeyReader(in)
+	} else {
+		p.lines = bufio.NewReader(in)
+	}
+
+	return p
+}
+
+// Default creates a prompter that reads from the standard input and writes to
+// the standard error, so that answers are not mixed with the output of the
+// program.
+//
+// Returns:
+//   - *Prompter: The new prompter. Never returns nil.
+func Default() *Prompter {
+	return New(os.Stdin, os.Stderr)
+}
+
+// IsInteractive checks whether the prompts are interactive.
+//
+// Returns:
+//   - bool: True if the prompts are interactive, false if they are line-based.
+func (p Prompter) IsInteractive() bool {
+	return p.interactive
+}
+
+// run runs an interactive prompt with the input in raw mode, if it is a
+// terminal.
+//
+// Parameters:
+//   - fn: The prompt.
+//
+// Returns:
+//   - error: The error returned by the prompt, or an error if the mode could
+//     not be changed.
+func (p *Prompter) run(fn func() error) error {
+	if !p.is_tty {
+		return fn()
+	}
+
+	return gctrm.WithMode(p.fd, gctrm.ModeRaw, fn)
+}
+
+// read_line reads a line of a line-based prompt.
+//
+// Returns:
+//   - string: The line, without its line ending.
+//   - error: An error if the input could not be read.
+//
+// Errors:
+//   - io.EOF: If the input is exhausted before any character is read.
+//   - any other error returned by the input.
+func (p *Prompter) read_line() (string, error) {
+	line, err := p.lines.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ask writes a question of a line-based prompt and reads the answer,
+// repeating it until the answer is accepted.
+//
+// Parameters:
+//   - question: The question, including its hint.
+//   - accept: The function that checks the answer. Its error message is
+//     written before the question is repeated.
+//
+// Returns:
+//   - error: An error if the input could not be read.
+func (p *Prompter) ask(question string, accept func(line string) error) error {
+	for {
+		err := gcstr.Write(p.out, question)
+		if err != nil {
+			return err
+		}
+
+		line, err := p.read_line()
+		if err != nil {
+			return err
+		}
+
+		err = accept(line)
+		if err == nil {
+			return nil
+		}
+
+		err = gcstr.Write(p.out, "! "+err.Error()+"\n")
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// screen redraws the lines of an interactive prompt in place.
+type screen struct {
+	// p is the prompter.
+	p *Prompter
+
+	// row is the line of the cursor, relative to the first line.
+	row int
+}
+
+// draw replaces the lines previously drawn.
+//
+// Parameters:
+//   - lines: The lines to draw. Assumed to be non-empty.
+//   - focus: The line at the end of which the cursor is left.
+//
+// Returns:
+//   - error: An error if the lines could not be written.
+func (s *screen) draw(lines []string, focus int) error {
+	t := s.p.term
+
+	err := t.MoveUp(s.row)
+	if err != nil {
+		return err
+	}
+
+	err = gcstr.Write(s.p.out, "\r")
+	if err != nil {
+		return err
+	}
+
+	_ = t.ClearToEndOfScreen()
+
+	err = gcstr.Write(s.p.out, strings.Join(lines, "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	s.row = len(lines) - 1
+
+	if focus >= len(lines)-1 {
+		return nil
+	}
+
+	err = t.MoveUp(s.row - focus)
+	if err != nil {
+		return err
+	}
+
+	err = gcstr.Write(s.p.out, "\r")
+	if err != nil {
+		return err
+	}
+
+	s.row = focus
+
+	return t.MoveRight(utf8.RuneCountInString(lines[focus]))
+}
+
+// done draws the final line of an interactive prompt and moves to the next
+// line.
+//
+// Parameters:
+//   - line: The final line.
+//
+// Returns:
+//   - error: An error if the line could not be written.
+func (s *screen) done(line string) error {
+	err := s.draw([]string{line}, 0)
+	if err != nil {
+		return err
+	}
+
+	s.row = 0
+
+	return gcstr.Write(s.p.out, "\r\n")
+}
+
+// Confirm asks a yes or no question.
+//
+// Parameters:
+//   - question: The question.
+//   - def: The answer if the user just presses Enter.
+//
+// Returns:
+//   - bool: The answer.
+//   - error: An error if the answer could not be read.
+//
+// Errors:
+//   - Interrupted: If the user pressed Ctrl+C or Escape.
+//   - io.EOF: If the input is exhausted.
+//   - any other error returned by the input or the output.
+func (p *Prompter) Confirm(question string, def bool) (bool, error) {
+	if p == nil {
+		return false, io.EOF
+	}
+
+	hint := " [y/N] "
+	if def {
+		hint = " [Y/n] "
+	}
+
+	answer := def
+
+	parse := func(s string) error {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "":
+			answer = def
+		case "y", "yes":
+			answer = true
+		case "n", "no":
+			answer = false
+		default:
+			return errors.New(gcstr.ExpectedValue("answer", "y or n", quote(s)))
+		}
+
+		return nil
+	}
+
+	if !p.interactive {
+		err := p.ask("? "+question+hint, parse)
+		return answer, err
+	}
+
+	err := p.run(func() error {
+		s := &screen{p: p}
+
+		err := s.draw([]string{"? " + question + hint}, 0)
+		if err != nil {
+			return err
+		}
+
+		for {
+			key, err := p.keys.ReadKey()
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case is_interrupt(key):
+				_ = s.done("? " + question + hint)
+				return Interrupted
+			case key.Code == gctrm.KeyEnter:
+			case key.Code == gctrm.KeyRune && key.Mod == 0 && parse(string(key.Rune)) == nil:
+			default:
+				continue
+			}
+
+			word := "no"
+			if answer {
+				word = "yes"
+			}
+
+			return s.done("? " + question + " " + word)
+		}
+	})
+
+	return answer, err
+}
+
+// is_interrupt checks whether a key interrupts a prompt.
+//
+// Parameters:
+//   - key: The key.
+//
+// Returns:
+//   - bool: True if the key is Ctrl+C or Escape, false otherwise.
+func is_interrupt(key gctrm.KeyEvent) bool {
+	return key.Code == gctrm.KeyEscape || (key.Code == gctrm.KeyRune && key.Mod == gctrm.ModCtrl && key.Rune == 'c')
+}
+
+// quote quotes an answer for an error message.
+//
+// Parameters:
+//   - s: The answer.
+//
+// Returns:
+//   - string: The quoted answer, or an empty string if the answer is empty so
+//     that gcstr.ExpectedValue reports it as nothing.
+func quote(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "\"" + s + "\""
+}
+
+// Text asks for a line of text.
+//
+// Parameters:
+//   - question: The question.
+//   - validate: The function that validates the answer. If nil, every answer
+//     is accepted.
+//
+// Returns:
+//   - string: The answer.
+//   - error: An error if the answer could not be read.
+//
+// Errors:
+//   - Interrupted: If the user pressed Ctrl+C or Escape.
+//   - io.EOF: If the input is exhausted.
+//   - any other error returned by the input or the output.
+//
+// The question is repeated until the validator accepts the answer.
+func (p *Prompter) Text(question string, validate Validator) (string, error) {
+	return p.text(question, validate, false)
+}
+
+// Password asks for a secret line of text, which is not echoed. If the input
+// is a terminal, echo is turned off even for line-based prompts.
+//
+// Parameters:
+//   - question: The question.
+//   - validate: The function that validates the answer. If nil, every answer
+//     is accepted.
+//
+// Returns:
+//   - string: The answer.
+//   - error: An error if the answer could not be read.
+//
+// Errors:
+//   - Interrupted: If the user pressed Ctrl+C or Escape.
+//   - io.EOF: If the input is exhausted.
+//   - any other error returned by the input or the output.
+//
+// The question is repeated until the validator accepts the answer.
+func (p *Prompter) Password(question string, validate Validator) (string, error) {
+	return p.text(question, validate, true)
+}
+
+// text asks for a line of text.
+//
+// Parameters:
+//   - question: The question.
+//   - validate: The function that validates the answer.
+//   - secret: True if the answer is not echoed.
+//
+// Returns:
+//   - string: The answer.
+//   - error: An error if the answer could not be read.
+func (p *Prompter) text(question string, validate Validator, secret bool) (string, error) {
+	if p == nil {
+		return "", io.EOF
+	}
+
+	if validate == nil {
+		validate = func(string) error { return nil }
+	}
+
+	var answer string
+
+	if !p.interactive {
+		ask := func() error {
+			return p.ask("? "+question+": ", func(line string) error {
+				answer = line
+				return validate(line)
+			})
+		}
+
+		var err error
+
+		if secret && p.is_tty {
+			err = gctrm.WithMode(p.fd, gctrm.ModeNoEcho, ask)
+		} else {
+			err = ask()
+		}
+
+		return answer, err
+	}
+
+	err := p.run(func() error {
+		s := &screen{p: p}
+
+		var input []rune
+		var msg string
+
+		for {
+			shown := string(input)
+			if secret {
+				shown = ""
+			}
+
+			lines := []string{"? " + question + ": " + shown}
+			if msg != "" {
+				lines = append(lines, "! "+msg)
+			}
+
+			err := s.draw(lines, 0)
+			if err != nil {
+				return err
+			}
+
+			key, err := p.keys.ReadKey()
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case is_interrupt(key):
+				_ = s.done(lines[0])
+				return Interrupted
+			case key.Code == gctrm.KeyEnter:
+				err := validate(string(input))
+				if err != nil {
+					msg = err.Error()
+					continue
+				}
+
+				answer = string(input)
+
+				if secret {
+					// The mask does not reveal the length of the secret.
+					return s.done("? " + question + ": " + secret_mask)
+				}
+
+				return s.done("? " + question + ": " + answer)
+			case key.Code == gctrm.KeyBackspace:
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+				}
+			case key.Code == gctrm.KeyRune && key.Mod == gctrm.ModCtrl && key.Rune == 'u':
+				input = input[:0]
+			case key.Code == gctrm.KeyRune && key.Mod == 0:
+				input = append(input, key.Rune)
+			case key.Code == gctrm.KeyPaste:
+				input = append(input, []rune(strings.ReplaceAll(key.Text, "\n", " "))...)
+			}
+
+			msg = ""
+		}
+	})
+
+	return answer, err
+}
@@ -0,0 +1,173 @@
+package prompt
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// new_test_prompter creates a prompter over a fixed input.
+func new_test_prompter(input string, interactive bool) (*Prompter, *bytes.Buffer) {
+	var out bytes.Buffer
+
+	p := New(strings.NewReader(input), &out, WithTerminfo(nil), WithInteractive(interactive))
+
+	return p, &out
+}
+
+// TestConfirm tests the confirm prompt in both modes.
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input       string
+		interactive bool
+		def         bool
+		expected    bool
+	}{
+		{"y\n", false, false, true},
+		{"\n", false, true, true},
+		{"maybe\nno\n", false, true, false},
+		{"xy", true, false, true},
+		{"\r", true, true, true},
+		{"N", true, true, false},
+	}
+
+	for _, test := range tests {
+		p, out := new_test_prompter(test.input, test.interactive)
+
+		res, err := p.Confirm("Continue?", test.def)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v instead", test.input, err)
+			continue
+		}
+
+		if res != test.expected {
+			t.Errorf("%q: expected %t, got %t instead", test.input, test.expected, res)
+		}
+
+		if test.input == "maybe\nno\n" && !strings.Contains(out.String(), `expected answer to be y or n, got "maybe" instead`) {
+			t.Errorf("expected the validation message, got %q instead", out.String())
+		}
+	}
+
+	p, _ := new_test_prompter("\x03", true)
+
+	_, err := p.Confirm("Continue?", false)
+	if err != Interrupted {
+		t.Errorf("expected %v, got %v instead", Interrupted, err)
+	}
+
+	p, _ = new_test_prompter("", false)
+
+	_, err = p.Confirm("Continue?", false)
+	if err != io.EOF {
+		t.Errorf("expected %v, got %v instead", io.EOF, err)
+	}
+}
+
+// TestText tests the text and password prompts with a validator.
+func TestText(t *testing.T) {
+	p, out := new_test_prompter("\nab\nabc\n", false)
+
+	res, err := p.Text("Name", All(NotEmpty(), LengthBetween(3, 5)))
+	if err != nil || res != "abc" {
+		t.Errorf("expected %q, got %q instead (%v)", "abc", res, err)
+	}
+
+	for _, msg := range []string{
+		"expected answer to be a non-empty value, got nothing instead",
+		"expected answer to be between 3 and 5 characters, got 2 characters instead",
+	} {
+		if !strings.Contains(out.String(), msg) {
+			t.Errorf("expected %q in %q", msg, out.String())
+		}
+	}
+
+	p, out = new_test_prompter("s3\x7fc\r\x15secret\r", true)
+
+	res, err = p.Password("Password", LengthBetween(3, -1))
+	if err != nil || res != "secret" {
+		t.Errorf("expected %q, got %q instead (%v)", "secret", res, err)
+	}
+
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("expected the password not to be echoed, got %q instead", out.String())
+	}
+
+	if !strings.Contains(out.String(), "? Password: "+secret_mask) {
+		t.Errorf("expected the password to be masked, got %q instead", out.String())
+	}
+
+	if !strings.Contains(out.String(), "expected answer to be at least 3 characters, got 2 characters instead") {
+		t.Errorf("expected the validation message, got %q instead", out.String())
+	}
+}
+
+// TestSelect tests the single and multi-select prompts in both modes.
+func TestSelect(t *testing.T) {
+	options := []string{"apple", "banana", "cherry", "blueberry"}
+
+	p, _ := new_test_prompter("9\nch\n", false)
+
+	res, err := p.Select("Fruit", options)
+	if err != nil || res != 2 {
+		t.Errorf("expected %d, got %d instead (%v)", 2, res, err)
+	}
+
+	p, _ = new_test_prompter("\x1b[B\x1b[B\x1b[A\r", true)
+
+	res, err = p.Select("Fruit", options)
+	if err != nil || res != 1 {
+		t.Errorf("expected %d, got %d instead (%v)", 1, res, err)
+	}
+
+	p, _ = new_test_prompter("bb\r", true)
+
+	res, err = p.Select("Fruit", options)
+	if err != nil || res != 3 {
+		t.Errorf("expected %d, got %d instead (%v)", 3, res, err)
+	}
+
+	p, _ = new_test_prompter("3, 1\n", false)
+
+	multi, err := p.MultiSelect("Fruits", options)
+	if err != nil || len(multi) != 2 || multi[0] != 0 || multi[1] != 2 {
+		t.Errorf("expected %v, got %v instead (%v)", []int{0, 2}, multi, err)
+	}
+
+	p, _ = new_test_prompter(" \x1b[B\x1b[B \x1b[A\t\t\r", true)
+
+	multi, err = p.MultiSelect("Fruits", options)
+	if err != nil || len(multi) != 2 || multi[0] != 0 || multi[1] != 2 {
+		t.Errorf("expected %v, got %v instead (%v)", []int{0, 2}, multi, err)
+	}
+
+	_, err = p.Select("Fruit", nil)
+	if err == nil {
+		t.Errorf("expected error, got nil instead")
+	}
+}
+
+// TestFuzzyFilter tests the ranking of fuzzy matches.
+func TestFuzzyFilter(t *testing.T) {
+	options := []string{"go-commons", "strings", "GoCommons", "gcm", "other"}
+
+	res := FuzzyFilter("gcm", options)
+
+	expected := []int{3, 2, 0}
+	if len(res) != len(expected) {
+		t.Fatalf("expected %v, got %v instead", expected, res)
+	}
+
+	for i, idx := range expected {
+		if res[i] != idx {
+			t.Errorf("expected %v, got %v instead", expected, res)
+			break
+		}
+	}
+
+	_, ok := FuzzyScore("xyz", "strings")
+	if ok {
+		t.Errorf("expected %t, got %t instead", false, ok)
+	}
+}
@@ -0,0 +1,386 @@
+package prompt
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	gctrm "github.com/PlayerR9/go-commons/os/terminal"
+	gcstr "github.com/PlayerR9/go-commons/strings"
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// list_state is the state of an interactive select.
+type list_state struct {
+	// options are the options.
+	options []string
+
+	// filter is the fuzzy filter typed by the user.
+	filter []rune
+
+	// matches are the indices of the options that match the filter.
+	matches []int
+
+	// cursor is the index, in matches, of the highlighted option.
+	cursor int
+
+	// offset is the index, in matches, of the first option shown.
+	offset int
+
+	// checked are the options that are checked. Nil for single selects.
+	checked []bool
+}
+
+// refilter updates the matches after the filter changed.
+func (ls *list_state) refilter() {
+	ls.matches = FuzzyFilter(string(ls.filter), ls.options)
+	ls.cursor = 0
+	ls.offset = 0
+}
+
+// move moves the cursor, wrapping around.
+//
+// Parameters:
+//   - delta: The number of options to move by.
+//   - page_size: The number of options shown at once.
+func (ls *list_state) move(delta, page_size int) {
+	if len(ls.matches) == 0 {
+		return
+	}
+
+	ls.cursor = ((ls.cursor+delta)%len(ls.matches) + len(ls.matches)) % len(ls.matches)
+
+	if ls.cursor < ls.offset {
+		ls.offset = ls.cursor
+	} else if ls.cursor >= ls.offset+page_size {
+		ls.offset = ls.cursor - page_size + 1
+	}
+}
+
+// lines renders the select.
+//
+// Parameters:
+//   - question: The question.
+//   - page_size: The number of options shown at once.
+//
+// Returns:
+//   - []string: The lines. The first one holds the question and the filter.
+func (ls *list_state) lines(question string, page_size int) []string {
+	lines := []string{"? " + question + ": " + string(ls.filter)}
+
+	if len(ls.matches) == 0 {
+		return append(lines, "  no matches")
+	}
+
+	end := min(ls.offset+page_size, len(ls.matches))
+
+	for i := ls.offset; i < end; i++ {
+		var builder strings.Builder
+
+		if i == ls.cursor {
+			builder.WriteString("> ")
+		} else {
+			builder.WriteString("  ")
+		}
+
+		idx := ls.matches[i]
+
+		if ls.checked != nil {
+			if ls.checked[idx] {
+				builder.WriteString("[x] ")
+			} else {
+				builder.WriteString("[ ] ")
+			}
+		}
+
+		builder.WriteString(ls.options[idx])
+		lines = append(lines, builder.String())
+	}
+
+	return lines
+}
+
+// Select asks to choose one of several options. In interactive prompts, the
+// arrow keys move between the options and typing filters them fuzzily; in
+// line-based prompts, the answer is either the number of an option or a
+// filter that matches exactly one option.
+//
+// Parameters:
+//   - question: The question.
+//   - options: The options.
+//
+// Returns:
+//   - int: The index of the chosen option.
+//   - error: An error if the answer could not be read.
+//
+// Errors:
+//   - *errors.Err: If there are no options.
+//   - Interrupted: If the user pressed Ctrl+C or Escape.
+//   - io.EOF: If the input is exhausted.
+//   - any other error returned by the input or the output.
+func (p *Prompter) Select(question string, options []string) (int, error) {
+	if p == nil {
+		return -1, io.EOF
+	} else if len(options) == 0 {
+		return -1, gers.NewErrInvalidParameter("options must not be empty")
+	}
+
+	if !p.interactive {
+		return p.select_lines(question, options)
+	}
+
+	choice := -1
+
+	err := p.run(func() error {
+		ls := &list_state{
+			options: options,
+		}
+
+		ls.refilter()
+
+		return p.run_list(question, ls, func() string {
+			choice = ls.matches[ls.cursor]
+			return options[choice]
+		})
+	})
+
+	return choice, err
+}
+
+// MultiSelect asks to choose any number of options. In interactive prompts,
+// the arrow keys move between the options, Space toggles them and typing
+// filters them fuzzily; in line-based prompts, the answer is a list of option
+// numbers separated by commas or spaces.
+//
+// Parameters:
+//   - question: The question.
+//   - options: The options.
+//
+// Returns:
+//   - []int: The indices of the chosen options, in ascending order.
+//   - error: An error if the answer could not be read.
+//
+// Errors:
+//   - *errors.Err: If there are no options.
+//   - Interrupted: If the user pressed Ctrl+C or Escape.
+//   - io.EOF: If the input is exhausted.
+//   - any other error returned by the input or the output.
+func (p *Prompter) MultiSelect(question string, options []string) ([]int, error) {
+	if p == nil {
+		return nil, io.EOF
+	} else if len(options) == 0 {
+		return nil, gers.NewErrInvalidParameter("options must not be empty")
+	}
+
+	if !p.interactive {
+		return p.multi_select_lines(question, options)
+	}
+
+	var chosen []int
+
+	err := p.run(func() error {
+		ls := &list_state{
+			options: options,
+			checked: make([]bool, len(options)),
+		}
+
+		ls.refilter()
+
+		return p.run_list(question, ls, func() string {
+			var names []string
+
+			for i, ok := range ls.checked {
+				if ok {
+					chosen = append(chosen, i)
+					names = append(names, options[i])
+				}
+			}
+
+			return strings.Join(names, ", ")
+		})
+	})
+
+	return chosen, err
+}
+
+// run_list runs an interactive select.
+//
+// Parameters:
+//   - question: The question.
+//   - ls: The state of the select.
+//   - submit: The function called when the user presses Enter. It returns
+//     the summary of the answer.
+//
+// Returns:
+//   - error: An error if the answer could not be read.
+func (p *Prompter) run_list(question string, ls *list_state, submit func() string) error {
+	s := &screen{p: p}
+
+	_ = p.term.HideCursor()
+	defer p.term.ShowCursor()
+
+	for {
+		err := s.draw(ls.lines(question, p.page_size), 0)
+		if err != nil {
+			return err
+		}
+
+		key, err := p.keys.ReadKey()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case is_interrupt(key):
+			_ = s.done("? " + question)
+			return Interrupted
+		case key.Code == gctrm.KeyEnter:
+			if ls.checked == nil && len(ls.matches) == 0 {
+				continue
+			}
+
+			return s.done("? " + question + ": " + submit())
+		case key.Code == gctrm.KeyUp || (key.Code == gctrm.KeyRune && key.Mod == gctrm.ModCtrl && key.Rune == 'p'):
+			ls.move(-1, p.page_size)
+		case key.Code == gctrm.KeyDown || (key.Code == gctrm.KeyRune && key.Mod == gctrm.ModCtrl && key.Rune == 'n'):
+			ls.move(1, p.page_size)
+		case key.Code == gctrm.KeyPageUp:
+			ls.move(-p.page_size, p.page_size)
+		case key.Code == gctrm.KeyPageDown:
+			ls.move(p.page_size, p.page_size)
+		case ls.checked != nil && (key.Code == gctrm.KeyTab || (key.Code == gctrm.KeyRune && key.Mod == 0 && key.Rune == ' ')):
+			if len(ls.matches) > 0 {
+				idx := ls.matches[ls.cursor]
+				ls.checked[idx] = !ls.checked[idx]
+			}
+		case key.Code == gctrm.KeyBackspace:
+			if len(ls.filter) > 0 {
+				ls.filter = ls.filter[:len(ls.filter)-1]
+				ls.refilter()
+			}
+		case key.Code == gctrm.KeyRune && key.Mod == 0:
+			ls.filter = append(ls.filter, key.Rune)
+			ls.refilter()
+		}
+	}
+}
+
+// write_options writes the numbered options of a line-based select.
+//
+// Parameters:
+//   - question: The question.
+//   - options: The options.
+//
+// Returns:
+//   - error: An error if the options could not be written.
+func (p *Prompter) write_options(question string, options []string) error {
+	var builder strings.Builder
+
+	builder.WriteString("? ")
+	builder.WriteString(question)
+	builder.WriteByte('\n')
+
+	for i, opt := range options {
+		builder.WriteString("  ")
+		builder.WriteString(strconv.Itoa(i + 1))
+		builder.WriteString(") ")
+		builder.WriteString(opt)
+		builder.WriteByte('\n')
+	}
+
+	return gcstr.Write(p.out, builder.String())
+}
+
+// select_lines runs a line-based select.
+//
+// Parameters:
+//   - question: The question.
+//   - options: The options. Assumed to be non-empty.
+//
+// Returns:
+//   - int: The index of the chosen option.
+//   - error: An error if the answer could not be read.
+func (p *Prompter) select_lines(question string, options []string) (int, error) {
+	err := p.write_options(question, options)
+	if err != nil {
+		return -1, err
+	}
+
+	expected := "a number between 1 and " + strconv.Itoa(len(options)) + " or a filter matching one option"
+	hint := "Choice [1-" + strconv.Itoa(len(options)) + "]: "
+
+	choice := -1
+
+	err = p.ask(hint, func(line string) error {
+		line = strings.TrimSpace(line)
+
+		n, err := strconv.Atoi(line)
+		if err == nil && n >= 1 && n <= len(options) {
+			choice = n - 1
+			return nil
+		}
+
+		if line != "" {
+			matches := FuzzyFilter(line, options)
+			if len(matches) == 1 {
+				choice = matches[0]
+				return nil
+			}
+		}
+
+		return errors.New(gcstr.ExpectedValue("choice", expected, quote(line)))
+	})
+
+	return choice, err
+}
+
+// multi_select_lines runs a line-based multi-select.
+//
+// Parameters:
+//   - question: The question.
+//   - options: The options. Assumed to be non-empty.
+//
+// Returns:
+//   - []int: The indices of the chosen options, in ascending order.
+//   - error: An error if the answer could not be read.
+func (p *Prompter) multi_select_lines(question string, options []string) ([]int, error) {
+	err := p.write_options(question, options)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := "numbers between 1 and " + strconv.Itoa(len(options))
+	hint := "Choices (e.g. 1,3): "
+
+	var chosen []int
+
+	err = p.ask(hint, func(line string) error {
+		checked := make([]bool, len(options))
+
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+
+		for _, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 1 || n > len(options) {
+				return errors.New(gcstr.ExpectedValue("choices", expected, quote(field)))
+			}
+
+			checked[n-1] = true
+		}
+
+		chosen = chosen[:0]
+
+		for i, ok := range checked {
+			if ok {
+				chosen = append(chosen, i)
+			}
+		}
+
+		return nil
+	})
+
+	return chosen, err
+}
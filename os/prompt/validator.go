@@ -0,0 +1,134 @@
+package prompt
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+// Validator is a function that validates the answer to a prompt.
+//
+// Parameters:
+//   - answer: The answer.
+//
+// Returns:
+//   - error: An error if the answer is invalid. Its message is shown to the
+//     user before the question is repeated.
+type Validator func(answer string) error
+
+// NotEmpty returns a validator that rejects empty or blank answers.
+//
+// Returns:
+//   - Validator: The validator. Never returns nil.
+func NotEmpty() Validator {
+	return func(answer string) error {
+		if strings.TrimSpace(answer) != "" {
+			return nil
+		}
+
+		return errors.New(gcstr.ExpectedValue("answer", "a non-empty value", ""))
+	}
+}
+
+// LengthBetween returns a validator that rejects answers whose number of
+// characters is not between min and max, inclusive.
+//
+// Parameters:
+//   - min: The minimum number of characters.
+//   - max: The maximum number of characters. Negative values mean no maximum.
+//
+// Returns:
+//   - Validator: The validator. Never returns nil.
+func LengthBetween(min, max int) Validator {
+	expected := "at least " + strconv.Itoa(min) + " characters"
+	if max >= 0 {
+		expected = "between " + strconv.Itoa(min) + " and " + strconv.Itoa(max) + " characters"
+	}
+
+	return func(answer string) error {
+		n := utf8.RuneCountInString(answer)
+		if n >= min && (max < 0 || n <= max) {
+			return nil
+		}
+
+		return errors.New(gcstr.ExpectedValue("answer", expected, strconv.Itoa(n)+" characters"))
+	}
+}
+
+// Integer returns a validator that rejects answers that are not integers
+// between min and max, inclusive.
+//
+// Parameters:
+//   - min: The minimum value.
+//   - max: The maximum value.
+//
+// Returns:
+//   - Validator: The validator. Never returns nil.
+func Integer(min, max int) Validator {
+	expected := "an integer between " + strconv.Itoa(min) + " and " + strconv.Itoa(max)
+
+	return func(answer string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(answer))
+		if err == nil && n >= min && n <= max {
+			return nil
+		}
+
+		return errors.New(gcstr.ExpectedValue("answer", expected, quote(answer)))
+	}
+}
+
+// OneOf returns a validator that rejects answers that are not one of the given
+// values.
+//
+// Parameters:
+//   - values: The accepted values.
+//
+// Returns:
+//   - Validator: The validator. Never returns nil.
+func OneOf(values ...string) Validator {
+	quoted := make([]string, 0, len(values))
+
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+
+	expected := "one of " + strings.Join(quoted, ", ")
+
+	return func(answer string) error {
+		for _, v := range values {
+			if answer == v {
+				return nil
+			}
+		}
+
+		return errors.New(gcstr.ExpectedValue("answer", expected, quote(answer)))
+	}
+}
+
+// All returns a validator that accepts answers that every validator accepts.
+// Nil validators are ignored.
+//
+// Parameters:
+//   - validators: The validators.
+//
+// Returns:
+//   - Validator: The validator. Never returns nil.
+func All(validators ...Validator) Validator {
+	return func(answer string) error {
+		for _, v := range validators {
+			if v == nil {
+				continue
+			}
+
+			err := v(answer)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
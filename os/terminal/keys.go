@@ -0,0 +1,489 @@
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyCode is the code of a key.
+type KeyCode int
+
+const (
+	// KeyRune is the code of a key that produces a character.
+	KeyRune KeyCode = iota
+
+	// KeyUnknown is the code of an escape sequence that is not recognized.
+	KeyUnknown
+
+	// KeyPaste is the code of text pasted while bracketed paste is enabled.
+	KeyPaste
+
+	// KeyEnter is the code of the Enter key.
+	KeyEnter
+
+	// KeyTab is the code of the Tab key.
+	KeyTab
+
+	// KeyBackspace is the code of the Backspace key.
+	KeyBackspace
+
+	// KeyEscape is the code of the Escape key.
+	KeyEscape
+
+	// KeyUp is the code of the Up arrow key.
+	KeyUp
+
+	// KeyDown is the code of the Down arrow key.
+	KeyDown
+
+	// KeyRight is the code of the Right arrow key.
+	KeyRight
+
+	// KeyLeft is the code of the Left arrow key.
+	KeyLeft
+
+	// KeyHome is the code of the Home key.
+	KeyHome
+
+	// KeyEnd is the code of the End key.
+	KeyEnd
+
+	// KeyPageUp is the code of the Page Up key.
+	KeyPageUp
+
+	// KeyPageDown is the code of the Page Down key.
+	KeyPageDown
+
+	// KeyInsert is the code of the Insert key.
+	KeyInsert
+
+	// KeyDelete is the code of the Delete key.
+	KeyDelete
+
+	// KeyF1 is the code of the F1 key.
+	KeyF1
+
+	// KeyF2 is the code of the F2 key.
+	KeyF2
+
+	// KeyF3 is the code of the F3 key.
+	KeyF3
+
+	// KeyF4 is the code of the F4 key.
+	KeyF4
+
+	// KeyF5 is the code of the F5 key.
+	KeyF5
+
+	// KeyF6 is the code of the F6 key.
+	KeyF6
+
+	// KeyF7 is the code of the F7 key.
+	KeyF7
+
+	// KeyF8 is the code of the F8 key.
+	KeyF8
+
+	// KeyF9 is the code of the F9 key.
+	KeyF9
+
+	// KeyF10 is the code of the F10 key.
+	KeyF10
+
+	// KeyF11 is the code of the F11 key.
+	KeyF11
+
+	// KeyF12 is the code of the F12 key.
+	KeyF12
+)
+
+var (
+	// key_names are the names of the key codes.
+	key_names = map[KeyCode]string{
+		KeyRune:      "rune",
+		KeyUnknown:   "unknown",
+		KeyPaste:     "paste",
+		KeyEnter:     "enter",
+		KeyTab:       "tab",
+		KeyBackspace: "backspace",
+		KeyEscape:    "esc",
+		KeyUp:        "up",
+		KeyDown:      "down",
+		KeyRight:     "right",
+		KeyLeft:      "left",
+		KeyHome:      "home",
+		KeyEnd:       "end",
+		KeyPageUp:    "pgup",
+		KeyPageDown:  "pgdown",
+		KeyInsert:    "insert",
+		KeyDelete:    "delete",
+	}
+
+	// tilde_keys are the keys of the "CSI <n> ~" sequences.
+	tilde_keys = map[int]KeyCode{
+		1: KeyHome, 2: KeyInsert, 3: KeyDelete, 4: KeyEnd, 5: KeyPageUp,
+		6: KeyPageDown, 7: KeyHome, 8: KeyEnd, 11: KeyF1, 12: KeyF2,
+		13: KeyF3, 14: KeyF4, 15: KeyF5, 17: KeyF6, 18: KeyF7, 19: KeyF8,
+		20: KeyF9, 21: KeyF10, 23: KeyF11, 24: KeyF12,
+	}
+
+	// final_keys are the keys of the "CSI <final>" and "SS3 <final>"
+	// sequences.
+	final_keys = map[byte]KeyCode{
+		'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft, 'H': KeyHome,
+		'F': KeyEnd, 'P': KeyF1, 'Q': KeyF2, 'R': KeyF3, 'S': KeyF4,
+	}
+)
+
+// String implements the fmt.Stringer interface.
+func (c KeyCode) String() string {
+	if c >= KeyF1 && c <= KeyF12 {
+		return "f" + strconv.Itoa(int(c-KeyF1)+1)
+	}
+
+	name, ok := key_names[c]
+	if !ok {
+		return "KeyCode(" + strconv.Itoa(int(c)) + ")"
+	}
+
+	return name
+}
+
+// Modifier is a set of modifier keys.
+type Modifier int
+
+const (
+	// ModShift is the Shift key.
+	ModShift Modifier = 1 << iota
+
+	// ModAlt is the Alt key.
+	ModAlt
+
+	// ModCtrl is the Ctrl key.
+	ModCtrl
+)
+
+// KeyEvent is a key press.
+type KeyEvent struct {
+	// Code is the code of the key.
+	Code KeyCode
+
+	// Rune is the character of the key if Code is KeyRune.
+	Rune rune
+
+	// Mod are the modifiers held with the key.
+	Mod Modifier
+
+	// Text is the pasted text if Code is KeyPaste, or the unrecognized
+	// sequence if Code is KeyUnknown.
+	Text string
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Format: "[ctrl+][alt+][shift+]<key>", such as "ctrl+c" or "alt+up".
+func (k KeyEvent) String() string {
+	var builder strings.Builder
+
+	if k.Mod&ModCtrl != 0 {
+		builder.WriteString("ctrl+")
+	}
+
+	if k.Mod&ModAlt != 0 {
+		builder.WriteString("alt+")
+	}
+
+	if k.Mod&ModShift != 0 {
+		builder.WriteString("shift+")
+	}
+
+	switch k.Code {
+	case KeyRune:
+		if k.Rune == ' ' {
+			builder.WriteString("space")
+		} else {
+			builder.WriteRune(k.Rune)
+		}
+	case KeyUnknown:
+		builder.WriteString(strconv.Quote(k.Text))
+	default:
+		builder.WriteString(k.Code.String())
+	}
+
+	return builder.String()
+}
+
+// KeyReader decodes key presses from the input of a terminal in raw or cbreak
+// mode.
+type KeyReader struct {
+	// r is the buffered input.
+	r *bufio.Reader
+}
+
+// NewKeyReader creates a new key reader.
+//
+// Parameters:
+//   - r: The input to read from.
+//
+// Returns:
+//   - *KeyReader: The new key reader. Never returns nil.
+//
+// A lone Escape byte is told apart from the start of an escape sequence by
+// whether more input is available from the same read, as terminals send
+// escape sequences in a single write.
+func NewKeyReader(r io.Reader) *KeyReader {
+	return &KeyReader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// ReadKey reads the next key press.
+//
+// Returns:
+//   - KeyEvent: The key press.
+//   - error: An error if the input could not be read.
+//
+// Errors:
+//   - io.EOF: If the input is exhausted.
+//   - any other error returned by the input.
+//
+// Invalid UTF-8 input is decoded as utf8.RuneError.
+func (kr *KeyReader) ReadKey() (KeyEvent, error) {
+	if kr == nil {
+		return KeyEvent{}, io.EOF
+	}
+
+	return kr.read_key()
+}
+
+// Keys returns an iterator over the key presses, which stops at the first
+// error.
+//
+// Returns:
+//   - iter.Seq2[KeyEvent, error]: The iterator. The last pair holds the error,
+//     unless it is io.EOF.
+func (kr *KeyReader) Keys() iter.Seq2[KeyEvent, error] {
+	return func(yield func(KeyEvent, error) bool) {
+		for {
+			key, err := kr.ReadKey()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				yield(key, err)
+				return
+			}
+
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+// read_key reads the next key press.
+//
+// Returns:
+//   - KeyEvent: The key press.
+//   - error: An error if the input could not be read.
+func (kr *KeyReader) read_key() (KeyEvent, error) {
+	r, _, err := kr.r.ReadRune()
+	if err != nil {
+		return KeyEvent{}, err
+	}
+
+	if r != 0x1b {
+		return control_key(r), nil
+	}
+
+	if kr.r.Buffered() == 0 {
+		return KeyEvent{Code: KeyEscape}, nil
+	}
+
+	b, _ := kr.r.ReadByte()
+
+	switch b {
+	case '[':
+		return kr.read_csi()
+	case 'O':
+		return kr.read_ss3()
+	}
+
+	_ = kr.r.UnreadByte()
+
+	key, err := kr.read_key()
+	if err != nil {
+		return KeyEvent{Code: KeyEscape}, nil
+	}
+
+	key.Mod |= ModAlt
+
+	return key, nil
+}
+
+// control_key decodes a key that is not part of an escape sequence.
+//
+// Parameters:
+//   - r: The character that was read.
+//
+// Returns:
+//   - KeyEvent: The key press.
+func control_key(r rune) KeyEvent {
+	switch {
+	case r == '\r' || r == '\n':
+		return KeyEvent{Code: KeyEnter}
+	case r == '\t':
+		return KeyEvent{Code: KeyTab}
+	case r == 0x7f || r == 0x08:
+		return KeyEvent{Code: KeyBackspace}
+	case r == 0:
+		return KeyEvent{Code: KeyRune, Rune: ' ', Mod: ModCtrl}
+	case r < 0x1b:
+		return KeyEvent{Code: KeyRune, Rune: 'a' + r - 1, Mod: ModCtrl}
+	case r < 0x20:
+		return KeyEvent{Code: KeyRune, Rune: '\\' + r - 0x1c, Mod: ModCtrl}
+	default:
+		return KeyEvent{Code: KeyRune, Rune: r}
+	}
+}
+
+// modifier decodes the modifier parameter of an escape sequence.
+//
+// Parameters:
+//   - param: The parameter, such as "5" for Ctrl.
+//
+// Returns:
+//   - Modifier: The modifiers.
+func modifier(param string) Modifier {
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 1 {
+		return 0
+	}
+
+	return Modifier(n-1) & (ModShift | ModAlt | ModCtrl)
+}
+
+// read_csi reads the rest of a "ESC [" sequence.
+//
+// Returns:
+//   - KeyEvent: The key press.
+//   - error: An error if the input could not be read.
+func (kr *KeyReader) read_csi() (KeyEvent, error) {
+	var params strings.Builder
+
+	var final byte
+
+	for {
+		b, err := kr.r.ReadByte()
+		if err == io.EOF {
+			return KeyEvent{Code: KeyUnknown, Text: "\x1b[" + params.String()}, nil
+		} else if err != nil {
+			return KeyEvent{}, err
+		}
+
+		if b >= 0x40 && b <= 0x7e {
+			final = b
+			break
+		}
+
+		params.WriteByte(b)
+	}
+
+	fields := strings.Split(params.String(), ";")
+
+	var mod Modifier
+
+	if len(fields) > 1 {
+		mod = modifier(fields[1])
+	}
+
+	unknown := KeyEvent{
+		Code: KeyUnknown,
+		Text: "\x1b[" + params.String() + string(final),
+	}
+
+	switch final {
+	case '~':
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return unknown, nil
+		}
+
+		if n == 200 {
+			return kr.read_paste()
+		}
+
+		code, ok := tilde_keys[n]
+		if !ok {
+			return unknown, nil
+		}
+
+		return KeyEvent{Code: code, Mod: mod}, nil
+	case 'Z':
+		return KeyEvent{Code: KeyTab, Mod: ModShift}, nil
+	}
+
+	code, ok := final_keys[final]
+	if !ok {
+		return unknown, nil
+	}
+
+	return KeyEvent{Code: code, Mod: mod}, nil
+}
+
+// read_ss3 reads the rest of a "ESC O" sequence.
+//
+// Returns:
+//   - KeyEvent: The key press.
+//   - error: An error if the input could not be read.
+func (kr *KeyReader) read_ss3() (KeyEvent, error) {
+	b, err := kr.r.ReadByte()
+	if err == io.EOF {
+		return KeyEvent{Code: KeyRune, Rune: 'O', Mod: ModAlt}, nil
+	} else if err != nil {
+		return KeyEvent{}, err
+	}
+
+	code, ok := final_keys[b]
+	if !ok {
+		return KeyEvent{Code: KeyUnknown, Text: "\x1bO" + string(b)}, nil
+	}
+
+	return KeyEvent{Code: code}, nil
+}
+
+// read_paste reads pasted text up to the end of the bracketed paste.
+//
+// Returns:
+//   - KeyEvent: The paste.
+//   - error: An error if the input could not be read.
+func (kr *KeyReader) read_paste() (KeyEvent, error) {
+	const end = "\x1b[201~"
+
+	var text []byte
+
+	for {
+		b, err := kr.r.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return KeyEvent{}, err
+		}
+
+		text = append(text, b)
+
+		if len(text) >= len(end) && string(text[len(text)-len(end):]) == end {
+			text = text[:len(text)-len(end)]
+			break
+		}
+	}
+
+	if !utf8.Valid(text) {
+		text = []byte(strings.ToValidUTF8(string(text), string(utf8.RuneError)))
+	}
+
+	return KeyEvent{Code: KeyPaste, Text: string(text)}, nil
+}
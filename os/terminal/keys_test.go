@@ -0,0 +1,60 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReadKey tests the decoding of key presses from a byte stream.
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"ab\r\t\x7f", []string{"a", "b", "enter", "tab", "backspace"}},
+		{"\x03\x00\x1c", []string{"ctrl+c", "ctrl+space", "ctrl+\\"}},
+		{"\x1b[A\x1b[B\x1bOC\x1b[D", []string{"up", "down", "right", "left"}},
+		{"\x1b[1;5C\x1b[1;3A\x1b[1;2H", []string{"ctrl+right", "alt+up", "shift+home"}},
+		{"\x1bOP\x1b[15~\x1b[24;5~\x1b[1;2S", []string{"f1", "f5", "ctrl+f12", "shift+f4"}},
+		{"\x1b[3~\x1b[5~\x1b[Z", []string{"delete", "pgup", "shift+tab"}},
+		{"\x1bx\x1b\x1b[A\x1b\x01", []string{"alt+x", "alt+up", "ctrl+alt+a"}},
+		{"héllo", []string{"h", "é", "l", "l", "o"}},
+		{"\x1b[99z", []string{`"\x1b[99z"`}},
+		{"\x1b", []string{"esc"}},
+	}
+
+	for _, test := range tests {
+		var got []string
+
+		for key, err := range NewKeyReader(strings.NewReader(test.input)).Keys() {
+			if err != nil {
+				t.Fatalf("%q: expected no error, got %v instead", test.input, err)
+			}
+
+			got = append(got, key.String())
+		}
+
+		if strings.Join(got, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("%q: expected %q, got %q instead", test.input, test.expected, got)
+		}
+	}
+}
+
+// TestReadPaste tests the decoding of bracketed paste.
+func TestReadPaste(t *testing.T) {
+	kr := NewKeyReader(strings.NewReader("\x1b[200~line 1\nline\x1b[A 2\x1b[201~q"))
+
+	key, err := kr.ReadKey()
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if key.Code != KeyPaste || key.Text != "line 1\nline\x1b[A 2" {
+		t.Errorf("expected paste %q, got %v %q instead", "line 1\nline\x1b[A 2", key.Code, key.Text)
+	}
+
+	key, _ = kr.ReadKey()
+	if key.Code != KeyRune || key.Rune != 'q' {
+		t.Errorf("expected %q, got %v instead", "q", key)
+	}
+}
@@ -0,0 +1,130 @@
+package terminal
+
+import (
+	"strconv"
+)
+
+// Mode is an input mode of a terminal.
+type Mode int
+
+const (
+	// ModeRaw disables line buffering, echo, signal generation and every
+	// input and output processing.
+	ModeRaw Mode = iota
+
+	// ModeCbreak disables line buffering and echo but keeps signal
+	// generation and output processing, so that Ctrl+C still interrupts the
+	// program.
+	ModeCbreak
+
+	// ModeNoEcho only disables echo, except for the line endings, so that the
+	// line can still be edited, such as when reading a password.
+	ModeNoEcho
+)
+
+// String implements the fmt.Stringer interface.
+func (m Mode) String() string {
+	switch m {
+	case ModeRaw:
+		return "raw"
+	case ModeCbreak:
+		return "cbreak"
+	case ModeNoEcho:
+		return "noecho"
+	default:
+		return "Mode(" + strconv.Itoa(int(m)) + ")"
+	}
+}
+
+// State is the state of a terminal, as saved before changing its mode.
+type State struct {
+	// state is the platform-specific state.
+	state term_state
+}
+
+// MakeRaw puts the terminal behind a file descriptor in raw mode.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//
+// Returns:
+//   - *State: The previous state, to be given to Restore.
+//   - error: An error if the mode could not be changed.
+//
+// Errors:
+//   - NotTTY: If the file descriptor does not refer to a terminal.
+//   - any other error returned by the operating system.
+func MakeRaw(fd uintptr) (*State, error) {
+	return set_mode(fd, ModeRaw)
+}
+
+// MakeCbreak puts the terminal behind a file descriptor in cbreak mode.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//
+// Returns:
+//   - *State: The previous state, to be given to Restore.
+//   - error: An error if the mode could not be changed.
+//
+// Errors:
+//   - NotTTY: If the file descriptor does not refer to a terminal.
+//   - any other error returned by the operating system.
+func MakeCbreak(fd uintptr) (*State, error) {
+	return set_mode(fd, ModeCbreak)
+}
+
+// Restore restores the terminal behind a file descriptor to a saved state.
+// Does nothing if the state is nil.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//   - state: The saved state.
+//
+// Returns:
+//   - error: An error if the state could not be restored.
+func Restore(fd uintptr, state *State) error {
+	if state == nil {
+		return nil
+	}
+
+	return restore(fd, state)
+}
+
+// WithMode runs a function while the terminal behind a file descriptor is in
+// the given mode. The previous state is restored when the function returns,
+// even if it panics.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//   - mode: The mode.
+//   - fn: The function to run. Does nothing if it is nil.
+//
+// Returns:
+//   - error: The error returned by the function or, if there is none, an error
+//     if the mode could not be changed or restored.
+//
+// Errors:
+//   - NotTTY: If the file descriptor does not refer to a terminal.
+//   - any error returned by the function or by the operating system.
+func WithMode(fd uintptr, mode Mode, fn func() error) (err error) {
+	if fn == nil {
+		return nil
+	}
+
+	state, err := set_mode(fd, mode)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		rerr := restore(fd, state)
+		if err == nil {
+			err = rerr
+		}
+	}()
+
+	err = fn()
+
+	return err
+}
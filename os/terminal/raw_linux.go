@@ -0,0 +1,92 @@
+package terminal
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// term_state is the state of a terminal on Linux.
+type term_state = syscall.Termios
+
+// ioctl_termios gets or sets the termios of a file descriptor.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//   - req: The request, either TCGETS or TCSETS.
+//   - t: The termios to fill or apply.
+//
+// Returns:
+//   - error: An error if the request failed.
+func ioctl_termios(fd uintptr, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(t)))
+	if errno == syscall.ENOTTY {
+		return NotTTY
+	} else if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// set_mode puts a terminal in the given mode.
+//
+// Parameters:
+//   - fd: The file descriptor of the terminal.
+//   - mode: The mode.
+//
+// Returns:
+//   - *State: The previous state.
+//   - error: An error if the mode could not be changed.
+func set_mode(fd uintptr, mode Mode) (*State, error) {
+	var old syscall.Termios
+
+	err := ioctl_termios(fd, syscall.TCGETS, &old)
+	if err != nil {
+		return nil, err
+	}
+
+	t := old
+
+	switch mode {
+	case ModeRaw:
+		// Same as cfmakeraw(3).
+		t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+			syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+		t.Oflag &^= syscall.OPOST
+		t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		t.Cflag &^= syscall.CSIZE | syscall.PARENB
+		t.Cflag |= syscall.CS8
+	case ModeNoEcho:
+		t.Lflag &^= syscall.ECHO
+		t.Lflag |= syscall.ECHONL
+	default:
+		t.Lflag &^= syscall.ECHO | syscall.ICANON
+	}
+
+	// Reads only return whole lines in canonical mode.
+	if t.Lflag&syscall.ICANON == 0 {
+		t.Cc[syscall.VMIN] = 1
+		t.Cc[syscall.VTIME] = 0
+	}
+
+	err = ioctl_termios(fd, syscall.TCSETS, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &State{
+		state: old,
+	}, nil
+}
+
+// restore restores a terminal to a saved state.
+//
+// Parameters:
+//   - fd: The file descriptor of the terminal.
+//   - state: The saved state. Assumed to be non-nil.
+//
+// Returns:
+//   - error: An error if the state could not be restored.
+func restore(fd uintptr, state *State) error {
+	return ioctl_termios(fd, syscall.TCSETS, &state.state)
+}
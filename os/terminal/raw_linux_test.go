@@ -0,0 +1,143 @@
+package terminal
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// open_pty opens a pseudo-terminal pair.
+func open_pty(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+
+	t.Cleanup(func() { master.Close() })
+
+	var unlock int32
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+	if errno != 0 {
+		t.Skipf("could not unlock pseudo-terminal: %v", errno)
+	}
+
+	var n uint32
+
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+	if errno != 0 {
+		t.Skipf("could not get pseudo-terminal number: %v", errno)
+	}
+
+	slave, err := os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("could not open pseudo-terminal: %v", err)
+	}
+
+	t.Cleanup(func() { slave.Close() })
+
+	return master, slave
+}
+
+// get_lflag returns the local flags of a terminal.
+func get_lflag(t *testing.T, f *os.File) uint32 {
+	t.Helper()
+
+	var termios syscall.Termios
+
+	err := ioctl_termios(f.Fd(), syscall.TCGETS, &termios)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	return termios.Lflag
+}
+
+// TestWithMode tests that modes are set and restored, even on error.
+func TestWithMode(t *testing.T) {
+	master, slave := open_pty(t)
+
+	if !New(slave).IsTTY() {
+		t.Fatalf("expected the pseudo-terminal to be a terminal")
+	}
+
+	before := get_lflag(t, slave)
+
+	expected := errors.New("failure")
+
+	err := WithMode(slave.Fd(), ModeRaw, func() error {
+		lflag := get_lflag(t, slave)
+		if lflag&(syscall.ICANON|syscall.ECHO|syscall.ISIG) != 0 {
+			t.Errorf("expected canonical mode, echo and signals to be disabled")
+		}
+
+		_, err := master.Write([]byte("\x1b[1;5Dx"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		kr := NewKeyReader(slave)
+
+		for _, want := range []string{"ctrl+left", "x"} {
+			key, err := kr.ReadKey()
+			if err != nil {
+				t.Fatalf("expected no error, got %v instead", err)
+			}
+
+			if key.String() != want {
+				t.Errorf("expected %q, got %q instead", want, key.String())
+			}
+		}
+
+		return expected
+	})
+
+	if err != expected {
+		t.Errorf("expected %v, got %v instead", expected, err)
+	}
+
+	after := get_lflag(t, slave)
+	if after != before {
+		t.Errorf("expected %#x, got %#x instead", before, after)
+	}
+
+	state, err := MakeCbreak(slave.Fd())
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if get_lflag(t, slave)&syscall.ISIG == 0 {
+		t.Errorf("expected signals to stay enabled in cbreak mode")
+	}
+
+	err = Restore(slave.Fd(), state)
+	if err != nil || get_lflag(t, slave) != before {
+		t.Errorf("expected the state to be restored, got %v instead", err)
+	}
+
+	_ = WithMode(slave.Fd(), ModeNoEcho, func() error {
+		lflag := get_lflag(t, slave)
+		if lflag&syscall.ECHO != 0 || lflag&syscall.ICANON == 0 {
+			t.Errorf("expected echo to be disabled and canonical mode to stay enabled")
+		}
+
+		return nil
+	})
+
+	func() {
+		defer func() { _ = recover() }()
+
+		_ = WithMode(slave.Fd(), ModeRaw, func() error {
+			panic("failure")
+		})
+	}()
+
+	if get_lflag(t, slave) != before {
+		t.Errorf("expected the state to be restored after a panic")
+	}
+}
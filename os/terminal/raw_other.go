@@ -0,0 +1,39 @@
+//go:build !linux
+
+package terminal
+
+import (
+	"runtime"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+// term_state is the state of a terminal on platforms that are yet to be
+// supported.
+type term_state struct{}
+
+// set_mode puts a terminal in the given mode.
+//
+// Parameters:
+//   - fd: The file descriptor of the terminal.
+//   - mode: The mode.
+//
+// Returns:
+//   - *State: Always nil.
+//   - error: An error as the platform is yet to be supported.
+func set_mode(fd uintptr, mode Mode) (*State, error) {
+	return nil, gerr.New(gers.OperationFail, "terminal modes are yet to be supported on "+runtime.GOOS)
+}
+
+// restore restores a terminal to a saved state.
+//
+// Parameters:
+//   - fd: The file descriptor of the terminal.
+//   - state: The saved state.
+//
+// Returns:
+//   - error: An error as the platform is yet to be supported.
+func restore(fd uintptr, state *State) error {
+	return gerr.New(gers.OperationFail, "terminal modes are yet to be supported on "+runtime.GOOS)
+}
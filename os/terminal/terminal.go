@@ -0,0 +1,368 @@
+// Package terminal provides terminal control through escape sequences that
+// can be written to any io.Writer. The sequences are looked up from the
+// terminfo database or, by default, are generic ANSI escape sequences.
+package terminal
+
+import (
+	"errors"
+	"io"
+
+	gcstr "github.com/PlayerR9/go-commons/strings"
+)
+
+var (
+	// NotTTY is an error that is returned when an operation requires a
+	// terminal but the writer is not one. Functions must return this error as
+	// is and not wrap it as callers are expected to check for this error
+	// using ==.
+	NotTTY error
+)
+
+func init() {
+	NotTTY = errors.New("not a terminal")
+}
+
+// fder is implemented by writers backed by a file descriptor, such as
+// *os.File.
+type fder interface {
+	// Fd returns the file descriptor.
+	//
+	// Returns:
+	//   - uintptr: The file descriptor.
+	Fd() uintptr
+}
+
+// Terminal writes ANSI escape sequences to a writer.
+type Terminal struct {
+	// w is the writer to write to.
+	w io.Writer
+
+	// fd is the file descriptor of the writer, if any.
+	fd uintptr
+
+	// has_fd is true if the writer has a file descriptor.
+	has_fd bool
+
+	// info is the description of the terminal.
+	info *Terminfo
+}
+
+// New creates a new terminal that writes generic ANSI escape sequences to the
+// given writer.
+//
+// Parameters:
+//   - w: The writer to write to.
+//
+// Returns:
+//   - *Terminal: The new terminal. Never returns nil.
+//
+// If the writer has a file descriptor (such as *os.File), it is used to
+// detect whether the output is a terminal and to query its size.
+func New(w io.Writer) *Terminal {
+	return NewWithTerminfo(w, nil)
+}
+
+// NewWithTerminfo creates a new terminal that writes the capabilities of the
+// given description to the given writer.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - info: The description of the terminal. If nil, ANSI() is used.
+//
+// Returns:
+//   - *Terminal: The new terminal. Never returns nil.
+//
+// Operations whose capability the terminal lacks return an *ErrNoCapability
+// error.
+func NewWithTerminfo(w io.Writer, info *Terminfo) *Terminal {
+	if info == nil {
+		info = ANSI()
+	}
+
+	t := &Terminal{
+		w:    w,
+		info: info,
+	}
+
+	f, ok := w.(fder)
+	if ok {
+		t.fd = f.Fd()
+		t.has_fd = true
+	}
+
+	return t
+}
+
+// Writer returns the writer of the terminal.
+//
+// Returns:
+//   - io.Writer: The writer.
+func (t Terminal) Writer() io.Writer {
+	return t.w
+}
+
+// Terminfo returns the description of the terminal.
+//
+// Returns:
+//   - *Terminfo: The description. Never returns nil.
+func (t Terminal) Terminfo() *Terminfo {
+	return t.info
+}
+
+// IsTerminal checks whether a file descriptor refers to a terminal.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//
+// Returns:
+//   - bool: True if the file descriptor refers to a terminal, false otherwise.
+func IsTerminal(fd uintptr) bool {
+	return is_tty(fd)
+}
+
+// IsTTY checks whether the writer is a terminal.
+//
+// Returns:
+//   - bool: True if the writer is a terminal, false otherwise.
+func (t Terminal) IsTTY() bool {
+	return t.has_fd && is_tty(t.fd)
+}
+
+// Size returns the size of the terminal.
+//
+// Returns:
+//   - int: The number of columns.
+//   - int: The number of rows.
+//   - error: An error if the size could not be determined.
+//
+// Errors:
+//   - NotTTY: If the writer is not a terminal.
+//   - any other error returned by the operating system.
+func (t Terminal) Size() (int, int, error) {
+	if !t.has_fd {
+		return 0, 0, NotTTY
+	}
+
+	return size(t.fd)
+}
+
+// put writes a capability.
+//
+// Parameters:
+//   - name: The name of the capability.
+//   - params: The parameters of the capability, if any.
+//
+// Returns:
+//   - error: An error if the capability is absent or could not be written.
+func (t *Terminal) put(name string, params ...any) error {
+	if t == nil {
+		return io.ErrShortWrite
+	}
+
+	s, ok := t.info.String(name)
+	if !ok {
+		return NewErrNoCapability(name)
+	}
+
+	if len(params) > 0 {
+		var err error
+
+		s, err = Tparm(s, params...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return gcstr.Write(t.w, strip_padding(s))
+}
+
+// move writes a parameterized cursor movement or, if the terminal lacks it,
+// repeats the single-step movement.
+//
+// Parameters:
+//   - n: The number of steps. Does nothing if it is not positive.
+//   - name: The name of the parameterized capability.
+//   - single: The name of the single-step capability.
+//
+// Returns:
+//   - error: An error if neither capability is present or could not be
+//     written.
+func (t *Terminal) move(n int, name, single string) error {
+	if n <= 0 {
+		return nil
+	}
+
+	if t != nil {
+		_, ok := t.info.String(name)
+		if ok {
+			return t.put(name, n)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		err := t.put(single)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClearScreen clears the screen and moves the cursor to the top-left corner.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) ClearScreen() error {
+	return t.put("clear")
+}
+
+// ClearLine clears the current line and moves the cursor to its start.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) ClearLine() error {
+	err := t.put("cr")
+	if err != nil {
+		return err
+	}
+
+	return t.put("el")
+}
+
+// ClearToEndOfLine clears the current line from the cursor to its end.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) ClearToEndOfLine() error {
+	return t.put("el")
+}
+
+// ClearToEndOfScreen clears the screen from the cursor to its end.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) ClearToEndOfScreen() error {
+	return t.put("ed")
+}
+
+// MoveTo moves the cursor to the given position.
+//
+// Parameters:
+//   - row: The row, starting at 0. Negative values are treated as 0.
+//   - col: The column, starting at 0. Negative values are treated as 0.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) MoveTo(row, col int) error {
+	return t.put("cup", max(row, 0), max(col, 0))
+}
+
+// MoveUp moves the cursor up. Does nothing if n is not positive.
+//
+// Parameters:
+//   - n: The number of rows.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) MoveUp(n int) error {
+	return t.move(n, "cuu", "cuu1")
+}
+
+// MoveDown moves the cursor down. Does nothing if n is not positive.
+//
+// Parameters:
+//   - n: The number of rows.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) MoveDown(n int) error {
+	return t.move(n, "cud", "cud1")
+}
+
+// MoveRight moves the cursor right. Does nothing if n is not positive.
+//
+// Parameters:
+//   - n: The number of columns.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) MoveRight(n int) error {
+	return t.move(n, "cuf", "cuf1")
+}
+
+// MoveLeft moves the cursor left. Does nothing if n is not positive.
+//
+// Parameters:
+//   - n: The number of columns.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) MoveLeft(n int) error {
+	return t.move(n, "cub", "cub1")
+}
+
+// SaveCursor saves the position of the cursor.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) SaveCursor() error {
+	return t.put("sc")
+}
+
+// RestoreCursor moves the cursor to the position saved by SaveCursor.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) RestoreCursor() error {
+	return t.put("rc")
+}
+
+// HideCursor hides the cursor.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) HideCursor() error {
+	return t.put("civis")
+}
+
+// ShowCursor shows the cursor.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) ShowCursor() error {
+	return t.put("cnorm")
+}
+
+// EnterAltScreen switches to the alternate screen buffer.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) EnterAltScreen() error {
+	return t.put("smcup")
+}
+
+// ExitAltScreen switches back from the alternate screen buffer.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) ExitAltScreen() error {
+	return t.put("rmcup")
+}
+
+// EnableBracketedPaste makes the terminal surround pasted text with escape
+// sequences, so that a KeyReader reports it as a single KeyPaste event.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) EnableBracketedPaste() error {
+	return t.put("BE")
+}
+
+// DisableBracketedPaste undoes EnableBracketedPaste.
+//
+// Returns:
+//   - error: An error if the sequence could not be written.
+func (t *Terminal) DisableBracketedPaste() error {
+	return t.put("BD")
+}
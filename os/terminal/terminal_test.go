@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSequences tests the escape sequences written by the terminal.
+func TestSequences(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(t *Terminal) error
+		expected string
+	}{
+		{"ClearScreen", (*Terminal).ClearScreen, "\x1b[2J\x1b[H"},
+		{"ClearLine", (*Terminal).ClearLine, "\r\x1b[K"},
+		{"ClearToEndOfLine", (*Terminal).ClearToEndOfLine, "\x1b[K"},
+		{"ClearToEndOfScreen", (*Terminal).ClearToEndOfScreen, "\x1b[J"},
+		{"MoveTo", func(t *Terminal) error { return t.MoveTo(2, 4) }, "\x1b[3;5H"},
+		{"MoveUp", func(t *Terminal) error { return t.MoveUp(3) }, "\x1b[3A"},
+		{"MoveDown", func(t *Terminal) error { return t.MoveDown(1) }, "\x1b[1B"},
+		{"MoveRight", func(t *Terminal) error { return t.MoveRight(2) }, "\x1b[2C"},
+		{"MoveLeft", func(t *Terminal) error { return t.MoveLeft(0) }, ""},
+		{"SaveCursor", (*Terminal).SaveCursor, "\x1b7"},
+		{"RestoreCursor", (*Terminal).RestoreCursor, "\x1b8"},
+		{"HideCursor", (*Terminal).HideCursor, "\x1b[?25l"},
+		{"ShowCursor", (*Terminal).ShowCursor, "\x1b[?25h"},
+		{"EnterAltScreen", (*Terminal).EnterAltScreen, "\x1b[?1049h"},
+		{"ExitAltScreen", (*Terminal).ExitAltScreen, "\x1b[?1049l"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+
+		err := test.fn(New(&buf))
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v instead", test.name, err)
+			continue
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q instead", test.name, test.expected, buf.String())
+		}
+	}
+}
+
+// TestNotTTY tests that a buffer is not detected as a terminal.
+func TestNotTTY(t *testing.T) {
+	term := New(&bytes.Buffer{})
+
+	if term.IsTTY() {
+		t.Errorf("expected %t, got %t instead", false, true)
+	}
+
+	_, _, err := term.Size()
+	if err != NotTTY {
+		t.Errorf("expected %v, got %v instead", NotTTY, err)
+	}
+}
@@ -0,0 +1,651 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+const (
+	// magic_legacy is the magic number of entries whose numbers are 16 bits
+	// wide.
+	magic_legacy = 0432
+
+	// magic_extended is the magic number of entries whose numbers are 32 bits
+	// wide (the extended number format).
+	magic_extended = 01036
+)
+
+// Terminfo is the description of a terminal, as found in the compiled
+// terminfo database.
+type Terminfo struct {
+	// Names are the names of the terminal. The last one is usually a
+	// description.
+	Names []string
+
+	// Bools are the boolean capabilities that are set.
+	Bools map[string]bool
+
+	// Numbers are the numeric capabilities that are present.
+	Numbers map[string]int
+
+	// Strings are the string capabilities that are present.
+	Strings map[string]string
+}
+
+// new_terminfo creates a new, empty terminal description.
+//
+// Returns:
+//   - *Terminfo: The new description. Never returns nil.
+func new_terminfo() *Terminfo {
+	return &Terminfo{
+		Bools:   make(map[string]bool),
+		Numbers: make(map[string]int),
+		Strings: make(map[string]string),
+	}
+}
+
+// Flag checks whether a boolean capability is set.
+//
+// Parameters:
+//   - name: The name of the capability.
+//
+// Returns:
+//   - bool: True if the capability is set, false otherwise.
+func (ti *Terminfo) Flag(name string) bool {
+	if ti == nil {
+		return false
+	}
+
+	return ti.Bools[name]
+}
+
+// Number returns a numeric capability.
+//
+// Parameters:
+//   - name: The name of the capability.
+//
+// Returns:
+//   - int: The value of the capability.
+//   - bool: True if the capability is present, false otherwise.
+func (ti *Terminfo) Number(name string) (int, bool) {
+	if ti == nil {
+		return 0, false
+	}
+
+	n, ok := ti.Numbers[name]
+	return n, ok
+}
+
+// String returns a string capability.
+//
+// Parameters:
+//   - name: The name of the capability.
+//
+// Returns:
+//   - string: The value of the capability.
+//   - bool: True if the capability is present, false otherwise.
+func (ti *Terminfo) String(name string) (string, bool) {
+	if ti == nil {
+		return "", false
+	}
+
+	s, ok := ti.Strings[name]
+	return s, ok
+}
+
+// Tparm evaluates a parameterized string capability.
+//
+// Parameters:
+//   - name: The name of the capability.
+//   - params: The parameters of the capability.
+//
+// Returns:
+//   - string: The evaluated capability.
+//   - error: An error if the capability is absent or could not be evaluated.
+//
+// Errors:
+//   - *ErrNoCapability: If the capability is absent.
+//   - any error returned by the Tparm function.
+func (ti *Terminfo) Tparm(name string, params ...any) (string, error) {
+	s, ok := ti.String(name)
+	if !ok {
+		return "", NewErrNoCapability(name)
+	}
+
+	return Tparm(s, params...)
+}
+
+// Dumb returns the description of a dumb terminal, which can do little more
+// than print text. It is used when no description of the terminal is found.
+//
+// Returns:
+//   - *Terminfo: The description. Never returns nil.
+func Dumb() *Terminfo {
+	ti := new_terminfo()
+
+	ti.Names = []string{"dumb", "80-column dumb tty"}
+	ti.Bools["am"] = true
+	ti.Numbers["cols"] = 80
+	ti.Strings["bel"] = "\a"
+	ti.Strings["cr"] = "\r"
+	ti.Strings["cud1"] = "\n"
+	ti.Strings["ind"] = "\n"
+
+	return ti
+}
+
+// ANSI returns the description of a generic terminal that understands ANSI
+// escape sequences. It is used by terminals created without a description.
+//
+// Returns:
+//   - *Terminfo: The description. Never returns nil.
+func ANSI() *Terminfo {
+	ti := new_terminfo()
+
+	ti.Names = []string{"ansi", "generic ANSI terminal"}
+	ti.Bools["am"] = true
+	ti.Numbers["cols"] = 80
+	ti.Numbers["lines"] = 24
+
+	ti.Strings["bel"] = "\a"
+	ti.Strings["cr"] = "\r"
+	ti.Strings["clear"] = "\x1b[2J\x1b[H"
+	ti.Strings["el"] = "\x1b[K"
+	ti.Strings["el1"] = "\x1b[1K"
+	ti.Strings["ed"] = "\x1b[J"
+	ti.Strings["home"] = "\x1b[H"
+	ti.Strings["cup"] = "\x1b[%i%p1%d;%p2%dH"
+	ti.Strings["cuu"] = "\x1b[%p1%dA"
+	ti.Strings["cud"] = "\x1b[%p1%dB"
+	ti.Strings["cuf"] = "\x1b[%p1%dC"
+	ti.Strings["cub"] = "\x1b[%p1%dD"
+	ti.Strings["cuu1"] = "\x1b[A"
+	ti.Strings["cud1"] = "\n"
+	ti.Strings["cuf1"] = "\x1b[C"
+	ti.Strings["cub1"] = "\b"
+	ti.Strings["sc"] = "\x1b7"
+	ti.Strings["rc"] = "\x1b8"
+	ti.Strings["civis"] = "\x1b[?25l"
+	ti.Strings["cnorm"] = "\x1b[?25h"
+	ti.Strings["smcup"] = "\x1b[?1049h"
+	ti.Strings["rmcup"] = "\x1b[?1049l"
+	ti.Strings["BE"] = "\x1b[?2004h"
+	ti.Strings["BD"] = "\x1b[?2004l"
+	ti.Strings["sgr0"] = "\x1b[m"
+	ti.Strings["bold"] = "\x1b[1m"
+	ti.Strings["rev"] = "\x1b[7m"
+	ti.Strings["smso"] = "\x1b[7m"
+	ti.Strings["rmso"] = "\x1b[27m"
+	ti.Strings["smul"] = "\x1b[4m"
+	ti.Strings["rmul"] = "\x1b[24m"
+
+	return ti
+}
+
+// ErrNoCapability is an error that is returned when a terminal lacks a
+// capability.
+type ErrNoCapability struct {
+	// Name is the name of the missing capability.
+	Name string
+}
+
+// Error implements the error interface.
+//
+// Message: "terminal has no <name> capability"
+func (e ErrNoCapability) Error() string {
+	return "terminal has no " + strconv.Quote(e.Name) + " capability"
+}
+
+// NewErrNoCapability creates a new ErrNoCapability error.
+//
+// Parameters:
+//   - name: The name of the missing capability.
+//
+// Returns:
+//   - *ErrNoCapability: The new error. Never returns nil.
+func NewErrNoCapability(name string) *ErrNoCapability {
+	return &ErrNoCapability{
+		Name: name,
+	}
+}
+
+// terminfo_reader reads the sections of a compiled entry.
+type terminfo_reader struct {
+	// data is the compiled entry.
+	data []byte
+
+	// pos is the position of the next byte to read.
+	pos int
+}
+
+// take reads the next n bytes.
+//
+// Parameters:
+//   - n: The number of bytes.
+//
+// Returns:
+//   - []byte: The bytes.
+//   - error: An error if the entry is too short.
+func (r *terminfo_reader) take(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errors.New("truncated terminfo entry")
+	}
+
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+
+	return b, nil
+}
+
+// align skips the padding byte that keeps sections at an even offset.
+func (r *terminfo_reader) align() {
+	if r.pos%2 == 1 && r.pos < len(r.data) {
+		r.pos++
+	}
+}
+
+// shorts reads n little-endian signed 16-bit integers.
+//
+// Parameters:
+//   - n: The number of integers.
+//
+// Returns:
+//   - []int: The integers.
+//   - error: An error if the entry is too short.
+func (r *terminfo_reader) shorts(n int) ([]int, error) {
+	b, err := r.take(2 * n)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int, n)
+
+	for i := range values {
+		values[i] = int(int16(binary.LittleEndian.Uint16(b[2*i:])))
+	}
+
+	return values, nil
+}
+
+// numbers reads n little-endian signed integers of the given width.
+//
+// Parameters:
+//   - n: The number of integers.
+//   - wide: True if the integers are 32 bits wide, false if they are 16 bits
+//     wide.
+//
+// Returns:
+//   - []int: The integers.
+//   - error: An error if the entry is too short.
+func (r *terminfo_reader) numbers(n int, wide bool) ([]int, error) {
+	if !wide {
+		return r.shorts(n)
+	}
+
+	b, err := r.take(4 * n)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int, n)
+
+	for i := range values {
+		values[i] = int(int32(binary.LittleEndian.Uint32(b[4*i:])))
+	}
+
+	return values, nil
+}
+
+// string_at reads the NUL-terminated string at an offset of a table.
+//
+// Parameters:
+//   - table: The table.
+//   - off: The offset.
+//
+// Returns:
+//   - string: The string.
+//   - int: The offset right after the terminating NUL.
+//   - error: An error if the offset is out of bounds.
+func string_at(table []byte, off int) (string, int, error) {
+	if off < 0 || off >= len(table) {
+		return "", 0, errors.New("string offset out of bounds")
+	}
+
+	end := bytes.IndexByte(table[off:], 0)
+	if end < 0 {
+		return "", 0, errors.New("unterminated string")
+	}
+
+	return string(table[off : off+end]), off + end + 1, nil
+}
+
+// ParseTerminfo parses a compiled terminfo entry, in either the legacy or the
+// extended number format, along with its extended capabilities.
+//
+// Parameters:
+//   - data: The compiled entry.
+//
+// Returns:
+//   - *Terminfo: The description of the terminal.
+//   - error: An error if the entry is malformed.
+//
+// Errors:
+//   - *errors.Err: If the entry is not a terminfo entry or is malformed.
+//
+// Capabilities that are absent or cancelled are not included.
+func ParseTerminfo(data []byte) (*Terminfo, error) {
+	r := &terminfo_reader{
+		data: data,
+	}
+
+	header, err := r.shorts(6)
+	if err != nil {
+		return nil, gerr.New(gers.BadParameter, "data is not a terminfo entry")
+	}
+
+	var wide bool
+
+	switch header[0] {
+	case magic_legacy:
+	case magic_extended:
+		wide = true
+	default:
+		return nil, gerr.New(gers.BadParameter, "data is not a terminfo entry")
+	}
+
+	for _, n := range header[1:] {
+		if n < 0 {
+			return nil, gerr.New(gers.BadParameter, "terminfo header has negative sizes")
+		}
+	}
+
+	ti := new_terminfo()
+
+	err = ti.parse_standard(r, header, wide)
+	if err != nil {
+		return nil, gerr.New(gers.BadParameter, err.Error())
+	}
+
+	r.align()
+
+	if r.pos < len(r.data) {
+		err := ti.parse_extended(r, wide)
+		if err != nil {
+			return nil, gerr.New(gers.BadParameter, err.Error())
+		}
+	}
+
+	return ti, nil
+}
+
+// parse_standard parses the standard sections of a compiled entry.
+//
+// Parameters:
+//   - r: The reader, positioned after the header.
+//   - header: The header.
+//   - wide: True if numbers are 32 bits wide.
+//
+// Returns:
+//   - error: An error if the sections are malformed.
+func (ti *Terminfo) parse_standard(r *terminfo_reader, header []int, wide bool) error {
+	names, err := r.take(header[1])
+	if err != nil {
+		return err
+	}
+
+	names = bytes.TrimRight(names, "\x00")
+	ti.Names = strings.Split(string(names), "|")
+
+	bools, err := r.take(header[2])
+	if err != nil {
+		return err
+	}
+
+	for i, b := range bools {
+		if b == 1 && i < len(bool_names) {
+			ti.Bools[bool_names[i]] = true
+		}
+	}
+
+	r.align()
+
+	nums, err := r.numbers(header[3], wide)
+	if err != nil {
+		return err
+	}
+
+	for i, n := range nums {
+		if n >= 0 && i < len(number_names) {
+			ti.Numbers[number_names[i]] = n
+		}
+	}
+
+	offsets, err := r.shorts(header[4])
+	if err != nil {
+		return err
+	}
+
+	table, err := r.take(header[5])
+	if err != nil {
+		return err
+	}
+
+	for i, off := range offsets {
+		if off < 0 || i >= len(string_names) {
+			continue
+		}
+
+		s, _, err := string_at(table, off)
+		if err != nil {
+			return err
+		}
+
+		ti.Strings[string_names[i]] = s
+	}
+
+	return nil
+}
+
+// parse_extended parses the extended capabilities of a compiled entry.
+//
+// Parameters:
+//   - r: The reader, positioned at the extended header.
+//   - wide: True if numbers are 32 bits wide.
+//
+// Returns:
+//   - error: An error if the section is malformed.
+func (ti *Terminfo) parse_extended(r *terminfo_reader, wide bool) error {
+	header, err := r.shorts(5)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range header {
+		if n < 0 {
+			return errors.New("extended header has negative sizes")
+		}
+	}
+
+	bool_count, num_count, str_count := header[0], header[1], header[2]
+
+	bools, err := r.take(bool_count)
+	if err != nil {
+		return err
+	}
+
+	r.align()
+
+	nums, err := r.numbers(num_count, wide)
+	if err != nil {
+		return err
+	}
+
+	offsets, err := r.shorts(str_count)
+	if err != nil {
+		return err
+	}
+
+	name_offsets, err := r.shorts(bool_count + num_count + str_count)
+	if err != nil {
+		return err
+	}
+
+	table, err := r.take(header[4])
+	if err != nil {
+		return err
+	}
+
+	// The names are stored right after the last string value.
+	var names_start int
+
+	values := make([]string, str_count)
+	present := make([]bool, str_count)
+
+	for i, off := range offsets {
+		if off < 0 {
+			continue
+		}
+
+		s, end, err := string_at(table, off)
+		if err != nil {
+			return err
+		}
+
+		values[i] = s
+		present[i] = true
+		names_start = max(names_start, end)
+	}
+
+	names := make([]string, len(name_offsets))
+
+	for i, off := range name_offsets {
+		s, _, err := string_at(table, names_start+off)
+		if err != nil {
+			return err
+		}
+
+		names[i] = s
+	}
+
+	for i, b := range bools {
+		if b == 1 {
+			ti.Bools[names[i]] = true
+		}
+	}
+
+	for i, n := range nums {
+		if n >= 0 {
+			ti.Numbers[names[bool_count+i]] = n
+		}
+	}
+
+	for i, s := range values {
+		if present[i] {
+			ti.Strings[names[bool_count+num_count+i]] = s
+		}
+	}
+
+	return nil
+}
+
+// terminfo_dirs returns the directories searched for compiled entries, in
+// order of precedence.
+//
+// Returns:
+//   - []string: The directories.
+func terminfo_dirs() []string {
+	var dirs []string
+
+	dir := os.Getenv("TERMINFO")
+	if dir != "" {
+		dirs = append(dirs, dir)
+	}
+
+	home, err := os.UserHomeDir()
+	if err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("TERMINFO_DIRS")) {
+		if dir == "" {
+			dir = "/usr/share/terminfo"
+		}
+
+		dirs = append(dirs, dir)
+	}
+
+	dirs = append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo")
+
+	return dirs
+}
+
+// LoadTerminfo loads the description of a terminal from the terminfo database.
+// The directories searched are $TERMINFO, ~/.terminfo, $TERMINFO_DIRS,
+// /etc/terminfo, /lib/terminfo and /usr/share/terminfo, in that order.
+//
+// Parameters:
+//   - term: The name of the terminal, such as "xterm-256color".
+//
+// Returns:
+//   - *Terminfo: The description of the terminal.
+//   - error: An error if the description could not be found or parsed.
+//
+// Errors:
+//   - *errors.Err: If the name is invalid, the entry is not found or it is
+//     malformed.
+func LoadTerminfo(term string) (*Terminfo, error) {
+	if term == "" || strings.ContainsAny(term, "/\\") || term == "." || term == ".." {
+		return nil, gerr.New(gers.BadParameter, "invalid terminal name "+strconv.Quote(term))
+	}
+
+	// Entries are stored under their first letter or, on some systems, under
+	// its hexadecimal code.
+	subdirs := []string{
+		term[:1],
+		strconv.FormatInt(int64(term[0]), 16),
+	}
+
+	for _, dir := range terminfo_dirs() {
+		for _, sub := range subdirs {
+			data, err := os.ReadFile(filepath.Join(dir, sub, term))
+			if err != nil {
+				continue
+			}
+
+			return ParseTerminfo(data)
+		}
+	}
+
+	return nil, gerr.New(gers.OperationFail, "no terminfo entry for "+strconv.Quote(term))
+}
+
+// FromEnv loads the description of the terminal named by $TERM. If $TERM is
+// "dumb", the description of a dumb terminal is returned. If $TERM is unset or
+// its description cannot be loaded, the generic ANSI description is returned
+// instead, as most terminals understand it.
+//
+// Returns:
+//   - *Terminfo: The description of the terminal. Never returns nil.
+func FromEnv() *Terminfo {
+	term := os.Getenv("TERM")
+	if term == "dumb" {
+		return Dumb()
+	} else if term == "" {
+		return ANSI()
+	}
+
+	ti, err := LoadTerminfo(term)
+	if err != nil {
+		return ANSI()
+	}
+
+	return ti
+}
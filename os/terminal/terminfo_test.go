@@ -0,0 +1,225 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// entry_builder builds compiled terminfo entries for tests.
+type entry_builder struct {
+	buf  bytes.Buffer
+	wide bool
+}
+
+func (b *entry_builder) shorts(values ...int) {
+	for _, v := range values {
+		_ = binary.Write(&b.buf, binary.LittleEndian, int16(v))
+	}
+}
+
+func (b *entry_builder) numbers(values ...int) {
+	if !b.wide {
+		b.shorts(values...)
+		return
+	}
+
+	for _, v := range values {
+		_ = binary.Write(&b.buf, binary.LittleEndian, int32(v))
+	}
+}
+
+func (b *entry_builder) align() {
+	if b.buf.Len()%2 == 1 {
+		b.buf.WriteByte(0)
+	}
+}
+
+// build_entry builds an entry with "am", "cols", "clear" and "cup" and, in
+// the extended section, "XT", "RGB" and "Ss".
+func build_entry(wide bool) []byte {
+	b := &entry_builder{wide: wide}
+
+	magic := magic_legacy
+	if wide {
+		magic = magic_extended
+	}
+
+	names := "test|test terminal\x00"
+	strs := "\x1b[H\x1b[2J\x00\x1b[%i%p1%d;%p2%dH\x00"
+
+	// clear is at index 5 and cup at index 10.
+	b.shorts(magic, len(names), 2, 1, 11, len(strs))
+	b.buf.WriteString(names)
+	b.buf.Write([]byte{0, 1})
+	b.align()
+	b.numbers(132)
+	b.shorts(-1, -1, -1, -1, -1, 0, -1, -1, -1, -1, 8)
+	b.buf.WriteString(strs)
+	b.align()
+
+	ext_strs := "\x1b[%p1%d q\x00"
+	ext_names := "XT\x00RGB\x00Ss\x00"
+
+	b.shorts(1, 1, 1, 6, len(ext_strs)+len(ext_names))
+	b.buf.WriteByte(1)
+	b.align()
+	b.numbers(8)
+	b.shorts(0)
+	b.shorts(0, 3, 7)
+	b.buf.WriteString(ext_strs)
+	b.buf.WriteString(ext_names)
+
+	return b.buf.Bytes()
+}
+
+// TestParseTerminfo tests the parsing of both number formats.
+func TestParseTerminfo(t *testing.T) {
+	for _, wide := range []bool{false, true} {
+		ti, err := ParseTerminfo(build_entry(wide))
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if len(ti.Names) != 2 || ti.Names[0] != "test" {
+			t.Errorf("expected %q, got %q instead", []string{"test", "test terminal"}, ti.Names)
+		}
+
+		if !ti.Flag("am") || ti.Flag("bw") {
+			t.Errorf("expected only am to be set, got %v instead", ti.Bools)
+		}
+
+		cols, ok := ti.Number("cols")
+		if !ok || cols != 132 {
+			t.Errorf("expected %d, got %d instead", 132, cols)
+		}
+
+		clear, _ := ti.String("clear")
+		if clear != "\x1b[H\x1b[2J" {
+			t.Errorf("expected %q, got %q instead", "\x1b[H\x1b[2J", clear)
+		}
+
+		if !ti.Flag("XT") {
+			t.Errorf("expected extended flag XT to be set")
+		}
+
+		rgb, _ := ti.Number("RGB")
+		if rgb != 8 {
+			t.Errorf("expected %d, got %d instead", 8, rgb)
+		}
+
+		ss, err := ti.Tparm("Ss", 2)
+		if err != nil || ss != "\x1b[2 q" {
+			t.Errorf("expected %q, got %q instead (%v)", "\x1b[2 q", ss, err)
+		}
+	}
+
+	_, err := ParseTerminfo([]byte("not terminfo"))
+	if err == nil {
+		t.Errorf("expected error, got nil instead")
+	}
+
+	_, err = ParseTerminfo(build_entry(false)[:20])
+	if err == nil {
+		t.Errorf("expected error, got nil instead")
+	}
+}
+
+// TestLoadTerminfo tests the lookup of entries through $TERMINFO.
+func TestLoadTerminfo(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.MkdirAll(filepath.Join(dir, "t"), 0755)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "t", "test"), build_entry(true), 0644)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	t.Setenv("TERMINFO", dir)
+	t.Setenv("TERM", "test")
+
+	ti := FromEnv()
+	if ti.Names[0] != "test" {
+		t.Errorf("expected %q, got %q instead", "test", ti.Names[0])
+	}
+
+	var buf bytes.Buffer
+
+	term := NewWithTerminfo(&buf, ti)
+
+	_ = term.MoveTo(4, 9)
+	if buf.String() != "\x1b[5;10H" {
+		t.Errorf("expected %q, got %q instead", "\x1b[5;10H", buf.String())
+	}
+
+	for _, name := range []string{"does-not-exist", ""} {
+		t.Setenv("TERM", name)
+
+		ti = FromEnv()
+		if ti.Names[0] != "ansi" {
+			t.Errorf("expected %q, got %q instead", "ansi", ti.Names[0])
+		}
+	}
+
+	t.Setenv("TERM", "dumb")
+
+	ti = FromEnv()
+	if ti.Names[0] != "dumb" {
+		t.Errorf("expected %q, got %q instead", "dumb", ti.Names[0])
+	}
+
+	err = NewWithTerminfo(&buf, ti).ClearScreen()
+	if _, ok := err.(*ErrNoCapability); !ok {
+		t.Errorf("expected *ErrNoCapability, got %v instead", err)
+	}
+}
+
+// TestTparm tests the evaluation of parameterized capabilities.
+func TestTparm(t *testing.T) {
+	const setaf = "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m"
+
+	tests := []struct {
+		format   string
+		params   []any
+		expected string
+	}{
+		{"\x1b[%i%p1%d;%p2%dH", []any{0, 0}, "\x1b[1;1H"},
+		{setaf, []any{1}, "\x1b[31m"},
+		{setaf, []any{10}, "\x1b[92m"},
+		{setaf, []any{100}, "\x1b[38;5;100m"},
+		{"%p1%:-4d|", []any{7}, "7   |"},
+		{"%p1%03x", []any{26}, "01a"},
+		{"%p1%s=%p1%l%d", []any{"abc"}, "abc=3"},
+		{"%p1%PA%gA%gA%+%d", []any{21}, "42"},
+		{"%'A'%c%%", nil, "A%"},
+		{"%?%p1%t%?%p2%tx%ey%;%ez%;", []any{1, 0}, "y"},
+		{"%?%p1%t%?%p2%tx%ey%;%ez%;", []any{0, 1}, "z"},
+	}
+
+	for _, test := range tests {
+		res, err := Tparm(test.format, test.params...)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v instead", test.format, err)
+			continue
+		}
+
+		if res != test.expected {
+			t.Errorf("%q: expected %q, got %q instead", test.format, test.expected, res)
+		}
+	}
+
+	_, err := Tparm("%p1%d", 1.5)
+	if err == nil {
+		t.Errorf("expected error, got nil instead")
+	}
+
+	if s := strip_padding("a$<5>b$<2*/>c$d"); s != "abc$d" {
+		t.Errorf("expected %q, got %q instead", "abc$d", s)
+	}
+}
@@ -0,0 +1,463 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+// tparm_value is a value of the stack of the tparm machine.
+type tparm_value struct {
+	// num is the value if it is a number.
+	num int
+
+	// str is the value if it is a string.
+	str string
+
+	// is_str is true if the value is a string.
+	is_str bool
+}
+
+// tparm_machine evaluates a parameterized capability.
+type tparm_machine struct {
+	// format is the capability being evaluated.
+	format string
+
+	// pos is the position of the next byte of the format.
+	pos int
+
+	// params are the parameters.
+	params [9]tparm_value
+
+	// stack is the stack of values.
+	stack []tparm_value
+
+	// vars are the variables, a-z followed by A-Z.
+	vars [52]tparm_value
+
+	// out is the output.
+	out strings.Builder
+}
+
+// push pushes a value.
+//
+// Parameters:
+//   - v: The value.
+func (m *tparm_machine) push(v tparm_value) {
+	m.stack = append(m.stack, v)
+}
+
+// push_num pushes a number.
+//
+// Parameters:
+//   - n: The number.
+func (m *tparm_machine) push_num(n int) {
+	m.stack = append(m.stack, tparm_value{num: n})
+}
+
+// push_bool pushes 1 if b is true and 0 otherwise.
+//
+// Parameters:
+//   - b: The boolean.
+func (m *tparm_machine) push_bool(b bool) {
+	if b {
+		m.push_num(1)
+	} else {
+		m.push_num(0)
+	}
+}
+
+// pop pops a value. An empty stack yields zero.
+//
+// Returns:
+//   - tparm_value: The value.
+func (m *tparm_machine) pop() tparm_value {
+	if len(m.stack) == 0 {
+		return tparm_value{}
+	}
+
+	v := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+
+	return v
+}
+
+// pop_num pops a number. Strings yield zero.
+//
+// Returns:
+//   - int: The number.
+func (m *tparm_machine) pop_num() int {
+	return m.pop().num
+}
+
+// var_index returns the index of a variable.
+//
+// Parameters:
+//   - c: The name of the variable.
+//
+// Returns:
+//   - int: The index.
+//   - bool: True if the name is valid, false otherwise.
+func var_index(c byte) (int, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return 26 + int(c-'A'), true
+	default:
+		return 0, false
+	}
+}
+
+// next reads the next byte of the format.
+//
+// Returns:
+//   - byte: The byte.
+//   - error: An error if the format ended.
+func (m *tparm_machine) next() (byte, error) {
+	if m.pos >= len(m.format) {
+		return 0, gerr.New(gers.BadParameter, "capability ends in the middle of an operation")
+	}
+
+	c := m.format[m.pos]
+	m.pos++
+
+	return c, nil
+}
+
+// skip skips the format up to the matching "%e" or "%;" of the current
+// conditional.
+//
+// Parameters:
+//   - stop_at_else: True if a matching "%e" stops the skip.
+func (m *tparm_machine) skip(stop_at_else bool) {
+	var level int
+
+	for m.pos < len(m.format) {
+		c := m.format[m.pos]
+		m.pos++
+
+		if c != '%' || m.pos >= len(m.format) {
+			continue
+		}
+
+		op := m.format[m.pos]
+		m.pos++
+
+		switch op {
+		case '?':
+			level++
+		case ';':
+			if level == 0 {
+				return
+			}
+
+			level--
+		case 'e':
+			if level == 0 && stop_at_else {
+				return
+			}
+		case '\'':
+			// Skip the character constant and its closing quote.
+			m.pos += 2
+		}
+	}
+}
+
+// printf evaluates a printf-like conversion, such as "%d" or "%:-3x".
+//
+// Returns:
+//   - error: An error if the conversion is malformed.
+func (m *tparm_machine) printf() error {
+	var spec strings.Builder
+
+	spec.WriteByte('%')
+
+	if m.pos < len(m.format) && m.format[m.pos] == ':' {
+		m.pos++
+	}
+
+	for m.pos < len(m.format) {
+		c := m.format[m.pos]
+		if !strings.ContainsRune("-+# .0123456789", rune(c)) {
+			break
+		}
+
+		spec.WriteByte(c)
+		m.pos++
+	}
+
+	verb, err := m.next()
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case 'd', 'o', 'x', 'X':
+		spec.WriteByte(verb)
+		fmt.Fprintf(&m.out, spec.String(), m.pop_num())
+	case 's':
+		spec.WriteByte(verb)
+
+		v := m.pop()
+		if !v.is_str {
+			v.str = strconv.Itoa(v.num)
+		}
+
+		fmt.Fprintf(&m.out, spec.String(), v.str)
+	default:
+		return gerr.New(gers.BadParameter, "unknown conversion %"+string(verb))
+	}
+
+	return nil
+}
+
+// binary evaluates a binary operator.
+//
+// Parameters:
+//   - op: The operator.
+func (m *tparm_machine) binary(op byte) {
+	b := m.pop_num()
+	a := m.pop_num()
+
+	switch op {
+	case '+':
+		m.push_num(a + b)
+	case '-':
+		m.push_num(a - b)
+	case '*':
+		m.push_num(a * b)
+	case '/':
+		if b == 0 {
+			m.push_num(0)
+		} else {
+			m.push_num(a / b)
+		}
+	case 'm':
+		if b == 0 {
+			m.push_num(0)
+		} else {
+			m.push_num(a % b)
+		}
+	case '&':
+		m.push_num(a & b)
+	case '|':
+		m.push_num(a | b)
+	case '^':
+		m.push_num(a ^ b)
+	case '=':
+		m.push_bool(a == b)
+	case '>':
+		m.push_bool(a > b)
+	case '<':
+		m.push_bool(a < b)
+	case 'A':
+		m.push_bool(a != 0 && b != 0)
+	case 'O':
+		m.push_bool(a != 0 || b != 0)
+	}
+}
+
+// step evaluates the operation that follows a '%'.
+//
+// Returns:
+//   - error: An error if the operation is malformed.
+func (m *tparm_machine) step() error {
+	if m.pos >= len(m.format) {
+		return gerr.New(gers.BadParameter, "capability ends in the middle of an operation")
+	}
+
+	op := m.format[m.pos]
+
+	switch op {
+	case ':', '#', ' ', '.', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'd', 'o', 'x', 'X', 's':
+		return m.printf()
+	}
+
+	m.pos++
+
+	switch op {
+	case '%':
+		m.out.WriteByte('%')
+	case 'c':
+		m.out.WriteByte(byte(m.pop_num()))
+	case 'p':
+		c, err := m.next()
+		if err != nil {
+			return err
+		}
+
+		if c < '1' || c > '9' {
+			return gerr.New(gers.BadParameter, "invalid parameter %p"+string(c))
+		}
+
+		m.push(m.params[c-'1'])
+	case 'P', 'g':
+		c, err := m.next()
+		if err != nil {
+			return err
+		}
+
+		idx, ok := var_index(c)
+		if !ok {
+			return gerr.New(gers.BadParameter, "invalid variable %"+string(op)+string(c))
+		}
+
+		if op == 'P' {
+			m.vars[idx] = m.pop()
+		} else {
+			m.push(m.vars[idx])
+		}
+	case '\'':
+		c, err := m.next()
+		if err != nil {
+			return err
+		}
+
+		end, err := m.next()
+		if err != nil {
+			return err
+		} else if end != '\'' {
+			return gerr.New(gers.BadParameter, "unterminated character constant")
+		}
+
+		m.push_num(int(c))
+	case '{':
+		end := strings.IndexByte(m.format[m.pos:], '}')
+		if end < 0 {
+			return gerr.New(gers.BadParameter, "unterminated integer constant")
+		}
+
+		n, err := strconv.Atoi(m.format[m.pos : m.pos+end])
+		if err != nil {
+			return gerr.New(gers.BadParameter, "invalid integer constant")
+		}
+
+		m.pos += end + 1
+		m.push_num(n)
+	case 'l':
+		m.push_num(len(m.pop().str))
+	case '+', '-', '*', '/', 'm', '&', '|', '^', '=', '>', '<', 'A', 'O':
+		m.binary(op)
+	case '!':
+		m.push_bool(m.pop_num() == 0)
+	case '~':
+		m.push_num(^m.pop_num())
+	case 'i':
+		m.params[0].num++
+		m.params[1].num++
+	case '?', ';':
+	case 't':
+		if m.pop_num() == 0 {
+			m.skip(true)
+		}
+	case 'e':
+		m.skip(false)
+	default:
+		return gerr.New(gers.BadParameter, "unknown operation %"+string(op))
+	}
+
+	return nil
+}
+
+// Tparm evaluates a parameterized capability, such as "cup", the way the
+// tparm function of curses does.
+//
+// Parameters:
+//   - format: The capability.
+//   - params: The parameters, at most nine. They must be of type int or
+//     string; missing parameters are zero.
+//
+// Returns:
+//   - string: The evaluated capability.
+//   - error: An error if the capability is malformed or a parameter is of an
+//     unsupported type.
+//
+// Errors:
+//   - *errors.Err: If there are too many parameters, one of them is of an
+//     unsupported type, or the capability is malformed.
+//
+// Static variables (%PA to %PZ) do not persist across calls.
+func Tparm(format string, params ...any) (string, error) {
+	if len(params) > 9 {
+		return "", gerr.New(gers.BadParameter, "capabilities take at most 9 parameters")
+	}
+
+	m := &tparm_machine{
+		format: format,
+	}
+
+	for i, p := range params {
+		switch p := p.(type) {
+		case int:
+			m.params[i] = tparm_value{num: p}
+		case string:
+			m.params[i] = tparm_value{str: p, is_str: true}
+		default:
+			return "", gerr.New(gers.BadParameter, fmt.Sprintf("parameter %d is of unsupported type %T", i+1, p))
+		}
+	}
+
+	for m.pos < len(m.format) {
+		c := m.format[m.pos]
+		m.pos++
+
+		if c != '%' {
+			m.out.WriteByte(c)
+			continue
+		}
+
+		err := m.step()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return m.out.String(), nil
+}
+
+// strip_padding removes the padding specifications, such as "$<5>" or
+// "$<2*/>", of an evaluated capability.
+//
+// Parameters:
+//   - s: The evaluated capability.
+//
+// Returns:
+//   - string: The capability without padding.
+func strip_padding(s string) string {
+	if !strings.Contains(s, "$<") {
+		return s
+	}
+
+	var builder strings.Builder
+
+	for {
+		start := strings.Index(s, "$<")
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(s[start:], '>')
+		if end < 0 {
+			break
+		}
+
+		spec := s[start+2 : start+end]
+		if spec == "" || strings.Trim(spec, "0123456789.*/") != "" {
+			builder.WriteString(s[:start+2])
+			s = s[start+2:]
+
+			continue
+		}
+
+		builder.WriteString(s[:start])
+		s = s[start+end+1:]
+	}
+
+	builder.WriteString(s)
+
+	return builder.String()
+}
@@ -0,0 +1,57 @@
+package terminal
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the structure filled by the TIOCGWINSZ ioctl.
+type winsize struct {
+	// Row is the number of rows.
+	Row uint16
+
+	// Col is the number of columns.
+	Col uint16
+
+	// Xpixel is the width in pixels.
+	Xpixel uint16
+
+	// Ypixel is the height in pixels.
+	Ypixel uint16
+}
+
+// is_tty checks whether a file descriptor refers to a terminal.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//
+// Returns:
+//   - bool: True if the file descriptor refers to a terminal, false otherwise.
+func is_tty(fd uintptr) bool {
+	var termios syscall.Termios
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+// size returns the size of the terminal behind a file descriptor.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//
+// Returns:
+//   - int: The number of columns.
+//   - int: The number of rows.
+//   - error: An error if the size could not be determined.
+func size(fd uintptr) (int, int, error) {
+	var ws winsize
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if errno == syscall.ENOTTY {
+		return 0, 0, NotTTY
+	} else if errno != 0 {
+		return 0, 0, errno
+	}
+
+	return int(ws.Col), int(ws.Row), nil
+}
@@ -0,0 +1,34 @@
+//go:build !linux
+
+package terminal
+
+import (
+	"runtime"
+
+	gers "github.com/PlayerR9/go-errors"
+	gerr "github.com/PlayerR9/go-errors/error"
+)
+
+// is_tty checks whether a file descriptor refers to a terminal.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//
+// Returns:
+//   - bool: Always false as the platform is yet to be supported.
+func is_tty(fd uintptr) bool {
+	return false
+}
+
+// size returns the size of the terminal behind a file descriptor.
+//
+// Parameters:
+//   - fd: The file descriptor.
+//
+// Returns:
+//   - int: Always 0.
+//   - int: Always 0.
+//   - error: An error as the platform is yet to be supported.
+func size(fd uintptr) (int, int, error) {
+	return 0, 0, gerr.New(gers.OperationFail, "terminal size is yet to be supported on "+runtime.GOOS)
+}
@@ -0,0 +1,331 @@
+package os
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+var (
+	// NoRuntimeDir is an error that is returned when $XDG_RUNTIME_DIR is not
+	// set, as the base directory specification defines no default for it.
+	// Functions must return this error as is and not wrap it as callers are
+	// expected to check for this error using ==.
+	NoRuntimeDir error
+)
+
+func init() {
+	NoRuntimeDir = errors.New("$XDG_RUNTIME_DIR is not set")
+}
+
+// BaseDir is a base directory of the XDG base directory specification.
+type BaseDir int
+
+const (
+	// ConfigHome is where user-specific configuration files are written.
+	// ($XDG_CONFIG_HOME, ~/.config by default.)
+	ConfigHome BaseDir = iota
+
+	// DataHome is where user-specific data files are written.
+	// ($XDG_DATA_HOME, ~/.local/share by default.)
+	DataHome
+
+	// CacheHome is where user-specific non-essential data is written.
+	// ($XDG_CACHE_HOME, ~/.cache by default.)
+	CacheHome
+
+	// StateHome is where user-specific state that should persist between
+	// restarts, such as history or logs, is written.
+	// ($XDG_STATE_HOME, ~/.local/state by default.)
+	StateHome
+
+	// RuntimeDir is where user-specific runtime files, such as sockets, are
+	// written. ($XDG_RUNTIME_DIR, no default.)
+	RuntimeDir
+)
+
+// String implements the fmt.Stringer interface.
+func (b BaseDir) String() string {
+	switch b {
+	case ConfigHome:
+		return "config"
+	case DataHome:
+		return "data"
+	case CacheHome:
+		return "cache"
+	case StateHome:
+		return "state"
+	case RuntimeDir:
+		return "runtime"
+	default:
+		return "BaseDir(" + strconv.Itoa(int(b)) + ")"
+	}
+}
+
+// xdg_env maps each base directory to its environment variable and its
+// default, relative to the home directory.
+var xdg_env = [...]struct {
+	name string
+	def  string
+}{
+	ConfigHome: {"XDG_CONFIG_HOME", ".config"},
+	DataHome:   {"XDG_DATA_HOME", ".local/share"},
+	CacheHome:  {"XDG_CACHE_HOME", ".cache"},
+	StateHome:  {"XDG_STATE_HOME", ".local/state"},
+	RuntimeDir: {"XDG_RUNTIME_DIR", ""},
+}
+
+// Path returns the path of the base directory. As required by the
+// specification, a relative path in the environment variable is ignored.
+//
+// Returns:
+//   - string: The path.
+//   - error: An error if the path could not be resolved.
+//
+// Errors:
+//   - NoRuntimeDir: If the base directory is RuntimeDir and $XDG_RUNTIME_DIR
+//     is not set.
+//   - *errors.Err: If the base directory is not valid.
+//   - any error returned by os.UserHomeDir.
+func (b BaseDir) Path() (string, error) {
+	if b < ConfigHome || b > RuntimeDir {
+		return "", gers.NewErrInvalidParameter("base directory is not valid")
+	}
+
+	env := xdg_env[b]
+
+	dir := os.Getenv(env.name)
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir), nil
+	}
+
+	if b == RuntimeDir {
+		return "", NoRuntimeDir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, env.def), nil
+}
+
+// AppDir returns the directory of an application inside the base directory
+// and creates it, along with any missing parent, if it does not exist. The
+// directories are only accessible by the user, as the specification requires
+// for the runtime directory and as suits files that may hold secrets.
+//
+// Parameters:
+//   - app: The name of the application.
+//
+// Returns:
+//   - string: The path of the directory.
+//   - error: An error if the directory could not be resolved or created.
+//
+// Errors:
+//   - *errors.Err: If the name is empty or is not a single path element.
+//   - any error returned by Path or the file system.
+func (b BaseDir) AppDir(app string) (string, error) {
+	err := check_app_name(app)
+	if err != nil {
+		return "", err
+	}
+
+	base, err := b.Path()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(base, app)
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// check_app_name checks that the name of an application is a single path
+// element.
+//
+// Parameters:
+//   - app: The name of the application.
+//
+// Returns:
+//   - error: An error if the name is not valid.
+func check_app_name(app string) error {
+	if app == "" {
+		return gers.NewErrInvalidParameter("app must not be empty")
+	} else if app == "." || app == ".." || strings.ContainsAny(app, `/\`) {
+		return gers.NewErrInvalidParameter("app must be a single path element")
+	}
+
+	return nil
+}
+
+// ConfigDirs returns the directories searched for configuration files after
+// ConfigHome, in order of preference. ($XDG_CONFIG_DIRS, /etc/xdg by default.)
+//
+// Returns:
+//   - []string: The directories. Never returns nil.
+func ConfigDirs() []string {
+	return search_dirs("XDG_CONFIG_DIRS", []string{"/etc/xdg"})
+}
+
+// DataDirs returns the directories searched for data files after DataHome, in
+// order of preference. ($XDG_DATA_DIRS, /usr/local/share and /usr/share by
+// default.)
+//
+// Returns:
+//   - []string: The directories. Never returns nil.
+func DataDirs() []string {
+	return search_dirs("XDG_DATA_DIRS", []string{"/usr/local/share", "/usr/share"})
+}
+
+// search_dirs returns the directories of a colon-separated environment
+// variable. Relative directories are ignored.
+//
+// Parameters:
+//   - name: The name of the variable.
+//   - def: The directories to use if the variable holds none.
+//
+// Returns:
+//   - []string: The directories.
+func search_dirs(name string, def []string) []string {
+	var dirs []string
+
+	for _, dir := range filepath.SplitList(os.Getenv(name)) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+
+	if len(dirs) == 0 {
+		return def
+	}
+
+	return dirs
+}
+
+// FindConfig finds a configuration file of an application, looking first in
+// ConfigHome and then in each of ConfigDirs.
+//
+// Parameters:
+//   - app: The name of the application.
+//   - name: The path of the file, relative to the directory of the
+//     application.
+//
+// Returns:
+//   - string: The path of the first file found.
+//   - error: An error if the file was not found.
+//
+// Errors:
+//   - *errors.Err: If the name of the application is not valid.
+//   - *fs.PathError: If the file was not found, wrapping fs.ErrNotExist.
+func FindConfig(app, name string) (string, error) {
+	return find_file(ConfigHome, ConfigDirs(), app, name)
+}
+
+// FindData finds a data file of an application, looking first in DataHome
+// and then in each of DataDirs.
+//
+// Parameters:
+//   - app: The name of the application.
+//   - name: The path of the file, relative to the directory of the
+//     application.
+//
+// Returns:
+//   - string: The path of the first file found.
+//   - error: An error if the file was not found.
+//
+// Errors:
+//   - *errors.Err: If the name of the application is not valid.
+//   - *fs.PathError: If the file was not found, wrapping fs.ErrNotExist.
+func FindData(app, name string) (string, error) {
+	return find_file(DataHome, DataDirs(), app, name)
+}
+
+// find_file finds the file of an application in a base directory and then in
+// the search directories.
+//
+// Parameters:
+//   - home: The base directory.
+//   - dirs: The search directories.
+//   - app: The name of the application.
+//   - name: The path of the file.
+//
+// Returns:
+//   - string: The path of the first file found.
+//   - error: An error if the file was not found.
+func find_file(home BaseDir, dirs []string, app, name string) (string, error) {
+	err := check_app_name(app)
+	if err != nil {
+		return "", err
+	}
+
+	base, err := home.Path()
+	if err == nil {
+		dirs = append([]string{base}, dirs...)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, app, name)
+
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+	}
+
+	return "", &fs.PathError{Op: "find", Path: filepath.Join(app, name), Err: fs.ErrNotExist}
+}
+
+// ExpandPath expands a user-supplied path: a leading "~" or "~user" is
+// replaced with the home directory, and then "$VAR" and "${VAR}" with the
+// value of the environment variable, or nothing if it is unset. Values of
+// variables are not expanded again, even if they start with "~".
+//
+// Parameters:
+//   - path: The path.
+//
+// Returns:
+//   - string: The expanded path.
+//   - error: An error if a home directory could not be found.
+//
+// Errors:
+//   - any error returned by os.UserHomeDir or user.Lookup.
+func ExpandPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return os.ExpandEnv(path), nil
+	}
+
+	name, rest, _ := strings.Cut(path[1:], "/")
+
+	var home string
+
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		home = dir
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+
+		home = u.HomeDir
+	}
+
+	return filepath.Join(home, os.ExpandEnv(rest)), nil
+}
@@ -0,0 +1,158 @@
+package os
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestBaseDirPath tests the defaults and the overrides of the base
+// directories.
+func TestBaseDirPath(t *testing.T) {
+	home := t.TempDir()
+
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "/custom/config")
+	t.Setenv("XDG_DATA_HOME", "relative/data")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	tests := []struct {
+		base     BaseDir
+		expected string
+	}{
+		{ConfigHome, "/custom/config"},
+		{DataHome, filepath.Join(home, ".local/share")},
+		{CacheHome, filepath.Join(home, ".cache")},
+		{StateHome, filepath.Join(home, ".local/state")},
+	}
+
+	for _, test := range tests {
+		path, err := test.base.Path()
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if path != test.expected {
+			t.Errorf("expected %q, got %q instead", test.expected, path)
+		}
+	}
+
+	_, err := RuntimeDir.Path()
+	if err != NoRuntimeDir {
+		t.Errorf("expected %v, got %v instead", NoRuntimeDir, err)
+	}
+}
+
+// TestBaseDirString tests the names of the base directories.
+func TestBaseDirString(t *testing.T) {
+	if StateHome.String() != "state" {
+		t.Errorf("expected %q, got %q instead", "state", StateHome.String())
+	}
+
+	if BaseDir(42).String() != "BaseDir(42)" {
+		t.Errorf("expected %q, got %q instead", "BaseDir(42)", BaseDir(42).String())
+	}
+}
+
+// TestAppDir tests that the directory of an application is created with the
+// right permissions.
+func TestAppDir(t *testing.T) {
+	base := t.TempDir()
+
+	t.Setenv("XDG_STATE_HOME", filepath.Join(base, "state"))
+
+	dir, err := StateHome.AppDir("tool")
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("expected %v, got %v instead", fs.FileMode(0700), info.Mode().Perm())
+	}
+
+	_, err = StateHome.AppDir("../escape")
+	if err == nil {
+		t.Errorf("expected an error, got nil instead")
+	}
+}
+
+// TestFindConfig tests the search across $XDG_CONFIG_DIRS.
+func TestFindConfig(t *testing.T) {
+	base := t.TempDir()
+
+	home := filepath.Join(base, "home")
+	first := filepath.Join(base, "first")
+	second := filepath.Join(base, "second")
+
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", first+string(filepath.ListSeparator)+"relative"+string(filepath.ListSeparator)+second)
+
+	expected := []string{first, second}
+	if dirs := ConfigDirs(); !slices.Equal(dirs, expected) {
+		t.Errorf("expected %v, got %v instead", expected, dirs)
+	}
+
+	for _, dir := range []string{first, second} {
+		err := os.MkdirAll(filepath.Join(dir, "tool"), 0755)
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+	}
+
+	err := os.WriteFile(filepath.Join(second, "tool", "tool.conf"), nil, 0644)
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	path, err := FindConfig("tool", "tool.conf")
+	if err != nil {
+		t.Fatalf("expected no error, got %v instead", err)
+	}
+
+	if path != filepath.Join(second, "tool", "tool.conf") {
+		t.Errorf("expected %q, got %q instead", filepath.Join(second, "tool", "tool.conf"), path)
+	}
+
+	_, err = FindConfig("tool", "missing.conf")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %v, got %v instead", fs.ErrNotExist, err)
+	}
+}
+
+// TestExpandPath tests the expansion of ~ and environment variables.
+func TestExpandPath(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	t.Setenv("PROJECT", "demo")
+	t.Setenv("TILDE", "~/elsewhere")
+
+	tests := map[string]string{
+		"~":                  "/home/user",
+		"~/notes":            "/home/user/notes",
+		"$HOME/src/$PROJECT": "/home/user/src/demo",
+		"/srv/${PROJECT}":    "/srv/demo",
+		"relative/~":         "relative/~",
+		"$TILDE/notes":       "~/elsewhere/notes",
+		"~/$PROJECT":         "/home/user/demo",
+	}
+
+	for path, expected := range tests {
+		got, err := ExpandPath(path)
+		if err != nil {
+			t.Fatalf("expected no error, got %v instead", err)
+		}
+
+		if got != expected {
+			t.Errorf("expected %q, got %q instead", expected, got)
+		}
+	}
+}